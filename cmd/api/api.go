@@ -13,18 +13,49 @@ import (
 	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/chi/v5/middleware"
 	"github.com/go-chi/cors"
+	"github.com/umeh-promise/ecommerce/internal/audit"
+	"github.com/umeh-promise/ecommerce/internal/events"
+	"github.com/umeh-promise/ecommerce/internal/idempotency"
+	"github.com/umeh-promise/ecommerce/internal/jobs"
+	"github.com/umeh-promise/ecommerce/internal/mailer"
+	"github.com/umeh-promise/ecommerce/internal/payments"
+	"github.com/umeh-promise/ecommerce/internal/pubsub"
+	"github.com/umeh-promise/ecommerce/internal/scheduler"
+	"github.com/umeh-promise/ecommerce/internal/services/addresses"
+	"github.com/umeh-promise/ecommerce/internal/services/admin"
+	"github.com/umeh-promise/ecommerce/internal/services/files"
+	"github.com/umeh-promise/ecommerce/internal/services/guest"
+	"github.com/umeh-promise/ecommerce/internal/services/ledger"
+	"github.com/umeh-promise/ecommerce/internal/services/notifications"
+	"github.com/umeh-promise/ecommerce/internal/services/orders"
 	"github.com/umeh-promise/ecommerce/internal/services/products"
+	"github.com/umeh-promise/ecommerce/internal/services/promotions"
+	"github.com/umeh-promise/ecommerce/internal/services/returns"
+	"github.com/umeh-promise/ecommerce/internal/services/reviews"
+	"github.com/umeh-promise/ecommerce/internal/services/sellers"
+	"github.com/umeh-promise/ecommerce/internal/services/shipments"
+	"github.com/umeh-promise/ecommerce/internal/services/shipping"
+	"github.com/umeh-promise/ecommerce/internal/services/tax"
 	"github.com/umeh-promise/ecommerce/internal/services/user"
+	"github.com/umeh-promise/ecommerce/internal/services/webhooks"
+	"github.com/umeh-promise/ecommerce/internal/services/wishlists"
+	"github.com/umeh-promise/ecommerce/internal/storage"
 	"github.com/umeh-promise/ecommerce/utils"
 )
 
+// jobDrainTimeout is how long shutdown waits for running jobs. Jobs cut off
+// are retried by another instance or after a restart.
+const jobDrainTimeout = 30 * time.Second
+
 type APIServer struct {
 	addr string
 	db   *sql.DB
+	// dbAddr opens the extra connection used to LISTEN for notifications.
+	dbAddr string
 }
 
-func NewAPIServer(addr string, db *sql.DB) *APIServer {
-	return &APIServer{addr: addr, db: db}
+func NewAPIServer(addr string, db *sql.DB, dbAddr string) *APIServer {
+	return &APIServer{addr: addr, db: db, dbAddr: dbAddr}
 }
 
 func (s *APIServer) mount(routerGroups ...func(r chi.Router)) *chi.Mux {
@@ -34,15 +65,19 @@ func (s *APIServer) mount(routerGroups ...func(r chi.Router)) *chi.Mux {
 	router.Use(middleware.Logger)
 	router.Use(middleware.Recoverer)
 	router.Use(cors.Handler(cors.Options{
-		AllowedOrigins:   []string{utils.GetString("CORS_ALLOWED_ORIGIN", "https://localhost:4000")},
-		AllowedMethods:   []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"},
-		AllowedHeaders:   []string{"Accept", "Authorization", "Content-Type", "X-CSRF-Token"},
-		ExposedHeaders:   []string{"Link"},
+		AllowedOrigins: []string{utils.GetString("CORS_ALLOWED_ORIGIN", "https://localhost:4000")},
+		AllowedMethods: []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"},
+		AllowedHeaders: []string{
+			"Accept", "Authorization", "Content-Type", "X-CSRF-Token", "If-Match", "If-None-Match", "Last-Event-ID",
+			guest.HeaderName, idempotency.HeaderName,
+		},
+		ExposedHeaders:   []string{"Link", "ETag", idempotency.ReplayedHeader},
 		AllowCredentials: false,
 		MaxAge:           300,
 	}))
 	// router.Use(app.RateLimitMiddleware)
-	router.Use(middleware.Timeout(60 * time.Second))
+	router.Use(utils.Timeout(60 * time.Second))
+	router.Use(idempotency.Middleware(idempotency.NewStore(s.db)))
 
 	router.Route("/v1", func(router chi.Router) {
 		for _, subRouter := range routerGroups {
@@ -54,17 +89,164 @@ func (s *APIServer) mount(routerGroups ...func(r chi.Router)) *chi.Mux {
 }
 
 func (s *APIServer) Run() error {
+	publicBaseURL := utils.GetString("PUBLIC_BASE_URL", "http://localhost:8080")
+
+	blobs, err := storage.New(storage.Config{
+		Backend:       utils.GetString("STORAGE_BACKEND", "local"),
+		PublicBaseURL: publicBaseURL,
+		LocalDir:      utils.GetString("STORAGE_LOCAL_DIR", "./uploads"),
+		S3Endpoint:    utils.GetString("S3_ENDPOINT", "http://localhost:9000"),
+		S3Bucket:      utils.GetString("S3_BUCKET", "ecommerce"),
+		S3Region:      utils.GetString("S3_REGION", "us-east-1"),
+		S3AccessKey:   utils.GetString("S3_ACCESS_KEY", ""),
+		S3SecretKey:   utils.GetString("S3_SECRET_KEY", ""),
+	})
+	if err != nil {
+		return err
+	}
+	fileHandler := files.NewHandler(blobs)
+
+	mail, err := mailer.New(mailer.Config{
+		Backend:  utils.GetString("MAILER_BACKEND", "log"),
+		From:     utils.GetString("MAIL_FROM", "no-reply@localhost"),
+		SMTPHost: utils.GetString("SMTP_HOST", ""),
+		SMTPPort: utils.GetInt("SMTP_PORT", 587),
+		SMTPUser: utils.GetString("SMTP_USERNAME", ""),
+		SMTPPass: utils.GetString("SMTP_PASSWORD", ""),
+		FileDir:  utils.GetString("MAIL_FILE_DIR", "./mail"),
+	})
+	if err != nil {
+		return err
+	}
+
+	jobQueue := jobs.NewQueue(s.db)
+	jobRunner := jobs.NewRunner(s.db, utils.GetInt("JOB_CONCURRENCY", 10))
+	jobs.Register(jobRunner, mailer.SendJob, 5, func(ctx context.Context, message mailer.Message) error {
+		return mail.Send(ctx, message)
+	})
+
+	guestHandler := guest.NewHandler()
+
+	auditStore := audit.NewStore(s.db)
+
 	userStore := user.NewStore(s.db)
-	userHandler := user.NewHandler(userStore)
+	userHandler := user.NewHandler(userStore, blobs, auditStore)
+
+	addressStore := addresses.NewStore(s.db)
+	addressHandler := addresses.NewHandler(addressStore)
 
 	productStore := products.NewStore(s.db)
-	productHandler := products.NewHandler(productStore)
+	productHandler := products.NewHandler(productStore, blobs, auditStore)
+
+	promotionStore := promotions.NewStore(s.db)
+	promotionHandler := promotions.NewHandler(promotionStore, productStore)
+
+	shippingStore := shipping.NewStore(s.db)
+	shippingProviders := []shipping.RateProvider{shipping.NewTableRateProvider(shippingStore)}
+	shippingHandler := shipping.NewHandler(shippingStore, shippingProviders, productStore, addressStore)
+
+	taxStore := tax.NewStore(s.db)
+	taxHandler := tax.NewHandler(taxStore)
+
+	ledgerStore := ledger.NewStore(s.db)
+	ledgerHandler := ledger.NewHandler(ledgerStore)
+
+	broker := pubsub.NewBroker(s.db, s.dbAddr)
+
+	orderStore := orders.NewStore(s.db)
+	orderHandler := orders.NewHandler(orderStore, productStore, promotionStore, ledgerStore, addressStore, shippingProviders, tax.NewRulesCalculator(taxStore), broker, auditStore)
+
+	gateway, err := payments.New(utils.GetString("PAYMENT_PROVIDER", "manual"))
+	if err != nil {
+		return err
+	}
+
+	returnStore := returns.NewStore(s.db)
+	returnHandler := returns.NewHandler(returnStore, orderStore, ledgerStore, gateway, jobQueue)
+	jobs.Register(jobRunner, returns.RefundJob, 5, returns.IssueRefund(returnStore, gateway))
+
+	shipmentStore := shipments.NewStore(s.db)
+	shipmentHandler := shipments.NewHandler(shipmentStore, orderStore)
+
+	reviewStore := reviews.NewStore(s.db)
+	reviewHandler := reviews.NewHandler(reviewStore, productStore)
+
+	wishlistStore := wishlists.NewStore(s.db)
+	wishlistHandler := wishlists.NewHandler(wishlistStore, productStore)
+
+	sellerStore := sellers.NewStore(s.db)
+	sellerHandler := sellers.NewHandler(sellerStore, productStore)
+
+	webhookStore := webhooks.NewStore(s.db)
+	webhookHandler := webhooks.NewHandler(webhookStore)
+
+	notificationStore := notifications.NewStore(s.db)
+	notificationHandler := notifications.NewHandler(notificationStore)
+	notifier := notifications.NewNotifier(notificationStore, userStore, orderStore, jobQueue, publicBaseURL)
+	jobs.Register(jobRunner, notifications.SendJob, 5, notifications.Send(notificationStore, mail))
+
+	idempotencyStore := idempotency.NewStore(s.db)
+
+	tasks := scheduler.New(s.db)
+	err = errors.Join(
+		tasks.Register("expire-unpaid-orders", utils.GetString("ORDER_EXPIRY_SCHEDULE", "*/5 * * * *"),
+			orders.ExpireUnpaidOrders(orderStore, time.Duration(utils.GetInt("ORDER_PAYMENT_TTL_MINUTES", 60))*time.Minute)),
+		tasks.Register("purge-idempotency-keys", "@hourly", func(ctx context.Context) error {
+			_, err := idempotencyStore.PurgeExpired(ctx)
+			return err
+		}),
+	)
+	if err != nil {
+		return err
+	}
+	schedulerHandler := scheduler.NewHandler(tasks)
+
+	adminStore := admin.NewStore(s.db)
+	adminHandler := admin.NewHandler(adminStore, userStore, productStore, orderStore, ledgerStore, auditStore)
 
 	handler := s.mount(
+		fileHandler.RegisterRoute(),
 		userHandler.RegisterRoute(),
+		guestHandler.RegisterRoute(),
+		addressHandler.RegisterRoute(userHandler),
 		productHandler.RegisterRoute(userHandler),
+		promotionHandler.RegisterRoute(userHandler),
+		orderHandler.RegisterRoute(userHandler),
+		reviewHandler.RegisterRoute(userHandler),
+		wishlistHandler.RegisterRoute(userHandler),
+		sellerHandler.RegisterRoute(userHandler),
+		ledgerHandler.RegisterRoute(userHandler),
+		shippingHandler.RegisterRoute(userHandler),
+		shipmentHandler.RegisterRoute(userHandler),
+		taxHandler.RegisterRoute(userHandler),
+		returnHandler.RegisterRoute(userHandler),
+		webhookHandler.RegisterRoute(userHandler),
+		schedulerHandler.RegisterRoute(userHandler),
+		notificationHandler.RegisterRoute(userHandler),
+		adminHandler.RegisterRoute(userHandler),
 	)
 
+	// Background workers stop once the server has shut down.
+	background, stopBackground := context.WithCancel(context.Background())
+	defer stopBackground()
+
+	dispatcher := events.NewDispatcher(s.db)
+	dispatcher.Subscribe(events.All, webhooks.Fanout(webhookStore, productStore))
+	for _, eventType := range notifications.EventTypes {
+		dispatcher.Subscribe(eventType, notifier.Handle)
+	}
+	for _, eventType := range orders.StreamEventTypes {
+		dispatcher.Subscribe(eventType, orders.NotifyStreams(broker))
+	}
+	go dispatcher.Run(background)
+
+	webhookWorker := webhooks.NewWorker(webhookStore, utils.GetString("WEBHOOK_ALLOW_PRIVATE", "false") == "true")
+	go webhookWorker.Run(background)
+
+	go jobRunner.Run(background)
+	go tasks.Run(background)
+	go broker.Run(background)
+
 	server := &http.Server{
 		Addr:         s.addr,
 		Handler:      handler,
@@ -72,6 +254,8 @@ func (s *APIServer) Run() error {
 		ReadTimeout:  time.Second * 10,
 		IdleTimeout:  time.Minute,
 	}
+	// Shutdown waits for open requests, so end the event streams.
+	server.RegisterOnShutdown(broker.Close)
 
 	utils.Logger.Info("Server has started at ", s.addr)
 
@@ -79,10 +263,10 @@ func (s *APIServer) Run() error {
 
 	go func() {
 		quit := make(chan os.Signal, 1)
-		s := <-quit
-
 		signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
 
+		s := <-quit
+
 		ctx, cancel := context.WithTimeout(context.Background(), utils.QueryTimeout)
 		defer cancel()
 
@@ -90,7 +274,7 @@ func (s *APIServer) Run() error {
 		shutdown <- server.Shutdown(ctx)
 	}()
 
-	err := server.ListenAndServe()
+	err = server.ListenAndServe()
 	if !errors.Is(err, http.ErrServerClosed) {
 		return err
 	}
@@ -99,6 +283,16 @@ func (s *APIServer) Run() error {
 		return err
 	}
 
+	// Stop claiming new work and give running jobs a chance to finish.
+	stopBackground()
+
+	drainCtx, cancelDrain := context.WithTimeout(context.Background(), jobDrainTimeout)
+	defer cancelDrain()
+
+	if err := jobRunner.Drain(drainCtx); err != nil {
+		utils.Logger.Warnw("jobs were still running at shutdown", "error", err.Error())
+	}
+
 	utils.Logger.Info("Server existed", "addr ", s.addr)
 
 	return nil
@@ -25,7 +25,7 @@ func main() {
 	defer db.Close()
 	utils.Logger.Info("DB connected successfully")
 
-	sever := api.NewAPIServer(":8080", db)
+	sever := api.NewAPIServer(":8080", db, config.Addr)
 	if err := sever.Run(); err != nil {
 		log.Fatal(err)
 	}
@@ -0,0 +1,64 @@
+package audit
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"time"
+)
+
+// canonical re-encodes a JSON document so that the same value always has
+// the same bytes. Postgres stores before and after as jsonb, which drops
+// the original formatting and key order, so hashes are taken over this
+// form on both write and verify.
+func canonical(document json.RawMessage) (json.RawMessage, error) {
+	if len(document) == 0 {
+		return nil, nil
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(document))
+	decoder.UseNumber()
+
+	var value any
+	if err := decoder.Decode(&value); err != nil {
+		return nil, err
+	}
+
+	return json.Marshal(value)
+}
+
+// computeHash hashes the entry's fields together with the previous entry's
+// hash.
+func (e *Entry) computeHash() (string, error) {
+	before, err := canonical(e.Before)
+	if err != nil {
+		return "", err
+	}
+
+	after, err := canonical(e.After)
+	if err != nil {
+		return "", err
+	}
+
+	material, err := json.Marshal([]any{
+		e.PrevHash,
+		e.ID,
+		e.ActorID,
+		e.Action,
+		e.TargetType,
+		e.TargetID,
+		before,
+		after,
+		e.RequestID,
+		e.IP,
+		e.CreatedAt.UTC().Format(time.RFC3339),
+	})
+	if err != nil {
+		return "", err
+	}
+
+	sum := sha256.Sum256(material)
+
+	return hex.EncodeToString(sum[:]), nil
+}
@@ -0,0 +1,106 @@
+package audit
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/umeh-promise/ecommerce/utils"
+)
+
+// Entry records one change: who made it, to what, and the target before
+// and after. Before is empty for creations and After for deletions.
+type Entry struct {
+	ID         int64           `json:"id"`
+	ActorID    string          `json:"actor_id"`
+	Action     string          `json:"action"`
+	TargetType string          `json:"target_type"`
+	TargetID   string          `json:"target_id"`
+	Before     json.RawMessage `json:"before"`
+	After      json.RawMessage `json:"after"`
+	RequestID  string          `json:"request_id"`
+	IP         string          `json:"ip"`
+	CreatedAt  time.Time       `json:"created_at"`
+	// PrevHash and Hash chain the entry to the one before it. Hash is empty
+	// for entries written before chaining.
+	PrevHash string `json:"prev_hash"`
+	Hash     string `json:"hash"`
+}
+
+// Filter narrows GetEntries. From is inclusive and To exclusive.
+type Filter struct {
+	ActorID    string `validate:"omitempty,uuid"`
+	Action     string `validate:"max=100"`
+	TargetType string `validate:"max=50"`
+	TargetID   string `validate:"max=100"`
+	From       *time.Time
+	To         *time.Time
+	Limit      int `validate:"gte=1,lte=200"`
+	Offset     int `validate:"gte=0"`
+}
+
+// Verification is the result of checking the hash chain. BrokenAt is the
+// first entry whose hash or link to its predecessor doesn't match.
+type Verification struct {
+	Valid    bool   `json:"valid"`
+	Checked  int    `json:"checked"`
+	BrokenAt *int64 `json:"broken_at"`
+}
+
+type AuditStore interface {
+	// Log appends the entry to the chain.
+	Log(context.Context, *Entry) error
+	// GetEntries returns matching entries, newest first.
+	GetEntries(context.Context, *Filter) ([]Entry, error)
+	Verify(context.Context) (*Verification, error)
+}
+
+// NewEntry describes a change made by actorID while serving r. before and
+// after are stored as JSON; pass nil when there is no such state. actorID
+// is empty for anonymous requests such as guest checkout.
+func NewEntry(r *http.Request, actorID, action, targetType, targetID string, before, after any) (*Entry, error) {
+	entry := &Entry{
+		ActorID:    actorID,
+		Action:     action,
+		TargetType: targetType,
+		TargetID:   targetID,
+		RequestID:  middleware.GetReqID(r.Context()),
+		IP:         r.RemoteAddr,
+	}
+
+	var err error
+
+	if entry.Before, err = snapshot(before); err != nil {
+		return nil, err
+	}
+
+	if entry.After, err = snapshot(after); err != nil {
+		return nil, err
+	}
+
+	return entry, nil
+}
+
+// Record logs a change that has already been made. The change stands even
+// if it can't be recorded, so a failure is logged rather than returned.
+func Record(store AuditStore, r *http.Request, actorID, action, targetType, targetID string, before, after any) {
+	entry, err := NewEntry(r, actorID, action, targetType, targetID, before, after)
+	if err == nil {
+		err = store.Log(context.WithoutCancel(r.Context()), entry)
+	}
+
+	if err != nil {
+		utils.Logger.Errorw("failed to write audit log",
+			"action", action, "target_type", targetType, "target_id", targetID, "error", err.Error())
+	}
+}
+
+func snapshot(state any) (json.RawMessage, error) {
+	if state == nil {
+		return nil, nil
+	}
+
+	return json.Marshal(state)
+}
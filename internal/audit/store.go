@@ -0,0 +1,224 @@
+package audit
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/umeh-promise/ecommerce/utils"
+)
+
+// verifyBatchSize is how many entries Verify reads per query.
+const verifyBatchSize = 500
+
+type Store struct {
+	db *sql.DB
+}
+
+func NewStore(db *sql.DB) *Store {
+	return &Store{db: db}
+}
+
+// Log appends the entry, linking it to the latest one. Writers are
+// serialised with an advisory lock so two entries never share a
+// predecessor.
+func (s *Store) Log(ctx context.Context, entry *Entry) error {
+	query := `
+		INSERT INTO audit_log (id, actor_id, action, target_type, target_id, before, after, request_id, ip, created_at, prev_hash, hash)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)
+	`
+
+	var actorID any
+	if entry.ActorID != "" {
+		actorID = entry.ActorID
+	}
+
+	var err error
+
+	if entry.Before, err = canonical(entry.Before); err != nil {
+		return err
+	}
+
+	if entry.After, err = canonical(entry.After); err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, utils.QueryTimeout)
+	defer cancel()
+
+	return utils.WithTx(ctx, s.db, func(tx *sql.Tx) error {
+		if _, err := tx.ExecContext(ctx, `SELECT pg_advisory_xact_lock(hashtext('audit_log'))`); err != nil {
+			return err
+		}
+
+		err := tx.QueryRowContext(ctx, `
+			SELECT COALESCE((SELECT hash FROM audit_log WHERE hash IS NOT NULL ORDER BY id DESC LIMIT 1), '')
+		`).Scan(&entry.PrevHash)
+		if err != nil {
+			return err
+		}
+
+		err = tx.QueryRowContext(ctx, `SELECT nextval(pg_get_serial_sequence('audit_log', 'id'))`).Scan(&entry.ID)
+		if err != nil {
+			return err
+		}
+
+		// The column keeps whole seconds, so hash what will be read back.
+		entry.CreatedAt = time.Now().UTC().Truncate(time.Second)
+
+		if entry.Hash, err = entry.computeHash(); err != nil {
+			return err
+		}
+
+		_, err = tx.ExecContext(ctx, query,
+			entry.ID, actorID, entry.Action, entry.TargetType, entry.TargetID,
+			nullJSON(entry.Before), nullJSON(entry.After), entry.RequestID, entry.IP,
+			entry.CreatedAt, entry.PrevHash, entry.Hash,
+		)
+		return err
+	})
+}
+
+func (s *Store) GetEntries(ctx context.Context, filter *Filter) ([]Entry, error) {
+	query := `
+		SELECT id, actor_id, action, target_type, target_id, before, after, request_id, ip, created_at, prev_hash, hash
+		FROM audit_log
+		WHERE ($1 = '' OR actor_id = NULLIF($1, '')::uuid)
+			AND ($2 = '' OR action = $2)
+			AND ($3 = '' OR target_type = $3)
+			AND ($4 = '' OR target_id = $4)
+			AND ($5::timestamptz IS NULL OR created_at >= $5)
+			AND ($6::timestamptz IS NULL OR created_at < $6)
+		ORDER BY id DESC
+		LIMIT $7 OFFSET $8
+	`
+
+	ctx, cancel := context.WithTimeout(ctx, utils.QueryTimeout)
+	defer cancel()
+
+	rows, err := s.db.QueryContext(ctx, query,
+		filter.ActorID, filter.Action, filter.TargetType, filter.TargetID,
+		filter.From, filter.To, filter.Limit, filter.Offset,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	entries := []Entry{}
+	for rows.Next() {
+		entry, err := scanEntry(rows)
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, *entry)
+	}
+
+	return entries, rows.Err()
+}
+
+// Verify walks the whole log in order and recomputes every hash. Entries
+// from before chaining are skipped, but an unhashed entry after the chain
+// has started counts as a break.
+func (s *Store) Verify(ctx context.Context) (*Verification, error) {
+	query := `
+		SELECT id, actor_id, action, target_type, target_id, before, after, request_id, ip, created_at, prev_hash, hash
+		FROM audit_log
+		WHERE id > $1
+		ORDER BY id
+		LIMIT $2
+	`
+
+	result := &Verification{Valid: true}
+	prevHash := ""
+	started := false
+	var afterID int64
+
+	for {
+		entries, err := s.verifyBatch(ctx, query, afterID)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, entry := range entries {
+			afterID = entry.ID
+
+			if entry.Hash == "" && !started {
+				continue
+			}
+			started = true
+			result.Checked++
+
+			hash, err := entry.computeHash()
+			if err != nil || entry.Hash == "" || entry.PrevHash != prevHash || hash != entry.Hash {
+				id := entry.ID
+				result.Valid = false
+				result.BrokenAt = &id
+				return result, nil
+			}
+
+			prevHash = entry.Hash
+		}
+
+		if len(entries) < verifyBatchSize {
+			return result, nil
+		}
+	}
+}
+
+func (s *Store) verifyBatch(ctx context.Context, query string, afterID int64) ([]Entry, error) {
+	ctx, cancel := context.WithTimeout(ctx, utils.QueryTimeout)
+	defer cancel()
+
+	rows, err := s.db.QueryContext(ctx, query, afterID, verifyBatchSize)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var entries []Entry
+	for rows.Next() {
+		entry, err := scanEntry(rows)
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, *entry)
+	}
+
+	return entries, rows.Err()
+}
+
+func scanEntry(rows *sql.Rows) (*Entry, error) {
+	var (
+		entry   Entry
+		actorID sql.NullString
+		before  []byte
+		after   []byte
+		hash    sql.NullString
+	)
+
+	err := rows.Scan(
+		&entry.ID, &actorID, &entry.Action, &entry.TargetType, &entry.TargetID,
+		&before, &after, &entry.RequestID, &entry.IP, &entry.CreatedAt,
+		&entry.PrevHash, &hash,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	entry.ActorID = actorID.String
+	entry.Before = before
+	entry.After = after
+	entry.Hash = hash.String
+
+	return &entry, nil
+}
+
+// nullJSON stores missing state as NULL rather than an empty document.
+func nullJSON(state []byte) any {
+	if len(state) == 0 {
+		return nil
+	}
+
+	return state
+}
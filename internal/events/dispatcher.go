@@ -0,0 +1,238 @@
+package events
+
+import (
+	"cmp"
+	"context"
+	"database/sql"
+	"fmt"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/lib/pq"
+	"github.com/umeh-promise/ecommerce/utils"
+)
+
+const (
+	pollInterval   = time.Second
+	batchSize      = 50
+	handlerTimeout = 30 * time.Second
+	maxAttempts    = 10
+	// leaseDuration is how long a dispatcher holds the events it claimed.
+	// Events it can't get to in time are released for the next batch.
+	leaseDuration = 5 * time.Minute
+)
+
+// Dispatcher delivers outbox events to in-process subscribers. Replicas can
+// each run one: rows are claimed with SKIP LOCKED and leased so an event is
+// handled by one dispatcher at a time.
+type Dispatcher struct {
+	db       *sql.DB
+	mu       sync.RWMutex
+	handlers map[string][]Handler
+}
+
+func NewDispatcher(db *sql.DB) *Dispatcher {
+	return &Dispatcher{db: db, handlers: map[string][]Handler{}}
+}
+
+// Subscribe registers handler for eventType, or for every event with All.
+func (d *Dispatcher) Subscribe(eventType string, handler Handler) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	d.handlers[eventType] = append(d.handlers[eventType], handler)
+}
+
+// Run polls the outbox until ctx is cancelled.
+func (d *Dispatcher) Run(ctx context.Context) {
+	ticker := time.NewTicker(pollInterval)
+	defer ticker.Stop()
+
+	for {
+		// Keep going while there is a backlog rather than waiting a tick
+		// between batches.
+		for {
+			n, err := d.dispatchBatch(ctx)
+			if err != nil && ctx.Err() == nil {
+				utils.Logger.Warnw("outbox dispatch failed", "error", err.Error())
+			}
+			if err != nil || n < batchSize {
+				break
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (d *Dispatcher) dispatchBatch(ctx context.Context) (int, error) {
+	batch, lease, err := d.claim(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	for i, event := range batch {
+		handlers := d.subscribers(event.Type)
+
+		// Hand back what can't be delivered before the lease runs out
+		// rather than race another dispatcher for it. The first event is
+		// always tried so one with many subscribers still gets through.
+		if i > 0 && time.Until(lease) < time.Duration(len(handlers))*handlerTimeout {
+			d.release(batch[i:], lease)
+			break
+		}
+
+		deliveryErr := d.deliver(ctx, handlers, event)
+
+		// A delivery cut short by shutdown isn't the subscriber's fault.
+		if ctx.Err() != nil {
+			d.release(batch[i:], lease)
+			return len(batch), ctx.Err()
+		}
+
+		if err := d.finish(ctx, event, lease, deliveryErr); err != nil {
+			utils.Logger.Warnw("failed to record event delivery", "event_id", event.ID, "error", err.Error())
+		}
+	}
+
+	return len(batch), nil
+}
+
+// claim leases a batch of due events and commits straight away, so no
+// transaction is open while subscribers run. The lease's expiry identifies
+// this claim when the outcome is recorded.
+func (d *Dispatcher) claim(ctx context.Context) ([]Event, time.Time, error) {
+	query := `
+		UPDATE outbox
+		SET locked_until = now() + $2 * interval '1 second'
+		WHERE id IN (
+			SELECT id FROM outbox
+			WHERE dispatched_at IS NULL AND failed_at IS NULL AND next_attempt_at <= now()
+				AND (locked_until IS NULL OR locked_until < now())
+			ORDER BY id
+			LIMIT $1
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING id, event_type, aggregate_id, payload, attempts, created_at, locked_until
+	`
+
+	ctx, cancel := context.WithTimeout(ctx, utils.QueryTimeout)
+	defer cancel()
+
+	rows, err := d.db.QueryContext(ctx, query, batchSize, int(leaseDuration.Seconds()))
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+	defer rows.Close()
+
+	var (
+		batch []Event
+		lease time.Time
+	)
+
+	for rows.Next() {
+		var event Event
+		err := rows.Scan(&event.ID, &event.Type, &event.AggregateID, &event.Payload, &event.Attempts, &event.CreatedAt, &lease)
+		if err != nil {
+			return nil, time.Time{}, err
+		}
+		batch = append(batch, event)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, time.Time{}, err
+	}
+
+	// RETURNING doesn't keep the subquery's order.
+	slices.SortFunc(batch, func(a, b Event) int {
+		return cmp.Compare(a.ID, b.ID)
+	})
+
+	return batch, lease, nil
+}
+
+// release gives up the lease on events that weren't delivered.
+func (d *Dispatcher) release(batch []Event, lease time.Time) {
+	ids := make([]int64, len(batch))
+	for i, event := range batch {
+		ids[i] = event.ID
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), utils.QueryTimeout)
+	defer cancel()
+
+	_, err := d.db.ExecContext(ctx,
+		`UPDATE outbox SET locked_until = NULL WHERE id = ANY($1) AND locked_until = $2`,
+		pq.Array(ids), lease,
+	)
+	if err != nil {
+		utils.Logger.Warnw("failed to release outbox events", "error", err.Error())
+	}
+}
+
+func (d *Dispatcher) subscribers(eventType string) []Handler {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	return append(append([]Handler{}, d.handlers[eventType]...), d.handlers[All]...)
+}
+
+func (d *Dispatcher) deliver(ctx context.Context, handlers []Handler, event Event) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("subscriber panicked: %v", r)
+		}
+	}()
+
+	for _, handler := range handlers {
+		handlerCtx, cancel := context.WithTimeout(ctx, handlerTimeout)
+		err := handler(handlerCtx, event)
+		cancel()
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// finish marks the event delivered, or schedules a retry with exponential
+// backoff. Events that keep failing are parked with failed_at set. Each
+// outcome is its own statement, and nothing is recorded if the lease was
+// lost and another dispatcher has claimed the event.
+func (d *Dispatcher) finish(ctx context.Context, event Event, lease time.Time, deliveryErr error) error {
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), utils.QueryTimeout)
+	defer cancel()
+
+	if deliveryErr == nil {
+		_, err := d.db.ExecContext(ctx,
+			`UPDATE outbox SET dispatched_at = now(), locked_until = NULL WHERE id = $1 AND locked_until = $2`,
+			event.ID, lease,
+		)
+		return err
+	}
+
+	attempts := event.Attempts + 1
+	utils.Logger.Warnw("event delivery failed",
+		"event_id", event.ID, "type", event.Type, "attempts", attempts, "error", deliveryErr.Error())
+
+	if attempts >= maxAttempts {
+		_, err := d.db.ExecContext(ctx, `
+			UPDATE outbox SET attempts = $1, last_error = $2, failed_at = now(), locked_until = NULL
+			WHERE id = $3 AND locked_until = $4
+		`, attempts, deliveryErr.Error(), event.ID, lease)
+		return err
+	}
+
+	_, err := d.db.ExecContext(ctx, `
+		UPDATE outbox
+		SET attempts = $1, last_error = $2, next_attempt_at = now() + $3 * interval '1 second', locked_until = NULL
+		WHERE id = $4 AND locked_until = $5
+	`, attempts, deliveryErr.Error(), int(utils.Backoff(attempts).Seconds()), event.ID, lease)
+	return err
+}
@@ -0,0 +1,36 @@
+package events
+
+import (
+	"context"
+	"encoding/json"
+)
+
+const (
+	UserRegistered      = "user.registered"
+	UserPasswordChanged = "user.password_changed"
+	ProductCreated      = "product.created"
+	ProductUpdated      = "product.updated"
+	ProductDeleted      = "product.deleted"
+	OrderPlaced         = "order.placed"
+	OrderPaid           = "order.paid"
+	OrderCancelled      = "order.cancelled"
+	ShipmentCreated     = "shipment.created"
+	ShipmentUpdated     = "shipment.updated"
+
+	// All subscribes a handler to every event type.
+	All = "*"
+)
+
+type Event struct {
+	ID          int64           `json:"id"`
+	Type        string          `json:"type"`
+	AggregateID string          `json:"aggregate_id"`
+	Payload     json.RawMessage `json:"payload"`
+	Attempts    int             `json:"-"`
+	CreatedAt   string          `json:"created_at"`
+}
+
+// Handler reacts to an event. Delivery is at least once: an event is
+// redelivered to every subscriber when any of them fails, so handlers must
+// tolerate seeing the same event ID twice.
+type Handler func(context.Context, Event) error
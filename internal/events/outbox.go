@@ -0,0 +1,22 @@
+package events
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+)
+
+// Publish records an event in the outbox as part of tx, so it is delivered
+// if and only if the surrounding change commits.
+func Publish(ctx context.Context, tx *sql.Tx, eventType, aggregateID string, payload any) error {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.ExecContext(ctx,
+		`INSERT INTO outbox (event_type, aggregate_id, payload) VALUES ($1, $2, $3)`,
+		eventType, aggregateID, body,
+	)
+	return err
+}
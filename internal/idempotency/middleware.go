@@ -0,0 +1,180 @@
+package idempotency
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/umeh-promise/ecommerce/internal/services/guest"
+	"github.com/umeh-promise/ecommerce/utils"
+)
+
+const (
+	HeaderName     = "Idempotency-Key"
+	ReplayedHeader = "Idempotent-Replayed"
+	maxKeyLength   = 255
+)
+
+// maxBodyBytes leaves room for an image upload plus its multipart framing.
+var maxBodyBytes = utils.MaxUploadBytes + 1<<20
+
+// Middleware makes POST and PUT requests that carry an Idempotency-Key safe
+// to retry. The first request with a key runs as normal and its response is
+// stored; later requests with the same key and body get that response back
+// without running the handler again. Reusing a key for a different request
+// is rejected with 422, and retrying while the first request is still
+// running gets 409.
+//
+// Server errors aren't stored, so a request that failed that way can be
+// retried with the same key.
+func Middleware(store KeyStore) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			key := r.Header.Get(HeaderName)
+			if key == "" || (r.Method != http.MethodPost && r.Method != http.MethodPut) {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			if len(key) > maxKeyLength {
+				utils.BadRequestError(w, r, fmt.Errorf("%s must be at most %d characters", HeaderName, maxKeyLength))
+				return
+			}
+
+			body, err := io.ReadAll(io.LimitReader(r.Body, maxBodyBytes+1))
+			if err != nil {
+				utils.BadRequestError(w, r, err)
+				return
+			}
+			if int64(len(body)) > maxBodyBytes {
+				utils.BadRequestError(w, r, fmt.Errorf("request body is too large"))
+				return
+			}
+			r.Body = io.NopCloser(bytes.NewReader(body))
+
+			record := &Record{
+				Scope:       scope(r),
+				Key:         key,
+				Method:      r.Method,
+				Path:        r.URL.Path,
+				Fingerprint: fingerprint(r, body),
+			}
+
+			ctx := r.Context()
+
+			claimed, err := store.Claim(ctx, record)
+			if err != nil {
+				utils.InternalServerError(w, r, err)
+				return
+			}
+
+			if !claimed {
+				replay(w, r, store, record)
+				return
+			}
+
+			// The client may hang up before we finish; the outcome still has
+			// to be saved or released for its retry.
+			saveCtx := context.WithoutCancel(ctx)
+			recorder := &responseRecorder{ResponseWriter: w, status: http.StatusOK}
+
+			defer func() {
+				if recorder.status >= http.StatusInternalServerError || !recorder.done {
+					if err := store.Release(saveCtx, record.Scope, record.Key); err != nil {
+						utils.Logger.Warnw("failed to release idempotency key", "key", record.Key, "error", err.Error())
+					}
+					return
+				}
+
+				record.StatusCode = recorder.status
+				record.ContentType = recorder.Header().Get("Content-Type")
+				record.ResponseBody = recorder.body.Bytes()
+
+				if err := store.Complete(saveCtx, record); err != nil {
+					utils.Logger.Warnw("failed to store idempotent response", "key", record.Key, "error", err.Error())
+				}
+			}()
+
+			next.ServeHTTP(recorder, r)
+			recorder.done = true
+		})
+	}
+}
+
+func replay(w http.ResponseWriter, r *http.Request, store KeyStore, attempt *Record) {
+	record, err := store.GetRecord(r.Context(), attempt.Scope, attempt.Key)
+	if err != nil {
+		switch err {
+		case utils.ErrorNotFound:
+			// The first request failed and released the key between our
+			// claim and this read.
+			utils.ConflictResponse(w, r, fmt.Errorf("a request with this %s was just retried, try again", HeaderName))
+		default:
+			utils.InternalServerError(w, r, err)
+		}
+		return
+	}
+
+	if record.Fingerprint != attempt.Fingerprint {
+		utils.UnprocessableEntityResponse(w, r, fmt.Errorf("%s has already been used for a different request", HeaderName))
+		return
+	}
+
+	if !record.Completed() {
+		utils.ConflictResponse(w, r, fmt.Errorf("a request with this %s is still being processed", HeaderName))
+		return
+	}
+
+	if record.ContentType != "" {
+		w.Header().Set("Content-Type", record.ContentType)
+	}
+	w.Header().Set(ReplayedHeader, "true")
+	w.WriteHeader(record.StatusCode)
+	w.Write(record.ResponseBody)
+}
+
+// scope ties keys to the caller's credentials, whether a bearer token or a
+// guest session.
+func scope(r *http.Request) string {
+	guestToken := r.Header.Get(guest.HeaderName)
+	if cookie, err := r.Cookie(guest.CookieName); guestToken == "" && err == nil {
+		guestToken = cookie.Value
+	}
+
+	sum := sha256.Sum256([]byte(r.Header.Get("Authorization") + "\n" + guestToken))
+	return hex.EncodeToString(sum[:])
+}
+
+func fingerprint(r *http.Request, body []byte) string {
+	hash := sha256.New()
+	fmt.Fprintf(hash, "%s %s\n", r.Method, r.URL.RequestURI())
+	hash.Write(body)
+
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+type responseRecorder struct {
+	http.ResponseWriter
+	status      int
+	wroteHeader bool
+	done        bool
+	body        bytes.Buffer
+}
+
+func (rec *responseRecorder) WriteHeader(status int) {
+	if !rec.wroteHeader {
+		rec.status = status
+		rec.wroteHeader = true
+	}
+	rec.ResponseWriter.WriteHeader(status)
+}
+
+func (rec *responseRecorder) Write(b []byte) (int, error) {
+	rec.wroteHeader = true
+	rec.body.Write(b)
+	return rec.ResponseWriter.Write(b)
+}
@@ -0,0 +1,32 @@
+package idempotency
+
+import "context"
+
+// Record is a request made with an Idempotency-Key. StatusCode is zero
+// while the first request is still being handled.
+type Record struct {
+	Scope        string
+	Key          string
+	Method       string
+	Path         string
+	Fingerprint  string
+	StatusCode   int
+	ContentType  string
+	ResponseBody []byte
+	CreatedAt    string
+}
+
+func (r *Record) Completed() bool {
+	return r.StatusCode != 0
+}
+
+type KeyStore interface {
+	// Claim saves the record unless its key is already in use, reporting
+	// whether this request owns the key. Expired keys can be claimed again.
+	Claim(context.Context, *Record) (bool, error)
+	GetRecord(ctx context.Context, scope, key string) (*Record, error)
+	// Complete stores the response so retries can replay it.
+	Complete(context.Context, *Record) error
+	// Release frees the key so the request can be retried.
+	Release(ctx context.Context, scope, key string) error
+}
@@ -0,0 +1,126 @@
+package idempotency
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/umeh-promise/ecommerce/utils"
+)
+
+// keyTTL is how long a key is remembered. Clients must not retry with the
+// same key after this.
+const keyTTL = 24 * time.Hour
+
+type Store struct {
+	db *sql.DB
+}
+
+func NewStore(db *sql.DB) *Store {
+	return &Store{db: db}
+}
+
+func (s *Store) Claim(ctx context.Context, record *Record) (bool, error) {
+	query := `
+		INSERT INTO idempotency_keys (scope, key, method, path, fingerprint)
+		VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT (scope, key) DO UPDATE
+		SET method = EXCLUDED.method, path = EXCLUDED.path, fingerprint = EXCLUDED.fingerprint,
+			status_code = NULL, content_type = '', response_body = NULL,
+			created_at = now(), completed_at = NULL
+		WHERE idempotency_keys.created_at < now() - $6 * interval '1 second'
+		RETURNING created_at
+	`
+
+	ctx, cancel := context.WithTimeout(ctx, utils.QueryTimeout)
+	defer cancel()
+
+	err := s.db.QueryRowContext(ctx, query,
+		record.Scope, record.Key, record.Method, record.Path, record.Fingerprint, int(keyTTL.Seconds()),
+	).Scan(&record.CreatedAt)
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return false, nil
+		default:
+			return false, err
+		}
+	}
+
+	return true, nil
+}
+
+func (s *Store) GetRecord(ctx context.Context, scope, key string) (*Record, error) {
+	query := `
+		SELECT scope, key, method, path, fingerprint, COALESCE(status_code, 0), content_type, response_body, created_at
+		FROM idempotency_keys
+		WHERE scope = $1 AND key = $2
+	`
+
+	ctx, cancel := context.WithTimeout(ctx, utils.QueryTimeout)
+	defer cancel()
+
+	var record Record
+
+	err := s.db.QueryRowContext(ctx, query, scope, key).Scan(
+		&record.Scope,
+		&record.Key,
+		&record.Method,
+		&record.Path,
+		&record.Fingerprint,
+		&record.StatusCode,
+		&record.ContentType,
+		&record.ResponseBody,
+		&record.CreatedAt,
+	)
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return nil, utils.ErrorNotFound
+		default:
+			return nil, err
+		}
+	}
+
+	return &record, nil
+}
+
+func (s *Store) Complete(ctx context.Context, record *Record) error {
+	query := `
+		UPDATE idempotency_keys
+		SET status_code = $1, content_type = $2, response_body = $3, completed_at = now()
+		WHERE scope = $4 AND key = $5
+	`
+
+	ctx, cancel := context.WithTimeout(ctx, utils.QueryTimeout)
+	defer cancel()
+
+	_, err := s.db.ExecContext(ctx, query,
+		record.StatusCode, record.ContentType, record.ResponseBody, record.Scope, record.Key)
+	return err
+}
+
+func (s *Store) Release(ctx context.Context, scope, key string) error {
+	ctx, cancel := context.WithTimeout(ctx, utils.QueryTimeout)
+	defer cancel()
+
+	_, err := s.db.ExecContext(ctx,
+		`DELETE FROM idempotency_keys WHERE scope = $1 AND key = $2 AND status_code IS NULL`, scope, key)
+	return err
+}
+
+// PurgeExpired deletes keys older than keyTTL, which Claim would overwrite
+// anyway, and reports how many were removed.
+func (s *Store) PurgeExpired(ctx context.Context) (int64, error) {
+	ctx, cancel := context.WithTimeout(ctx, utils.QueryTimeout)
+	defer cancel()
+
+	result, err := s.db.ExecContext(ctx,
+		`DELETE FROM idempotency_keys WHERE created_at < now() - $1 * interval '1 second'`, keyTTL.Seconds())
+	if err != nil {
+		return 0, err
+	}
+
+	return result.RowsAffected()
+}
@@ -0,0 +1,56 @@
+package jobs
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"time"
+)
+
+type Status string
+
+const (
+	StatusQueued    Status = "queued"
+	StatusRunning   Status = "running"
+	StatusSucceeded Status = "succeeded"
+	StatusDead      Status = "dead"
+)
+
+const defaultMaxAttempts = 5
+
+type Job struct {
+	ID          int64
+	Kind        string
+	Payload     json.RawMessage
+	Attempts    int
+	MaxAttempts int
+}
+
+// Enqueuer adds jobs to the queue. EnqueueTx adds the job as part of tx so
+// it only runs if the surrounding change commits.
+type Enqueuer interface {
+	Enqueue(ctx context.Context, kind string, payload any, options ...Option) error
+	EnqueueTx(ctx context.Context, tx *sql.Tx, kind string, payload any, options ...Option) error
+}
+
+type enqueueOptions struct {
+	runAt       *time.Time
+	maxAttempts int
+}
+
+type Option func(*enqueueOptions)
+
+// RunAt schedules the job for later instead of running it straight away.
+func RunAt(at time.Time) Option {
+	return func(o *enqueueOptions) {
+		o.runAt = &at
+	}
+}
+
+// MaxAttempts sets how many times the job is tried before it is
+// dead-lettered.
+func MaxAttempts(n int) Option {
+	return func(o *enqueueOptions) {
+		o.maxAttempts = n
+	}
+}
@@ -0,0 +1,50 @@
+package jobs
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+
+	"github.com/umeh-promise/ecommerce/utils"
+)
+
+type execer interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+}
+
+type Queue struct {
+	db *sql.DB
+}
+
+func NewQueue(db *sql.DB) *Queue {
+	return &Queue{db: db}
+}
+
+func (q *Queue) Enqueue(ctx context.Context, kind string, payload any, options ...Option) error {
+	return enqueue(ctx, q.db, kind, payload, options)
+}
+
+func (q *Queue) EnqueueTx(ctx context.Context, tx *sql.Tx, kind string, payload any, options ...Option) error {
+	return enqueue(ctx, tx, kind, payload, options)
+}
+
+func enqueue(ctx context.Context, db execer, kind string, payload any, options []Option) error {
+	opts := enqueueOptions{maxAttempts: defaultMaxAttempts}
+	for _, option := range options {
+		option(&opts)
+	}
+
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, utils.QueryTimeout)
+	defer cancel()
+
+	_, err = db.ExecContext(ctx, `
+		INSERT INTO jobs (kind, payload, max_attempts, run_at)
+		VALUES ($1, $2, $3, COALESCE($4, now()))
+	`, kind, body, opts.maxAttempts, opts.runAt)
+	return err
+}
@@ -0,0 +1,290 @@
+package jobs
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/umeh-promise/ecommerce/utils"
+)
+
+const (
+	pollInterval = time.Second
+	// jobTimeout bounds a single run. The lease is longer so a job is never
+	// picked up by another runner while it can still be running here.
+	jobTimeout    = 5 * time.Minute
+	leaseDuration = jobTimeout + time.Minute
+)
+
+type registration struct {
+	run     func(context.Context, json.RawMessage) error
+	limit   int
+	running int
+}
+
+// Runner claims queued jobs and runs them with the registered handlers. Any
+// number of runners can share the table: jobs are claimed with FOR UPDATE
+// SKIP LOCKED and leased, and a runner only claims kinds it can handle.
+type Runner struct {
+	db          *sql.DB
+	concurrency int
+
+	mu       sync.Mutex
+	handlers map[string]*registration
+	running  int
+	freed    chan struct{}
+
+	wg         sync.WaitGroup
+	jobCtx     context.Context
+	cancelJobs context.CancelFunc
+	stopped    chan struct{}
+}
+
+// NewRunner runs at most concurrency jobs at a time.
+func NewRunner(db *sql.DB, concurrency int) *Runner {
+	jobCtx, cancelJobs := context.WithCancel(context.Background())
+
+	return &Runner{
+		db:          db,
+		concurrency: max(concurrency, 1),
+		handlers:    map[string]*registration{},
+		freed:       make(chan struct{}, 1),
+		jobCtx:      jobCtx,
+		cancelJobs:  cancelJobs,
+		stopped:     make(chan struct{}),
+	}
+}
+
+// Register sets the handler for kind, decoding each job's payload into T.
+// A limit above zero caps how many jobs of this kind run at once.
+func Register[T any](runner *Runner, kind string, limit int, handler func(context.Context, T) error) {
+	runner.mu.Lock()
+	defer runner.mu.Unlock()
+
+	runner.handlers[kind] = &registration{
+		limit: limit,
+		run: func(ctx context.Context, payload json.RawMessage) error {
+			var value T
+			if err := json.Unmarshal(payload, &value); err != nil {
+				return fmt.Errorf("decode %s payload: %w", kind, err)
+			}
+			return handler(ctx, value)
+		},
+	}
+}
+
+// Run claims and starts jobs until ctx is cancelled. Jobs already started
+// keep running; use Drain to wait for them.
+func (rn *Runner) Run(ctx context.Context) {
+	defer close(rn.stopped)
+
+	ticker := time.NewTicker(pollInterval)
+	defer ticker.Stop()
+
+	for {
+		if err := rn.claimAndStart(ctx); err != nil && ctx.Err() == nil {
+			utils.Logger.Warnw("failed to claim jobs", "error", err.Error())
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		case <-rn.freed:
+		}
+	}
+}
+
+// Drain waits for running jobs after Run has stopped. If ctx expires first
+// the jobs are cancelled; they are retried later like any failed job.
+func (rn *Runner) Drain(ctx context.Context) error {
+	done := make(chan struct{})
+	go func() {
+		<-rn.stopped
+		rn.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		rn.cancelJobs()
+		<-done
+		return ctx.Err()
+	}
+}
+
+// slots works out how many jobs of each kind may be claimed right now, and
+// how many the runner has room for in total.
+func (rn *Runner) slots() (map[string]int, int) {
+	rn.mu.Lock()
+	defer rn.mu.Unlock()
+
+	free := rn.concurrency - rn.running
+	slots := map[string]int{}
+
+	for kind, registration := range rn.handlers {
+		n := free
+		if registration.limit > 0 {
+			n = min(n, registration.limit-registration.running)
+		}
+		if n > 0 {
+			slots[kind] = n
+		}
+	}
+
+	return slots, free
+}
+
+// claimAndStart claims jobs kind by kind, taking each claim out of the
+// shared free count so one poll never starts more than the runner's
+// concurrency.
+func (rn *Runner) claimAndStart(ctx context.Context) error {
+	slots, free := rn.slots()
+
+	for kind, n := range slots {
+		if free <= 0 {
+			break
+		}
+
+		jobs, err := rn.claim(ctx, kind, min(n, free))
+		if err != nil {
+			return err
+		}
+
+		free -= len(jobs)
+
+		for _, job := range jobs {
+			rn.start(job)
+		}
+	}
+
+	return nil
+}
+
+func (rn *Runner) claim(ctx context.Context, kind string, limit int) ([]Job, error) {
+	query := `
+		UPDATE jobs
+		SET status = 'running', attempts = attempts + 1, locked_until = now() + $3 * interval '1 second',
+			updated_at = now()
+		WHERE id IN (
+			SELECT id FROM jobs
+			WHERE kind = $1 AND (
+				(status = 'queued' AND run_at <= now()) OR
+				(status = 'running' AND locked_until < now())
+			)
+			ORDER BY run_at, id
+			LIMIT $2
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING id, kind, payload, attempts, max_attempts
+	`
+
+	ctx, cancel := context.WithTimeout(ctx, utils.QueryTimeout)
+	defer cancel()
+
+	rows, err := rn.db.QueryContext(ctx, query, kind, limit, int(leaseDuration.Seconds()))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var jobs []Job
+
+	for rows.Next() {
+		job := Job{}
+		if err := rows.Scan(&job.ID, &job.Kind, &job.Payload, &job.Attempts, &job.MaxAttempts); err != nil {
+			return nil, err
+		}
+
+		jobs = append(jobs, job)
+	}
+
+	return jobs, rows.Err()
+}
+
+func (rn *Runner) start(job Job) {
+	rn.mu.Lock()
+	registration := rn.handlers[job.Kind]
+	registration.running++
+	rn.running++
+	rn.mu.Unlock()
+
+	rn.wg.Add(1)
+
+	go func() {
+		defer rn.wg.Done()
+		defer func() {
+			rn.mu.Lock()
+			registration.running--
+			rn.running--
+			rn.mu.Unlock()
+
+			select {
+			case rn.freed <- struct{}{}:
+			default:
+			}
+		}()
+
+		rn.finish(job, rn.execute(registration, job))
+	}()
+}
+
+func (rn *Runner) execute(registration *registration, job Job) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("job panicked: %v", r)
+		}
+	}()
+
+	ctx, cancel := context.WithTimeout(rn.jobCtx, jobTimeout)
+	defer cancel()
+
+	return registration.run(ctx, job.Payload)
+}
+
+// finish records the outcome. Failed jobs are retried with exponential
+// backoff and dead-lettered once they run out of attempts. Nothing is
+// recorded if the lease was lost and another runner has claimed the job.
+func (rn *Runner) finish(job Job, jobErr error) {
+	ctx, cancel := context.WithTimeout(context.Background(), utils.QueryTimeout)
+	defer cancel()
+
+	var err error
+
+	switch {
+	case jobErr == nil:
+		_, err = rn.db.ExecContext(ctx, `
+			UPDATE jobs
+			SET status = 'succeeded', locked_until = NULL, finished_at = now(), updated_at = now()
+			WHERE id = $1 AND attempts = $2
+		`, job.ID, job.Attempts)
+
+	case job.Attempts >= job.MaxAttempts:
+		utils.Logger.Errorw("job dead-lettered", "job_id", job.ID, "kind", job.Kind, "error", jobErr.Error())
+
+		_, err = rn.db.ExecContext(ctx, `
+			UPDATE jobs
+			SET status = 'dead', locked_until = NULL, last_error = $1, finished_at = now(), updated_at = now()
+			WHERE id = $2 AND attempts = $3
+		`, jobErr.Error(), job.ID, job.Attempts)
+
+	default:
+		utils.Logger.Warnw("job failed", "job_id", job.ID, "kind", job.Kind, "attempts", job.Attempts, "error", jobErr.Error())
+
+		_, err = rn.db.ExecContext(ctx, `
+			UPDATE jobs
+			SET status = 'queued', locked_until = NULL, last_error = $1,
+				run_at = now() + $2 * interval '1 second', updated_at = now()
+			WHERE id = $3 AND attempts = $4
+		`, jobErr.Error(), int(utils.Backoff(job.Attempts).Seconds()), job.ID, job.Attempts)
+	}
+
+	if err != nil {
+		utils.Logger.Warnw("failed to record job outcome", "job_id", job.ID, "error", err.Error())
+	}
+}
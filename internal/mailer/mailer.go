@@ -0,0 +1,244 @@
+package mailer
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net"
+	"net/smtp"
+	"net/textproto"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	uuid "github.com/satori/go.uuid"
+	"github.com/umeh-promise/ecommerce/utils"
+)
+
+// SendJob is the background job kind that sends a Message.
+const SendJob = "email.send"
+
+type Message struct {
+	To      string `json:"to"`
+	Subject string `json:"subject"`
+	Body    string `json:"body"`
+	// HTML is an optional alternative to the plain-text Body.
+	HTML string `json:"html,omitempty"`
+}
+
+// Mailer delivers email.
+type Mailer interface {
+	Send(context.Context, Message) error
+}
+
+type Config struct {
+	Backend  string
+	From     string
+	SMTPHost string
+	SMTPPort int
+	SMTPUser string
+	SMTPPass string
+	FileDir  string
+}
+
+func New(config Config) (Mailer, error) {
+	switch config.Backend {
+	case "", "log":
+		return NewLogMailer(), nil
+	case "smtp":
+		if config.SMTPHost == "" {
+			return nil, fmt.Errorf("smtp mailer needs a host")
+		}
+		return NewSMTPMailer(config.SMTPHost, config.SMTPPort, config.SMTPUser, config.SMTPPass, config.From), nil
+	case "file":
+		return NewFileMailer(config.FileDir, config.From)
+	default:
+		return nil, fmt.Errorf("unknown mailer backend %q", config.Backend)
+	}
+}
+
+// LogMailer writes messages to the application log instead of sending them.
+// It is the default for local development.
+type LogMailer struct{}
+
+func NewLogMailer() *LogMailer {
+	return &LogMailer{}
+}
+
+func (m *LogMailer) Send(ctx context.Context, message Message) error {
+	utils.Logger.Infow("email", "to", message.To, "subject", message.Subject, "body", message.Body)
+	return nil
+}
+
+// smtpTimeout bounds a send when the caller's context has no deadline.
+const smtpTimeout = time.Minute
+
+type SMTPMailer struct {
+	addr string
+	host string
+	auth smtp.Auth
+	from string
+}
+
+func NewSMTPMailer(host string, port int, username, password, from string) *SMTPMailer {
+	var auth smtp.Auth
+	if username != "" {
+		auth = smtp.PlainAuth("", username, password, host)
+	}
+
+	return &SMTPMailer{
+		addr: net.JoinHostPort(host, fmt.Sprint(port)),
+		host: host,
+		auth: auth,
+		from: from,
+	}
+}
+
+// Send does what smtp.SendMail does, but over a connection bounded by ctx so
+// a stalled server can't hold the caller forever.
+func (m *SMTPMailer) Send(ctx context.Context, message Message) error {
+	body, err := encode(m.from, message)
+	if err != nil {
+		return err
+	}
+
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, "tcp", m.addr)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		deadline = time.Now().Add(smtpTimeout)
+	}
+	if err := conn.SetDeadline(deadline); err != nil {
+		return err
+	}
+
+	// Cancelling ctx interrupts whatever read or write is in progress.
+	stop := context.AfterFunc(ctx, func() {
+		conn.SetDeadline(time.Now())
+	})
+	defer stop()
+
+	client, err := smtp.NewClient(conn, m.host)
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	if ok, _ := client.Extension("STARTTLS"); ok {
+		if err := client.StartTLS(&tls.Config{ServerName: m.host}); err != nil {
+			return err
+		}
+	}
+
+	if m.auth != nil {
+		if ok, _ := client.Extension("AUTH"); !ok {
+			return fmt.Errorf("smtp server does not support authentication")
+		}
+		if err := client.Auth(m.auth); err != nil {
+			return err
+		}
+	}
+
+	if err := client.Mail(m.from); err != nil {
+		return err
+	}
+	if err := client.Rcpt(message.To); err != nil {
+		return err
+	}
+
+	w, err := client.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(body); err != nil {
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+
+	return client.Quit()
+}
+
+// FileMailer writes each message to its own .eml file, for development and
+// for checking rendered emails without a mail server.
+type FileMailer struct {
+	dir  string
+	from string
+}
+
+func NewFileMailer(dir, from string) (*FileMailer, error) {
+	if dir == "" {
+		return nil, fmt.Errorf("file mailer needs a directory")
+	}
+
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+
+	return &FileMailer{dir: dir, from: from}, nil
+}
+
+func (m *FileMailer) Send(ctx context.Context, message Message) error {
+	body, err := encode(m.from, message)
+	if err != nil {
+		return err
+	}
+
+	name := fmt.Sprintf("%d-%s.eml", time.Now().UnixNano(), uuid.NewV4().String())
+
+	return os.WriteFile(filepath.Join(m.dir, name), body, 0o644)
+}
+
+// encode renders the message as RFC 5322 text, as multipart/alternative
+// when it has an HTML part.
+func encode(from string, message Message) ([]byte, error) {
+	if strings.ContainsAny(message.To+message.Subject, "\r\n") {
+		return nil, fmt.Errorf("invalid email header")
+	}
+
+	var body bytes.Buffer
+	fmt.Fprintf(&body, "From: %s\r\n", from)
+	fmt.Fprintf(&body, "To: %s\r\n", message.To)
+	fmt.Fprintf(&body, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", message.Subject))
+	fmt.Fprintf(&body, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	body.WriteString("MIME-Version: 1.0\r\n")
+
+	if message.HTML == "" {
+		body.WriteString("Content-Type: text/plain; charset=UTF-8\r\n\r\n")
+		body.WriteString(message.Body)
+		return body.Bytes(), nil
+	}
+
+	parts := multipart.NewWriter(&body)
+	fmt.Fprintf(&body, "Content-Type: multipart/alternative; boundary=%s\r\n\r\n", parts.Boundary())
+
+	for _, part := range []struct{ contentType, content string }{
+		{"text/plain; charset=UTF-8", message.Body},
+		{"text/html; charset=UTF-8", message.HTML},
+	} {
+		w, err := parts.CreatePart(textproto.MIMEHeader{"Content-Type": {part.contentType}})
+		if err != nil {
+			return nil, err
+		}
+		if _, err := io.WriteString(w, part.content); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := parts.Close(); err != nil {
+		return nil, err
+	}
+
+	return body.Bytes(), nil
+}
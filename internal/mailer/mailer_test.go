@@ -0,0 +1,259 @@
+package mailer
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net"
+	"net/mail"
+	"net/textproto"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// envelope is what the fake SMTP server received.
+type envelope struct {
+	from string
+	to   []string
+	data string
+}
+
+// fakeSMTP accepts one connection on a local port and speaks just enough
+// SMTP for a plain, unauthenticated send.
+func fakeSMTP(t *testing.T) (host string, port int, received <-chan envelope) {
+	t.Helper()
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { listener.Close() })
+
+	ch := make(chan envelope, 1)
+
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+
+		text := textproto.NewConn(conn)
+		var env envelope
+
+		text.PrintfLine("220 localhost ESMTP fake")
+		for {
+			line, err := text.ReadLine()
+			if err != nil {
+				return
+			}
+
+			command := strings.ToUpper(line)
+			switch {
+			case strings.HasPrefix(command, "EHLO"), strings.HasPrefix(command, "HELO"):
+				text.PrintfLine("250-localhost")
+				text.PrintfLine("250 8BITMIME")
+			case strings.HasPrefix(command, "MAIL FROM:"):
+				env.from = address(line)
+				text.PrintfLine("250 OK")
+			case strings.HasPrefix(command, "RCPT TO:"):
+				env.to = append(env.to, address(line))
+				text.PrintfLine("250 OK")
+			case command == "DATA":
+				text.PrintfLine("354 go ahead")
+				data, err := io.ReadAll(text.DotReader())
+				if err != nil {
+					return
+				}
+				env.data = string(data)
+				text.PrintfLine("250 queued")
+			case command == "QUIT":
+				text.PrintfLine("221 bye")
+				ch <- env
+				return
+			default:
+				text.PrintfLine("502 not implemented")
+			}
+		}
+	}()
+
+	addr := listener.Addr().(*net.TCPAddr)
+	return addr.IP.String(), addr.Port, ch
+}
+
+// address returns the <path> of a MAIL or RCPT command, without any ESMTP
+// parameters that follow it.
+func address(line string) string {
+	start := strings.IndexByte(line, '<')
+	end := strings.IndexByte(line, '>')
+	if start < 0 || end < start {
+		return ""
+	}
+
+	return line[start+1 : end]
+}
+
+func TestSMTPMailerSend(t *testing.T) {
+	host, port, received := fakeSMTP(t)
+	mailer := NewSMTPMailer(host, port, "", "", "shop@example.com")
+
+	err := mailer.Send(context.Background(), Message{
+		To:      "buyer@example.com",
+		Subject: "Your order has shipped",
+		Body:    "Plain body",
+		HTML:    "<p>HTML body</p>",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var env envelope
+	select {
+	case env = <-received:
+	case <-time.After(5 * time.Second):
+		t.Fatal("server received nothing")
+	}
+
+	if env.from != "shop@example.com" {
+		t.Errorf("MAIL FROM = %q", env.from)
+	}
+	if len(env.to) != 1 || env.to[0] != "buyer@example.com" {
+		t.Errorf("RCPT TO = %q", env.to)
+	}
+
+	msg, err := mail.ReadMessage(strings.NewReader(env.data))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	headers := map[string]string{
+		"From":         "shop@example.com",
+		"To":           "buyer@example.com",
+		"Subject":      "Your order has shipped",
+		"MIME-Version": "1.0",
+	}
+	for name, want := range headers {
+		got := msg.Header.Get(name)
+		if name == "Subject" {
+			got, _ = new(mime.WordDecoder).DecodeHeader(got)
+		}
+		if got != want {
+			t.Errorf("%s = %q, want %q", name, got, want)
+		}
+	}
+	if _, err := msg.Header.Date(); err != nil {
+		t.Errorf("Date header: %v", err)
+	}
+
+	mediaType, params, err := mime.ParseMediaType(msg.Header.Get("Content-Type"))
+	if err != nil || mediaType != "multipart/alternative" {
+		t.Fatalf("Content-Type = %q, %v", msg.Header.Get("Content-Type"), err)
+	}
+
+	parts := multipart.NewReader(msg.Body, params["boundary"])
+	want := []struct{ contentType, body string }{
+		{"text/plain; charset=UTF-8", "Plain body"},
+		{"text/html; charset=UTF-8", "<p>HTML body</p>"},
+	}
+
+	for _, w := range want {
+		part, err := parts.NextPart()
+		if err != nil {
+			t.Fatal(err)
+		}
+		body, _ := io.ReadAll(part)
+		if part.Header.Get("Content-Type") != w.contentType || string(body) != w.body {
+			t.Errorf("part = %q %q, want %q %q", part.Header.Get("Content-Type"), body, w.contentType, w.body)
+		}
+	}
+
+	if _, err := parts.NextPart(); err != io.EOF {
+		t.Errorf("expected two parts, got more: %v", err)
+	}
+}
+
+func TestSMTPMailerHonoursContext(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer listener.Close()
+
+	// Accept the connection and never greet the client.
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		bufio.NewReader(conn).ReadString('\n')
+	}()
+
+	addr := listener.Addr().(*net.TCPAddr)
+	mailer := NewSMTPMailer(addr.IP.String(), addr.Port, "", "", "shop@example.com")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	err = mailer.Send(ctx, Message{To: "buyer@example.com", Subject: "Hi", Body: "Hello"})
+
+	var netErr net.Error
+	if !errors.As(err, &netErr) || !netErr.Timeout() {
+		t.Errorf("Send() = %v, want a timeout", err)
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("Send took %s despite the deadline", elapsed)
+	}
+}
+
+func TestEncodeRejectsHeaderInjection(t *testing.T) {
+	_, err := encode("shop@example.com", Message{To: "buyer@example.com\r\nBcc: other@example.com", Subject: "Hi"})
+	if err == nil {
+		t.Error("header injection was accepted")
+	}
+}
+
+func TestNewFileBackend(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "mail")
+
+	mailer, err := New(Config{Backend: "file", FileDir: dir, From: "shop@example.com"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := mailer.Send(context.Background(), Message{To: "buyer@example.com", Subject: "Hi", Body: "Hello"}); err != nil {
+		t.Fatal(err)
+	}
+
+	files, err := os.ReadDir(dir)
+	if err != nil || len(files) != 1 || filepath.Ext(files[0].Name()) != ".eml" {
+		t.Fatalf("mail dir = %v, %v", files, err)
+	}
+
+	data, err := os.ReadFile(filepath.Join(dir, files[0].Name()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), "To: buyer@example.com\r\n") || !strings.HasSuffix(string(data), "Hello") {
+		t.Errorf("unexpected message:\n%s", data)
+	}
+}
+
+func TestNewUnknownBackend(t *testing.T) {
+	if _, err := New(Config{Backend: "carrier-pigeon"}); err == nil {
+		t.Error("unknown backend was accepted")
+	}
+	if _, err := New(Config{Backend: "smtp", SMTPPort: 25}); err == nil {
+		t.Error("smtp backend without a host was accepted")
+	}
+	if _, err := New(Config{Backend: "smtp", SMTPHost: "localhost", SMTPPort: 25}); err != nil {
+		t.Errorf("smtp backend: %v", err)
+	}
+}
@@ -0,0 +1,59 @@
+package payments
+
+import (
+	"context"
+	"fmt"
+
+	uuid "github.com/satori/go.uuid"
+)
+
+type Refund struct {
+	OrderID string
+	Amount  int
+	Reason  string
+	// IdempotencyKey identifies the refund to the provider, so retrying a
+	// refund that already went through doesn't pay it out twice.
+	IdempotencyKey string
+}
+
+// Gateway moves money back to the customer. Implementations return the
+// provider's reference for the refund so it can be reconciled later, and
+// return the same reference when called again with the same idempotency
+// key.
+type Gateway interface {
+	Name() string
+	Refund(context.Context, Refund) (string, error)
+}
+
+// ManualGateway records refunds for staff to settle outside the system. It
+// is the default until a card processor is integrated.
+type ManualGateway struct{}
+
+func NewManualGateway() *ManualGateway {
+	return &ManualGateway{}
+}
+
+func (g *ManualGateway) Name() string {
+	return "manual"
+}
+
+func (g *ManualGateway) Refund(ctx context.Context, refund Refund) (string, error) {
+	if refund.Amount <= 0 {
+		return "", fmt.Errorf("refund amount must be positive")
+	}
+
+	if refund.IdempotencyKey != "" {
+		return "manual-" + refund.IdempotencyKey, nil
+	}
+
+	return "manual-" + uuid.NewV4().String(), nil
+}
+
+func New(provider string) (Gateway, error) {
+	switch provider {
+	case "", "manual":
+		return NewManualGateway(), nil
+	default:
+		return nil, fmt.Errorf("unknown payment provider %q", provider)
+	}
+}
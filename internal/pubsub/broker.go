@@ -0,0 +1,197 @@
+package pubsub
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"sync"
+	"time"
+
+	"github.com/lib/pq"
+	"github.com/umeh-promise/ecommerce/utils"
+)
+
+// channel is the Postgres NOTIFY channel every replica listens on.
+const channel = "pubsub"
+
+const (
+	minReconnectInterval = time.Second
+	maxReconnectInterval = time.Minute
+	// subscriberBuffer is how many messages a subscriber may fall behind by
+	// before it is dropped.
+	subscriberBuffer = 16
+)
+
+// Message is delivered to subscribers. Resync is set instead of Data when
+// the broker lost its connection to Postgres and messages may have been
+// missed, so subscribers should reload whatever they are following.
+type Message struct {
+	Topic  string          `json:"topic"`
+	Data   json.RawMessage `json:"data"`
+	Resync bool            `json:"-"`
+}
+
+// Broker is an in-process pub/sub whose messages are fanned out to every
+// replica through Postgres LISTEN/NOTIFY. Delivery is best effort: a
+// message published while a replica is reconnecting is lost, and slow
+// subscribers are dropped rather than allowed to block the others.
+type Broker struct {
+	db       *sql.DB
+	listener *pq.Listener
+
+	mu          sync.Mutex
+	subscribers map[string]map[chan Message]struct{}
+	closed      bool
+}
+
+// NewBroker listens on a dedicated connection opened with dsn, since a
+// pooled connection can't be held for LISTEN.
+func NewBroker(db *sql.DB, dsn string) *Broker {
+	broker := &Broker{db: db, subscribers: map[string]map[chan Message]struct{}{}}
+
+	broker.listener = pq.NewListener(dsn, minReconnectInterval, maxReconnectInterval,
+		func(event pq.ListenerEventType, err error) {
+			if err != nil {
+				utils.Logger.Warnw("pubsub listener", "event", event, "error", err.Error())
+			}
+		})
+
+	return broker
+}
+
+// Publish sends data to subscribers of topic on every replica. Postgres
+// limits a notification to 8000 bytes, so data should be small.
+func (b *Broker) Publish(ctx context.Context, topic string, data any) error {
+	raw, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+
+	payload, err := json.Marshal(&Message{Topic: topic, Data: raw})
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, utils.QueryTimeout)
+	defer cancel()
+
+	_, err = b.db.ExecContext(ctx, `SELECT pg_notify($1, $2)`, channel, string(payload))
+	return err
+}
+
+// Subscribe returns a channel of messages for topic and a func that must be
+// called to unsubscribe. The channel is closed when the subscriber is
+// dropped or the broker is closed.
+func (b *Broker) Subscribe(topic string) (<-chan Message, func()) {
+	messages := make(chan Message, subscriberBuffer)
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.closed {
+		close(messages)
+		return messages, func() {}
+	}
+
+	if b.subscribers[topic] == nil {
+		b.subscribers[topic] = map[chan Message]struct{}{}
+	}
+	b.subscribers[topic][messages] = struct{}{}
+
+	return messages, func() {
+		b.mu.Lock()
+		defer b.mu.Unlock()
+
+		b.remove(topic, messages)
+	}
+}
+
+// remove must be called with mu held.
+func (b *Broker) remove(topic string, messages chan Message) {
+	if _, ok := b.subscribers[topic][messages]; !ok {
+		return
+	}
+
+	delete(b.subscribers[topic], messages)
+	if len(b.subscribers[topic]) == 0 {
+		delete(b.subscribers, topic)
+	}
+	close(messages)
+}
+
+// Run receives notifications until ctx is cancelled.
+func (b *Broker) Run(ctx context.Context) {
+	if err := b.listener.Listen(channel); err != nil {
+		utils.Logger.Errorw("pubsub listen failed", "error", err.Error())
+	}
+	defer b.listener.Close()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+
+		case notification := <-b.listener.Notify:
+			// A nil notification means the connection was re-established.
+			if notification == nil {
+				b.resync()
+				continue
+			}
+
+			var message Message
+			if err := json.Unmarshal([]byte(notification.Extra), &message); err != nil {
+				utils.Logger.Warnw("invalid pubsub message", "error", err.Error())
+				continue
+			}
+
+			b.deliver(message)
+
+		case <-time.After(90 * time.Second):
+			// Make sure a silently dropped connection is noticed.
+			go b.listener.Ping()
+		}
+	}
+}
+
+func (b *Broker) deliver(message Message) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	for messages := range b.subscribers[message.Topic] {
+		select {
+		case messages <- message:
+		default:
+			b.remove(message.Topic, messages)
+		}
+	}
+}
+
+func (b *Broker) resync() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	for topic, subscribers := range b.subscribers {
+		for messages := range subscribers {
+			select {
+			case messages <- Message{Topic: topic, Resync: true}:
+			default:
+				b.remove(topic, messages)
+			}
+		}
+	}
+}
+
+// Close ends every subscription, which lets long-lived streams finish so
+// the server can shut down.
+func (b *Broker) Close() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.closed = true
+
+	for topic, subscribers := range b.subscribers {
+		for messages := range subscribers {
+			b.remove(topic, messages)
+		}
+	}
+}
@@ -0,0 +1,136 @@
+package scheduler
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func at(value string) time.Time {
+	t, err := time.Parse("2006-01-02 15:04:05", value)
+	if err != nil {
+		panic(err)
+	}
+	return t
+}
+
+func TestNext(t *testing.T) {
+	// 2024-01-01 is a Monday.
+	tests := []struct {
+		name string
+		spec string
+		from string
+		want string
+	}{
+		{"every minute", "* * * * *", "2024-01-01 00:00:30", "2024-01-01 00:01:00"},
+		{"strictly after from", "0 0 * * *", "2024-01-01 00:00:00", "2024-01-02 00:00:00"},
+		{"step over any", "*/15 * * * *", "2024-01-01 00:00:00", "2024-01-01 00:15:00"},
+		{"step wraps to next hour", "*/15 * * * *", "2024-01-01 00:50:00", "2024-01-01 01:00:00"},
+		{"step over range", "0-30/10 * * * *", "2024-01-01 00:30:00", "2024-01-01 01:00:00"},
+		{"step from a start value", "5/20 * * * *", "2024-01-01 00:25:00", "2024-01-01 00:45:00"},
+		{"list", "1,15 3 * * *", "2024-01-01 00:00:00", "2024-01-01 03:01:00"},
+		{"hour range with step", "0 9-17/4 * * *", "2024-01-01 13:00:00", "2024-01-01 17:00:00"},
+		{"day of month", "0 0 31 * *", "2024-02-01 00:00:00", "2024-03-31 00:00:00"},
+		{"month", "0 0 1 6 *", "2024-01-01 00:00:00", "2024-06-01 00:00:00"},
+		{"weekdays", "0 0 * * 1-5", "2024-01-05 12:00:00", "2024-01-08 00:00:00"},
+		{"0 is Sunday", "0 0 * * 0", "2024-01-01 00:00:00", "2024-01-07 00:00:00"},
+		{"7 is Sunday", "0 0 * * 7", "2024-01-01 00:00:00", "2024-01-07 00:00:00"},
+		{"7 in a range", "0 0 * * 6-7", "2024-01-01 00:00:00", "2024-01-06 00:00:00"},
+		{"either day field: weekday first", "0 0 15 * 5", "2024-01-01 00:00:00", "2024-01-05 00:00:00"},
+		{"either day field: date first", "0 0 15 * 5", "2024-01-13 00:00:00", "2024-01-15 00:00:00"},
+		{"leap day", "0 0 29 2 *", "2024-03-01 00:00:00", "2028-02-29 00:00:00"},
+		{"hourly", "@hourly", "2024-01-01 00:30:00", "2024-01-01 01:00:00"},
+		{"daily", "@daily", "2024-01-01 00:30:00", "2024-01-02 00:00:00"},
+		{"weekly", "@weekly", "2024-01-01 00:00:00", "2024-01-07 00:00:00"},
+		{"monthly", "@monthly", "2024-01-15 00:00:00", "2024-02-01 00:00:00"},
+		{"never", "0 0 30 2 *", "2024-01-01 00:00:00", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			schedule, err := ParseSchedule(tt.spec)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			got := schedule.Next(at(tt.from))
+
+			if tt.want == "" {
+				if !got.IsZero() {
+					t.Errorf("Next(%s) = %s, want never", tt.from, got)
+				}
+				return
+			}
+
+			if want := at(tt.want); !got.Equal(want) {
+				t.Errorf("Next(%s) = %s, want %s", tt.from, got, want)
+			}
+		})
+	}
+}
+
+func TestParseScheduleErrors(t *testing.T) {
+	tests := []string{
+		"",
+		"* * * *",
+		"* * * * * *",
+		"60 * * * *",
+		"* 24 * * *",
+		"* * 0 * *",
+		"* * 32 * *",
+		"* * * 0 *",
+		"* * * 13 *",
+		"* * * * 8",
+		"*/0 * * * *",
+		"*/x * * * *",
+		"5-1 * * * *",
+		"1-x * * * *",
+		"a * * * *",
+		"@yearly",
+	}
+
+	for _, spec := range tests {
+		t.Run(spec, func(t *testing.T) {
+			if _, err := ParseSchedule(spec); err == nil {
+				t.Errorf("ParseSchedule(%q) succeeded", spec)
+			}
+		})
+	}
+}
+
+func TestRegister(t *testing.T) {
+	noop := func(context.Context) error { return nil }
+
+	tests := []struct {
+		name    string
+		spec    string
+		wantErr bool
+	}{
+		{"valid", "*/5 * * * *", false},
+		{"invalid", "61 * * * *", true},
+		{"never matches", "0 0 30 2 *", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := &Scheduler{}
+
+			err := s.Register("task", tt.spec, noop)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Register(%q) = %v, want error %t", tt.spec, err, tt.wantErr)
+			}
+
+			if !tt.wantErr && s.tasks[0].next.IsZero() {
+				t.Error("registered task has no next run")
+			}
+		})
+	}
+
+	s := &Scheduler{}
+	if err := s.Register("task", "@hourly", noop); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Register("task", "@daily", noop); err == nil {
+		t.Error("duplicate task name was accepted")
+	}
+}
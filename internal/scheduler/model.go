@@ -0,0 +1,45 @@
+package scheduler
+
+import (
+	"context"
+	"time"
+)
+
+type RunStatus string
+
+const (
+	RunRunning   RunStatus = "running"
+	RunSucceeded RunStatus = "succeeded"
+	RunFailed    RunStatus = "failed"
+)
+
+type Run struct {
+	ID           int64      `json:"id"`
+	Task         string     `json:"task"`
+	ScheduledFor time.Time  `json:"scheduled_for"`
+	Status       RunStatus  `json:"status"`
+	Error        string     `json:"error"`
+	StartedAt    time.Time  `json:"started_at"`
+	FinishedAt   *time.Time `json:"finished_at"`
+}
+
+// TaskInfo is a registered task as reported to admins.
+type TaskInfo struct {
+	Name     string    `json:"name"`
+	Schedule string    `json:"schedule"`
+	NextRun  time.Time `json:"next_run"`
+	LastRun  *Run      `json:"last_run"`
+}
+
+type RunStore interface {
+	// StartRun records that task is running for the slot, reporting false
+	// if another replica has already taken it.
+	StartRun(ctx context.Context, task string, scheduledFor time.Time) (*Run, bool, error)
+	FinishRun(context.Context, *Run) error
+	// GetLastRuns returns the most recent run of each task.
+	GetLastRuns(context.Context) (map[string]Run, error)
+	GetRuns(ctx context.Context, task string, limit int) ([]Run, error)
+	// TryLock takes the task's advisory lock on a dedicated connection. The
+	// returned func releases it.
+	TryLock(ctx context.Context, task string) (func(), bool, error)
+}
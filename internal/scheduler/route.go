@@ -0,0 +1,62 @@
+package scheduler
+
+import (
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/umeh-promise/ecommerce/internal/services/user"
+	"github.com/umeh-promise/ecommerce/utils"
+)
+
+const runHistoryLimit = 50
+
+type Handler struct {
+	scheduler *Scheduler
+}
+
+func NewHandler(scheduler *Scheduler) *Handler {
+	return &Handler{scheduler: scheduler}
+}
+
+func (h *Handler) RegisterRoute(auth *user.Handler) func(r chi.Router) {
+	return func(r chi.Router) {
+		r.Route("/scheduler/tasks", func(r chi.Router) {
+			r.Use(auth.AuthTokenMiddleware, auth.AdminMiddleware)
+			r.Get("/", h.getTasks)
+			r.Get("/{task}/runs", h.getRuns)
+		})
+	}
+}
+
+func (h *Handler) getTasks(w http.ResponseWriter, r *http.Request) {
+	tasks, err := h.scheduler.Tasks(r.Context())
+	if err != nil {
+		utils.InternalServerError(w, r, err)
+		return
+	}
+
+	if err := utils.JSONResponse(w, http.StatusOK, tasks); err != nil {
+		utils.InternalServerError(w, r, err)
+		return
+	}
+}
+
+func (h *Handler) getRuns(w http.ResponseWriter, r *http.Request) {
+	name := chi.URLParam(r, "task")
+
+	if !h.scheduler.hasTask(name) {
+		utils.NotFoundResponse(w, r, utils.ErrorNotFound)
+		return
+	}
+
+	runs, err := h.scheduler.store.GetRuns(r.Context(), name, runHistoryLimit)
+	if err != nil {
+		utils.InternalServerError(w, r, err)
+		return
+	}
+
+	if err := utils.JSONResponse(w, http.StatusOK, runs); err != nil {
+		utils.InternalServerError(w, r, err)
+		return
+	}
+}
@@ -0,0 +1,201 @@
+package scheduler
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/umeh-promise/ecommerce/utils"
+)
+
+const (
+	tickInterval = time.Second
+	// taskTimeout bounds a single run so a stuck task cannot hold its lock
+	// forever.
+	taskTimeout = 10 * time.Minute
+)
+
+type TaskFunc func(context.Context) error
+
+type task struct {
+	name     string
+	spec     string
+	schedule *Schedule
+	fn       TaskFunc
+	next     time.Time
+	running  bool
+}
+
+// Scheduler runs registered tasks on cron schedules. Every replica runs a
+// Scheduler; a Postgres advisory lock and the run history make sure each
+// scheduled slot of a task runs on only one of them. Slots missed while no
+// replica was up are skipped rather than caught up.
+type Scheduler struct {
+	store RunStore
+	mu    sync.Mutex
+	tasks []*task
+}
+
+func New(db *sql.DB) *Scheduler {
+	return &Scheduler{store: NewStore(db)}
+}
+
+// Register adds a task. Schedules are evaluated in UTC, and one that never
+// matches, such as 30 February, is refused.
+func (s *Scheduler) Register(name, spec string, fn TaskFunc) error {
+	schedule, err := ParseSchedule(spec)
+	if err != nil {
+		return fmt.Errorf("task %s: %w", name, err)
+	}
+
+	next := schedule.Next(time.Now().UTC())
+	if next.IsZero() {
+		return fmt.Errorf("task %s: cron expression %q never matches", name, spec)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, t := range s.tasks {
+		if t.name == name {
+			return fmt.Errorf("task %s is already registered", name)
+		}
+	}
+
+	s.tasks = append(s.tasks, &task{
+		name:     name,
+		spec:     spec,
+		schedule: schedule,
+		fn:       fn,
+		next:     next,
+	})
+
+	return nil
+}
+
+// Run starts due tasks until ctx is cancelled.
+func (s *Scheduler) Run(ctx context.Context) {
+	ticker := time.NewTicker(tickInterval)
+	defer ticker.Stop()
+
+	var wg sync.WaitGroup
+	defer wg.Wait()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		now := time.Now().UTC()
+
+		s.mu.Lock()
+		for _, t := range s.tasks {
+			// A zero next means the schedule has run out of matches.
+			if t.next.IsZero() || now.Before(t.next) || t.running {
+				continue
+			}
+
+			slot := t.next
+			t.next = t.schedule.Next(now)
+			t.running = true
+
+			wg.Add(1)
+			go func(t *task) {
+				defer wg.Done()
+				s.run(ctx, t, slot)
+
+				s.mu.Lock()
+				t.running = false
+				s.mu.Unlock()
+			}(t)
+		}
+		s.mu.Unlock()
+	}
+}
+
+func (s *Scheduler) run(ctx context.Context, t *task, slot time.Time) {
+	release, locked, err := s.store.TryLock(ctx, t.name)
+	if err != nil {
+		utils.Logger.Errorw("failed to take scheduler lock", "task", t.name, "error", err.Error())
+		return
+	}
+	if !locked {
+		return
+	}
+	defer release()
+
+	run, started, err := s.store.StartRun(ctx, t.name, slot)
+	if err != nil {
+		utils.Logger.Errorw("failed to record scheduler run", "task", t.name, "error", err.Error())
+		return
+	}
+	if !started {
+		// Another replica already ran this slot.
+		return
+	}
+
+	run.Status = RunSucceeded
+	if err := execute(ctx, t.fn); err != nil {
+		run.Status = RunFailed
+		run.Error = err.Error()
+		utils.Logger.Errorw("scheduled task failed", "task", t.name, "error", err.Error())
+	}
+
+	if err := s.store.FinishRun(context.WithoutCancel(ctx), run); err != nil {
+		utils.Logger.Errorw("failed to record scheduler run", "task", t.name, "error", err.Error())
+	}
+}
+
+func execute(ctx context.Context, fn TaskFunc) (err error) {
+	ctx, cancel := context.WithTimeout(ctx, taskTimeout)
+	defer cancel()
+
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("panic: %v", r)
+		}
+	}()
+
+	return fn(ctx)
+}
+
+// Tasks lists the registered tasks with their most recent run.
+func (s *Scheduler) Tasks(ctx context.Context) ([]TaskInfo, error) {
+	lastRuns, err := s.store.GetLastRuns(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	tasks := make([]TaskInfo, 0, len(s.tasks))
+
+	for _, t := range s.tasks {
+		info := TaskInfo{Name: t.name, Schedule: t.spec, NextRun: t.next}
+		if run, ok := lastRuns[t.name]; ok {
+			info.LastRun = &run
+		}
+
+		tasks = append(tasks, info)
+	}
+
+	return tasks, nil
+}
+
+func (s *Scheduler) hasTask(name string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, t := range s.tasks {
+		if t.name == name {
+			return true
+		}
+	}
+
+	return false
+}
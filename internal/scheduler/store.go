@@ -0,0 +1,168 @@
+package scheduler
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/umeh-promise/ecommerce/utils"
+)
+
+const runColumns = `id, task, scheduled_for, status, error, started_at, finished_at`
+
+type scanner interface {
+	Scan(dest ...any) error
+}
+
+func scanRun(row scanner, run *Run) error {
+	var finishedAt sql.NullTime
+
+	err := row.Scan(
+		&run.ID,
+		&run.Task,
+		&run.ScheduledFor,
+		&run.Status,
+		&run.Error,
+		&run.StartedAt,
+		&finishedAt,
+	)
+	if err != nil {
+		return err
+	}
+
+	run.FinishedAt = nil
+	if finishedAt.Valid {
+		run.FinishedAt = &finishedAt.Time
+	}
+
+	return nil
+}
+
+type Store struct {
+	db *sql.DB
+}
+
+func NewStore(db *sql.DB) *Store {
+	return &Store{db: db}
+}
+
+func (s *Store) StartRun(ctx context.Context, task string, scheduledFor time.Time) (*Run, bool, error) {
+	query := `
+		INSERT INTO scheduler_runs (task, scheduled_for)
+		VALUES ($1, $2)
+		ON CONFLICT ON CONSTRAINT scheduler_runs_task_slot_key DO NOTHING
+		RETURNING ` + runColumns
+
+	ctx, cancel := context.WithTimeout(ctx, utils.QueryTimeout)
+	defer cancel()
+
+	var run Run
+
+	err := scanRun(s.db.QueryRowContext(ctx, query, task, scheduledFor), &run)
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return nil, false, nil
+		default:
+			return nil, false, err
+		}
+	}
+
+	return &run, true, nil
+}
+
+func (s *Store) FinishRun(ctx context.Context, run *Run) error {
+	query := `
+		UPDATE scheduler_runs
+		SET status = $1, error = $2, finished_at = now()
+		WHERE id = $3
+		RETURNING finished_at
+	`
+
+	ctx, cancel := context.WithTimeout(ctx, utils.QueryTimeout)
+	defer cancel()
+
+	return s.db.QueryRowContext(ctx, query, run.Status, run.Error, run.ID).Scan(&run.FinishedAt)
+}
+
+func (s *Store) GetLastRuns(ctx context.Context) (map[string]Run, error) {
+	query := `SELECT DISTINCT ON (task) ` + runColumns + ` FROM scheduler_runs ORDER BY task, started_at DESC, id DESC`
+
+	ctx, cancel := context.WithTimeout(ctx, utils.QueryTimeout)
+	defer cancel()
+
+	rows, err := s.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	runs := map[string]Run{}
+
+	for rows.Next() {
+		run := Run{}
+		if err := scanRun(rows, &run); err != nil {
+			return nil, err
+		}
+
+		runs[run.Task] = run
+	}
+
+	return runs, rows.Err()
+}
+
+func (s *Store) GetRuns(ctx context.Context, task string, limit int) ([]Run, error) {
+	query := `SELECT ` + runColumns + ` FROM scheduler_runs WHERE task = $1 ORDER BY started_at DESC, id DESC LIMIT $2`
+
+	ctx, cancel := context.WithTimeout(ctx, utils.QueryTimeout)
+	defer cancel()
+
+	rows, err := s.db.QueryContext(ctx, query, task, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var runs []Run
+
+	for rows.Next() {
+		run := Run{}
+		if err := scanRun(rows, &run); err != nil {
+			return nil, err
+		}
+
+		runs = append(runs, run)
+	}
+
+	return runs, rows.Err()
+}
+
+// TryLock uses a session-level advisory lock, so it holds a connection out
+// of the pool until released.
+func (s *Store) TryLock(ctx context.Context, task string) (func(), bool, error) {
+	conn, err := s.db.Conn(ctx)
+	if err != nil {
+		return nil, false, err
+	}
+
+	var locked bool
+
+	err = conn.QueryRowContext(ctx, `SELECT pg_try_advisory_lock(hashtext('scheduler:' || $1))`, task).Scan(&locked)
+	if err != nil || !locked {
+		conn.Close()
+		return nil, false, err
+	}
+
+	release := func() {
+		ctx, cancel := context.WithTimeout(context.Background(), utils.QueryTimeout)
+		defer cancel()
+
+		if _, err := conn.ExecContext(ctx, `SELECT pg_advisory_unlock(hashtext('scheduler:' || $1))`, task); err != nil {
+			utils.Logger.Warnw("failed to release scheduler lock", "task", task, "error", err.Error())
+		}
+		conn.Close()
+	}
+
+	return release, true, nil
+}
@@ -0,0 +1,46 @@
+package addresses
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/umeh-promise/ecommerce/internal/services/user"
+	"github.com/umeh-promise/ecommerce/utils"
+)
+
+type addressKey string
+
+var addressCtx addressKey = "address"
+
+// AddressMiddleware loads the caller's own address. It must be mounted
+// after AuthTokenMiddleware.
+func (middleware *Handler) AddressMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		addressID := chi.URLParam(r, "id")
+		ctx := r.Context()
+
+		address, err := middleware.store.GetAddressByID(ctx, addressID)
+		if err != nil {
+			switch err {
+			case utils.ErrorNotFound:
+				utils.NotFoundResponse(w, r, err)
+			default:
+				utils.InternalServerError(w, r, err)
+			}
+			return
+		}
+
+		if address.UserID != user.GetUserFromContext(r).ID {
+			utils.NotFoundResponse(w, r, utils.ErrorNotFound)
+			return
+		}
+
+		ctx = context.WithValue(ctx, addressCtx, address)
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+func GetAddressFromContext(r *http.Request) *Address {
+	return r.Context().Value(addressCtx).(*Address)
+}
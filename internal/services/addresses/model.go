@@ -0,0 +1,103 @@
+package addresses
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+type Address struct {
+	ID                string `json:"id"`
+	UserID            string `json:"-"`
+	FullName          string `json:"full_name"`
+	Line1             string `json:"line1"`
+	Line2             string `json:"line2"`
+	City              string `json:"city"`
+	Region            string `json:"region"`
+	PostalCode        string `json:"postal_code"`
+	Country           string `json:"country"`
+	PhoneNumber       string `json:"phone_number"`
+	IsDefaultShipping bool   `json:"is_default_shipping"`
+	IsDefaultBilling  bool   `json:"is_default_billing"`
+	Version           string `json:"-"`
+	CreatedAt         string `json:"created_at"`
+	UpdatedAt         string `json:"-"`
+}
+
+// countryRule lists what a country's postal system needs on top of the
+// fields every address has. Countries without a rule only need those.
+type countryRule struct {
+	requiresRegion bool
+	postalCode     *regexp.Regexp
+}
+
+var countryRules = map[string]countryRule{
+	"US": {requiresRegion: true, postalCode: regexp.MustCompile(`^\d{5}(-\d{4})?$`)},
+	"CA": {requiresRegion: true, postalCode: regexp.MustCompile(`^[A-Z]\d[A-Z] ?\d[A-Z]\d$`)},
+	"AU": {requiresRegion: true, postalCode: regexp.MustCompile(`^\d{4}$`)},
+	"GB": {postalCode: regexp.MustCompile(`^[A-Z]{1,2}\d[A-Z\d]? ?\d[A-Z]{2}$`)},
+	"DE": {postalCode: regexp.MustCompile(`^\d{5}$`)},
+	"FR": {postalCode: regexp.MustCompile(`^\d{5}$`)},
+	"NG": {requiresRegion: true},
+}
+
+// Normalize uppercases codes so they compare and validate consistently.
+func (a *Address) Normalize() {
+	a.Country = strings.ToUpper(strings.TrimSpace(a.Country))
+	a.PostalCode = strings.ToUpper(strings.TrimSpace(a.PostalCode))
+}
+
+// Validate applies the country-specific requirements. Field presence and
+// lengths are checked by the payload tags.
+func (a *Address) Validate() error {
+	rule, ok := countryRules[a.Country]
+	if !ok {
+		return nil
+	}
+
+	if rule.requiresRegion && a.Region == "" {
+		return fmt.Errorf("region is required for %s addresses", a.Country)
+	}
+
+	if rule.postalCode != nil && !rule.postalCode.MatchString(a.PostalCode) {
+		return fmt.Errorf("postal code %q is not valid for %s", a.PostalCode, a.Country)
+	}
+
+	return nil
+}
+
+type AddressStore interface {
+	CreateAddress(context.Context, *Address) error
+	GetAddressesByUserID(context.Context, string) ([]Address, error)
+	GetAddressByID(context.Context, string) (*Address, error)
+	GetDefaultShippingAddress(context.Context, string) (*Address, error)
+	UpdateAddress(context.Context, *Address) error
+	DeleteAddress(context.Context, string) error
+}
+
+type AddressPayload struct {
+	FullName          string `json:"full_name" validate:"required,max=100"`
+	Line1             string `json:"line1" validate:"required,max=255"`
+	Line2             string `json:"line2" validate:"max=255"`
+	City              string `json:"city" validate:"required,max=100"`
+	Region            string `json:"region" validate:"max=100"`
+	PostalCode        string `json:"postal_code" validate:"max=20"`
+	Country           string `json:"country" validate:"required,iso3166_1_alpha2"`
+	PhoneNumber       string `json:"phone_number" validate:"max=30"`
+	IsDefaultShipping bool   `json:"is_default_shipping"`
+	IsDefaultBilling  bool   `json:"is_default_billing"`
+}
+
+type UpdateAddressPayload struct {
+	FullName          *string `json:"full_name" validate:"omitempty,min=1,max=100"`
+	Line1             *string `json:"line1" validate:"omitempty,min=1,max=255"`
+	Line2             *string `json:"line2" validate:"omitempty,max=255"`
+	City              *string `json:"city" validate:"omitempty,min=1,max=100"`
+	Region            *string `json:"region" validate:"omitempty,max=100"`
+	PostalCode        *string `json:"postal_code" validate:"omitempty,max=20"`
+	Country           *string `json:"country" validate:"omitempty,iso3166_1_alpha2"`
+	PhoneNumber       *string `json:"phone_number" validate:"omitempty,max=30"`
+	IsDefaultShipping *bool   `json:"is_default_shipping"`
+	IsDefaultBilling  *bool   `json:"is_default_billing"`
+}
@@ -0,0 +1,164 @@
+package addresses
+
+import (
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/umeh-promise/ecommerce/internal/services/user"
+	"github.com/umeh-promise/ecommerce/utils"
+)
+
+type Handler struct {
+	store AddressStore
+}
+
+func NewHandler(store AddressStore) *Handler {
+	return &Handler{store: store}
+}
+
+func (h *Handler) RegisterRoute(auth *user.Handler) func(r chi.Router) {
+	return func(r chi.Router) {
+		r.Route("/auth/user/addresses", func(r chi.Router) {
+			r.Use(auth.AuthTokenMiddleware)
+			r.Get("/", h.getAddresses)
+			r.Post("/", h.createAddress)
+
+			r.Route("/{id}", func(r chi.Router) {
+				r.Use(h.AddressMiddleware)
+				r.Get("/", h.getAddress)
+				r.Put("/", h.updateAddress)
+				r.Delete("/", h.deleteAddress)
+			})
+		})
+	}
+}
+
+func (h *Handler) getAddresses(w http.ResponseWriter, r *http.Request) {
+	user := user.GetUserFromContext(r)
+
+	addresses, err := h.store.GetAddressesByUserID(r.Context(), user.ID)
+	if err != nil {
+		utils.InternalServerError(w, r, err)
+		return
+	}
+
+	if err := utils.JSONResponse(w, http.StatusOK, addresses); err != nil {
+		utils.InternalServerError(w, r, err)
+		return
+	}
+}
+
+func (h *Handler) createAddress(w http.ResponseWriter, r *http.Request) {
+	var payload AddressPayload
+
+	if err := utils.ParseJSON(w, r, &payload); err != nil {
+		utils.BadRequestError(w, r, err)
+		return
+	}
+
+	if err := utils.Validator.Struct(payload); err != nil {
+		utils.BadRequestError(w, r, err)
+		return
+	}
+
+	address := &Address{
+		UserID:            user.GetUserFromContext(r).ID,
+		FullName:          payload.FullName,
+		Line1:             payload.Line1,
+		Line2:             payload.Line2,
+		City:              payload.City,
+		Region:            payload.Region,
+		PostalCode:        payload.PostalCode,
+		Country:           payload.Country,
+		PhoneNumber:       payload.PhoneNumber,
+		IsDefaultShipping: payload.IsDefaultShipping,
+		IsDefaultBilling:  payload.IsDefaultBilling,
+	}
+
+	address.Normalize()
+	if err := address.Validate(); err != nil {
+		utils.BadRequestError(w, r, err)
+		return
+	}
+
+	if err := h.store.CreateAddress(r.Context(), address); err != nil {
+		utils.InternalServerError(w, r, err)
+		return
+	}
+
+	if err := utils.JSONResponse(w, http.StatusCreated, address); err != nil {
+		utils.InternalServerError(w, r, err)
+		return
+	}
+}
+
+func (h *Handler) getAddress(w http.ResponseWriter, r *http.Request) {
+	address := GetAddressFromContext(r)
+
+	if err := utils.JSONResponse(w, http.StatusOK, address); err != nil {
+		utils.InternalServerError(w, r, err)
+		return
+	}
+}
+
+func (h *Handler) updateAddress(w http.ResponseWriter, r *http.Request) {
+	var payload UpdateAddressPayload
+
+	if err := utils.ParseJSON(w, r, &payload); err != nil {
+		utils.BadRequestError(w, r, err)
+		return
+	}
+
+	if err := utils.Validator.Struct(payload); err != nil {
+		utils.BadRequestError(w, r, err)
+		return
+	}
+
+	address := GetAddressFromContext(r)
+
+	utils.AssignIfNotNil(&address.FullName, payload.FullName)
+	utils.AssignIfNotNil(&address.Line1, payload.Line1)
+	utils.AssignIfNotNil(&address.Line2, payload.Line2)
+	utils.AssignIfNotNil(&address.City, payload.City)
+	utils.AssignIfNotNil(&address.Region, payload.Region)
+	utils.AssignIfNotNil(&address.PostalCode, payload.PostalCode)
+	utils.AssignIfNotNil(&address.Country, payload.Country)
+	utils.AssignIfNotNil(&address.PhoneNumber, payload.PhoneNumber)
+	utils.AssignIfNotNil(&address.IsDefaultShipping, payload.IsDefaultShipping)
+	utils.AssignIfNotNil(&address.IsDefaultBilling, payload.IsDefaultBilling)
+
+	address.Normalize()
+	if err := address.Validate(); err != nil {
+		utils.BadRequestError(w, r, err)
+		return
+	}
+
+	if err := h.store.UpdateAddress(r.Context(), address); err != nil {
+		switch err {
+		case utils.ErrorNotFound:
+			utils.NotFoundResponse(w, r, err)
+		default:
+			utils.InternalServerError(w, r, err)
+		}
+		return
+	}
+
+	if err := utils.JSONResponse(w, http.StatusOK, address); err != nil {
+		utils.InternalServerError(w, r, err)
+		return
+	}
+}
+
+func (h *Handler) deleteAddress(w http.ResponseWriter, r *http.Request) {
+	address := GetAddressFromContext(r)
+
+	if err := h.store.DeleteAddress(r.Context(), address.ID); err != nil {
+		utils.InternalServerError(w, r, err)
+		return
+	}
+
+	if err := utils.JSONResponse(w, http.StatusNoContent, nil); err != nil {
+		utils.InternalServerError(w, r, err)
+		return
+	}
+}
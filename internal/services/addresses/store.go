@@ -0,0 +1,199 @@
+package addresses
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	uuid "github.com/satori/go.uuid"
+	"github.com/umeh-promise/ecommerce/utils"
+)
+
+const addressColumns = `id, user_id, full_name, line1, line2, city, region, postal_code, country, phone_number,
+	is_default_shipping, is_default_billing, version, created_at, updated_at`
+
+type scanner interface {
+	Scan(dest ...any) error
+}
+
+func scanAddress(row scanner, address *Address) error {
+	return row.Scan(
+		&address.ID,
+		&address.UserID,
+		&address.FullName,
+		&address.Line1,
+		&address.Line2,
+		&address.City,
+		&address.Region,
+		&address.PostalCode,
+		&address.Country,
+		&address.PhoneNumber,
+		&address.IsDefaultShipping,
+		&address.IsDefaultBilling,
+		&address.Version,
+		&address.CreatedAt,
+		&address.UpdatedAt,
+	)
+}
+
+type Store struct {
+	db *sql.DB
+}
+
+func NewStore(db *sql.DB) *Store {
+	return &Store{db: db}
+}
+
+// clearDefaults drops the user's other defaults of the kinds address is
+// claiming, so the partial unique indexes are never violated.
+func clearDefaults(ctx context.Context, tx *sql.Tx, address *Address) error {
+	_, err := tx.ExecContext(ctx, `
+		UPDATE addresses
+		SET is_default_shipping = is_default_shipping AND NOT $1,
+			is_default_billing = is_default_billing AND NOT $2
+		WHERE user_id = $3 AND id <> $4 AND (($1 AND is_default_shipping) OR ($2 AND is_default_billing))
+	`, address.IsDefaultShipping, address.IsDefaultBilling, address.UserID, address.ID)
+	return err
+}
+
+// CreateAddress stores the address. A user's first address becomes their
+// default for both shipping and billing.
+func (s *Store) CreateAddress(ctx context.Context, address *Address) error {
+	query := `
+		INSERT INTO addresses
+			(id, user_id, full_name, line1, line2, city, region, postal_code, country, phone_number,
+			is_default_shipping, is_default_billing)
+		VALUES
+			($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)
+		RETURNING version, created_at, updated_at
+	`
+
+	address.ID = uuid.NewV4().String()
+
+	ctx, cancel := context.WithTimeout(ctx, utils.QueryTimeout)
+	defer cancel()
+
+	return utils.WithTx(ctx, s.db, func(tx *sql.Tx) error {
+		var exists bool
+		err := tx.QueryRowContext(ctx,
+			`SELECT EXISTS (SELECT 1 FROM addresses WHERE user_id = $1)`, address.UserID,
+		).Scan(&exists)
+		if err != nil {
+			return err
+		}
+
+		if !exists {
+			address.IsDefaultShipping = true
+			address.IsDefaultBilling = true
+		}
+
+		if err := clearDefaults(ctx, tx, address); err != nil {
+			return err
+		}
+
+		return tx.QueryRowContext(ctx, query,
+			address.ID, address.UserID, address.FullName, address.Line1, address.Line2, address.City,
+			address.Region, address.PostalCode, address.Country, address.PhoneNumber,
+			address.IsDefaultShipping, address.IsDefaultBilling,
+		).Scan(&address.Version, &address.CreatedAt, &address.UpdatedAt)
+	})
+}
+
+func (s *Store) GetAddressesByUserID(ctx context.Context, userID string) ([]Address, error) {
+	query := `SELECT ` + addressColumns + ` FROM addresses
+		WHERE user_id = $1
+		ORDER BY is_default_shipping DESC, created_at DESC`
+
+	ctx, cancel := context.WithTimeout(ctx, utils.QueryTimeout)
+	defer cancel()
+
+	rows, err := s.db.QueryContext(ctx, query, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var addresses []Address
+
+	for rows.Next() {
+		address := Address{}
+		if err := scanAddress(rows, &address); err != nil {
+			return nil, err
+		}
+
+		addresses = append(addresses, address)
+	}
+
+	return addresses, rows.Err()
+}
+
+func (s *Store) GetAddressByID(ctx context.Context, id string) (*Address, error) {
+	return s.getAddress(ctx, `SELECT `+addressColumns+` FROM addresses WHERE id = $1`, id)
+}
+
+func (s *Store) GetDefaultShippingAddress(ctx context.Context, userID string) (*Address, error) {
+	return s.getAddress(ctx, `SELECT `+addressColumns+` FROM addresses WHERE user_id = $1 AND is_default_shipping`, userID)
+}
+
+func (s *Store) getAddress(ctx context.Context, query string, arg string) (*Address, error) {
+	var address Address
+
+	ctx, cancel := context.WithTimeout(ctx, utils.QueryTimeout)
+	defer cancel()
+
+	if err := scanAddress(s.db.QueryRowContext(ctx, query, arg), &address); err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return nil, utils.ErrorNotFound
+		default:
+			return nil, err
+		}
+	}
+
+	return &address, nil
+}
+
+func (s *Store) UpdateAddress(ctx context.Context, address *Address) error {
+	query := `
+		UPDATE addresses
+		SET full_name = $1, line1 = $2, line2 = $3, city = $4, region = $5, postal_code = $6,
+			country = $7, phone_number = $8, is_default_shipping = $9, is_default_billing = $10,
+			version = version + 1, updated_at = now()
+		WHERE id = $11 AND version = $12
+		RETURNING version
+	`
+
+	ctx, cancel := context.WithTimeout(ctx, utils.QueryTimeout)
+	defer cancel()
+
+	return utils.WithTx(ctx, s.db, func(tx *sql.Tx) error {
+		if err := clearDefaults(ctx, tx, address); err != nil {
+			return err
+		}
+
+		err := tx.QueryRowContext(ctx, query,
+			address.FullName, address.Line1, address.Line2, address.City, address.Region,
+			address.PostalCode, address.Country, address.PhoneNumber,
+			address.IsDefaultShipping, address.IsDefaultBilling,
+			address.ID, address.Version,
+		).Scan(&address.Version)
+		if err != nil {
+			switch {
+			case errors.Is(err, sql.ErrNoRows):
+				return utils.ErrorNotFound
+			default:
+				return err
+			}
+		}
+
+		return nil
+	})
+}
+
+func (s *Store) DeleteAddress(ctx context.Context, id string) error {
+	ctx, cancel := context.WithTimeout(ctx, utils.QueryTimeout)
+	defer cancel()
+
+	_, err := s.db.ExecContext(ctx, `DELETE FROM addresses WHERE id = $1`, id)
+	return err
+}
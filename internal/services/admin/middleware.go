@@ -0,0 +1,95 @@
+package admin
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/umeh-promise/ecommerce/internal/services/orders"
+	"github.com/umeh-promise/ecommerce/internal/services/products"
+	"github.com/umeh-promise/ecommerce/internal/services/user"
+	"github.com/umeh-promise/ecommerce/utils"
+)
+
+type adminKey string
+
+var (
+	targetUserCtx adminKey = "user"
+	productCtx    adminKey = "product"
+	orderCtx      adminKey = "order"
+)
+
+// The middlewares here load the target of an admin action whatever its
+// owner or status, unlike the ones in each service.
+
+func (middleware *Handler) UserMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+
+		target, err := middleware.userStore.GetUserByID(ctx, chi.URLParam(r, "userID"))
+		if err != nil {
+			switch err {
+			case utils.ErrorNotFound:
+				utils.NotFoundResponse(w, r, err)
+			default:
+				utils.InternalServerError(w, r, err)
+			}
+			return
+		}
+
+		ctx = context.WithValue(ctx, targetUserCtx, target)
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+func (middleware *Handler) ProductMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+
+		product, err := middleware.productStore.GetPostByID(ctx, chi.URLParam(r, "productID"))
+		if err != nil {
+			switch err {
+			case utils.ErrorNotFound:
+				utils.NotFoundResponse(w, r, err)
+			default:
+				utils.InternalServerError(w, r, err)
+			}
+			return
+		}
+
+		ctx = context.WithValue(ctx, productCtx, product)
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+func (middleware *Handler) OrderMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+
+		order, err := middleware.orderStore.GetOrderByID(ctx, chi.URLParam(r, "orderID"))
+		if err != nil {
+			switch err {
+			case utils.ErrorNotFound:
+				utils.NotFoundResponse(w, r, err)
+			default:
+				utils.InternalServerError(w, r, err)
+			}
+			return
+		}
+
+		ctx = context.WithValue(ctx, orderCtx, order)
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+func GetTargetUserFromContext(r *http.Request) *user.User {
+	return r.Context().Value(targetUserCtx).(*user.User)
+}
+
+func GetProductFromContext(r *http.Request) *products.Product {
+	return r.Context().Value(productCtx).(*products.Product)
+}
+
+func GetOrderFromContext(r *http.Request) *orders.Order {
+	return r.Context().Value(orderCtx).(*orders.Order)
+}
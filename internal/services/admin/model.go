@@ -0,0 +1,24 @@
+package admin
+
+import "context"
+
+type Stats struct {
+	Users struct {
+		Total     int `json:"total"`
+		Admins    int `json:"admins"`
+		Suspended int `json:"suspended"`
+	} `json:"users"`
+	// Products and Orders are counts by status.
+	Products map[string]int `json:"products"`
+	Orders   map[string]int `json:"orders"`
+	// Revenue is the total of paid orders less refunds, in minor units.
+	Revenue int `json:"revenue"`
+}
+
+type AdminStore interface {
+	GetStats(context.Context) (*Stats, error)
+}
+
+type OrderStatusPayload struct {
+	Status string `json:"status" validate:"required,oneof=paid cancelled"`
+}
@@ -0,0 +1,503 @@
+package admin
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/umeh-promise/ecommerce/internal/audit"
+	"github.com/umeh-promise/ecommerce/internal/services/ledger"
+	"github.com/umeh-promise/ecommerce/internal/services/orders"
+	"github.com/umeh-promise/ecommerce/internal/services/products"
+	"github.com/umeh-promise/ecommerce/internal/services/user"
+	"github.com/umeh-promise/ecommerce/utils"
+)
+
+const defaultPageSize = 50
+
+type Handler struct {
+	store        AdminStore
+	userStore    user.UserStore
+	productStore products.ProductStore
+	orderStore   orders.OrderStore
+	ledgerStore  ledger.LedgerStore
+	auditStore   audit.AuditStore
+}
+
+func NewHandler(store AdminStore, userStore user.UserStore, productStore products.ProductStore, orderStore orders.OrderStore, ledgerStore ledger.LedgerStore, auditStore audit.AuditStore) *Handler {
+	return &Handler{
+		store:        store,
+		userStore:    userStore,
+		productStore: productStore,
+		orderStore:   orderStore,
+		ledgerStore:  ledgerStore,
+		auditStore:   auditStore,
+	}
+}
+
+func (h *Handler) RegisterRoute(auth *user.Handler) func(r chi.Router) {
+	return func(r chi.Router) {
+		r.Route("/admin", func(r chi.Router) {
+			r.Use(auth.AuthTokenMiddleware, auth.AdminMiddleware)
+			r.Get("/stats", h.getStats)
+			r.Get("/audit", h.getAuditEntries)
+			r.Get("/audit/verify", h.verifyAuditLog)
+
+			r.Route("/users", func(r chi.Router) {
+				r.Get("/", h.searchUsers)
+				r.Route("/{userID}", func(r chi.Router) {
+					r.Use(h.UserMiddleware)
+					r.Get("/", h.getUser)
+					r.Post("/suspend", h.suspendUser)
+					r.Post("/unsuspend", h.unsuspendUser)
+					r.Post("/force-password-reset", h.forcePasswordReset)
+				})
+			})
+
+			r.Route("/products", func(r chi.Router) {
+				r.Get("/", h.getProducts)
+				r.Route("/{productID}", func(r chi.Router) {
+					r.Use(h.ProductMiddleware)
+					r.Get("/", h.getProduct)
+					r.Post("/hide", h.hideProduct)
+					r.Post("/unhide", h.unhideProduct)
+					r.Delete("/", h.deleteProduct)
+				})
+			})
+
+			r.Route("/orders", func(r chi.Router) {
+				r.Get("/", h.getOrders)
+				r.Route("/{orderID}", func(r chi.Router) {
+					r.Use(h.OrderMiddleware)
+					r.Get("/", h.getOrder)
+					r.Put("/status", h.updateOrderStatus)
+				})
+			})
+		})
+	}
+}
+
+// page reads the limit and offset query parameters. The filters they go
+// into validate the range.
+func page(r *http.Request) (int, int, error) {
+	limit, offset := defaultPageSize, 0
+
+	query := r.URL.Query()
+
+	if value := query.Get("limit"); value != "" {
+		n, err := strconv.Atoi(value)
+		if err != nil {
+			return 0, 0, fmt.Errorf("limit must be a number")
+		}
+		limit = n
+	}
+
+	if value := query.Get("offset"); value != "" {
+		n, err := strconv.Atoi(value)
+		if err != nil {
+			return 0, 0, fmt.Errorf("offset must be a number")
+		}
+		offset = n
+	}
+
+	return limit, offset, nil
+}
+
+// audit records an admin action after it has been made.
+func (h *Handler) audit(r *http.Request, action, targetType, targetID string, before, after any) {
+	actor := user.GetUserFromContext(r)
+
+	audit.Record(h.auditStore, r, actor.ID, action, targetType, targetID, before, after)
+}
+
+func (h *Handler) getStats(w http.ResponseWriter, r *http.Request) {
+	stats, err := h.store.GetStats(r.Context())
+	if err != nil {
+		utils.InternalServerError(w, r, err)
+		return
+	}
+
+	if err := utils.JSONResponse(w, http.StatusOK, stats); err != nil {
+		utils.InternalServerError(w, r, err)
+		return
+	}
+}
+
+func (h *Handler) searchUsers(w http.ResponseWriter, r *http.Request) {
+	limit, offset, err := page(r)
+	if err != nil {
+		utils.BadRequestError(w, r, err)
+		return
+	}
+
+	query := r.URL.Query()
+
+	filter := &user.UserFilter{
+		Query:  query.Get("q"),
+		Role:   query.Get("role"),
+		Limit:  limit,
+		Offset: offset,
+	}
+
+	if value := query.Get("suspended"); value != "" {
+		suspended, err := strconv.ParseBool(value)
+		if err != nil {
+			utils.BadRequestError(w, r, fmt.Errorf("suspended must be true or false"))
+			return
+		}
+		filter.Suspended = &suspended
+	}
+
+	if err := utils.Validator.Struct(filter); err != nil {
+		utils.BadRequestError(w, r, err)
+		return
+	}
+
+	users, err := h.userStore.SearchUsers(r.Context(), filter)
+	if err != nil {
+		utils.InternalServerError(w, r, err)
+		return
+	}
+
+	if err := utils.JSONResponse(w, http.StatusOK, users); err != nil {
+		utils.InternalServerError(w, r, err)
+		return
+	}
+}
+
+func (h *Handler) getUser(w http.ResponseWriter, r *http.Request) {
+	target := GetTargetUserFromContext(r)
+
+	if err := utils.JSONResponse(w, http.StatusOK, target); err != nil {
+		utils.InternalServerError(w, r, err)
+		return
+	}
+}
+
+func (h *Handler) suspendUser(w http.ResponseWriter, r *http.Request) {
+	if GetTargetUserFromContext(r).ID == user.GetUserFromContext(r).ID {
+		utils.BadRequestError(w, r, fmt.Errorf("you cannot suspend your own account"))
+		return
+	}
+
+	h.setSuspended(w, r, true)
+}
+
+func (h *Handler) unsuspendUser(w http.ResponseWriter, r *http.Request) {
+	h.setSuspended(w, r, false)
+}
+
+func (h *Handler) setSuspended(w http.ResponseWriter, r *http.Request, suspended bool) {
+	target := GetTargetUserFromContext(r)
+	before := *target
+
+	if err := h.userStore.SetSuspended(r.Context(), target, suspended); err != nil {
+		switch err {
+		case utils.ErrorEditConflict:
+			utils.ConflictResponse(w, r, err)
+		default:
+			utils.InternalServerError(w, r, err)
+		}
+		return
+	}
+
+	action := "user.unsuspend"
+	if suspended {
+		action = "user.suspend"
+	}
+	h.audit(r, action, "user", target.ID, &before, target)
+
+	if err := utils.JSONResponse(w, http.StatusOK, target); err != nil {
+		utils.InternalServerError(w, r, err)
+		return
+	}
+}
+
+func (h *Handler) forcePasswordReset(w http.ResponseWriter, r *http.Request) {
+	target := GetTargetUserFromContext(r)
+	before := *target
+
+	if err := h.userStore.RequirePasswordReset(r.Context(), target); err != nil {
+		switch err {
+		case utils.ErrorEditConflict:
+			utils.ConflictResponse(w, r, err)
+		default:
+			utils.InternalServerError(w, r, err)
+		}
+		return
+	}
+
+	h.audit(r, "user.force_password_reset", "user", target.ID, &before, target)
+
+	if err := utils.JSONResponse(w, http.StatusOK, target); err != nil {
+		utils.InternalServerError(w, r, err)
+		return
+	}
+}
+
+func (h *Handler) getProducts(w http.ResponseWriter, r *http.Request) {
+	limit, offset, err := page(r)
+	if err != nil {
+		utils.BadRequestError(w, r, err)
+		return
+	}
+
+	query := r.URL.Query()
+
+	filter := &products.ProductFilter{
+		Query:  query.Get("q"),
+		Status: query.Get("status"),
+		UserID: query.Get("seller_id"),
+		Limit:  limit,
+		Offset: offset,
+	}
+
+	if err := utils.Validator.Struct(filter); err != nil {
+		utils.BadRequestError(w, r, err)
+		return
+	}
+
+	products, err := h.productStore.GetProducts(r.Context(), filter)
+	if err != nil {
+		utils.InternalServerError(w, r, err)
+		return
+	}
+
+	if err := utils.JSONResponse(w, http.StatusOK, products); err != nil {
+		utils.InternalServerError(w, r, err)
+		return
+	}
+}
+
+func (h *Handler) getProduct(w http.ResponseWriter, r *http.Request) {
+	product := GetProductFromContext(r)
+
+	if err := utils.JSONResponse(w, http.StatusOK, product); err != nil {
+		utils.InternalServerError(w, r, err)
+		return
+	}
+}
+
+func (h *Handler) hideProduct(w http.ResponseWriter, r *http.Request) {
+	product := GetProductFromContext(r)
+
+	if product.Status == products.StatusHidden {
+		utils.BadRequestError(w, r, fmt.Errorf("product is already hidden"))
+		return
+	}
+
+	h.setProductStatus(w, r, "product.hide", products.StatusHidden)
+}
+
+// unhideProduct returns the product to draft, so the seller decides when
+// to publish it again.
+func (h *Handler) unhideProduct(w http.ResponseWriter, r *http.Request) {
+	product := GetProductFromContext(r)
+
+	if product.Status != products.StatusHidden {
+		utils.BadRequestError(w, r, fmt.Errorf("product is not hidden"))
+		return
+	}
+
+	h.setProductStatus(w, r, "product.unhide", products.StatusDraft)
+}
+
+func (h *Handler) setProductStatus(w http.ResponseWriter, r *http.Request, action string, status products.ProductStatus) {
+	product := GetProductFromContext(r)
+	before := *product
+
+	product.Status = status
+
+	if err := h.productStore.UpdateProduct(r.Context(), product); err != nil {
+		switch err {
+		case utils.ErrorEditConflict:
+			utils.ConflictResponse(w, r, err)
+		default:
+			utils.InternalServerError(w, r, err)
+		}
+		return
+	}
+
+	h.audit(r, action, "product", product.ID, &before, product)
+
+	if err := utils.JSONResponse(w, http.StatusOK, product); err != nil {
+		utils.InternalServerError(w, r, err)
+		return
+	}
+}
+
+func (h *Handler) deleteProduct(w http.ResponseWriter, r *http.Request) {
+	product := GetProductFromContext(r)
+
+	if err := h.productStore.DeleteProduct(r.Context(), product.ID); err != nil {
+		switch err {
+		case utils.ErrorNotFound:
+			utils.NotFoundResponse(w, r, err)
+		default:
+			utils.InternalServerError(w, r, err)
+		}
+		return
+	}
+
+	h.audit(r, "product.delete", "product", product.ID, product, nil)
+
+	if err := utils.JSONResponse(w, http.StatusNoContent, nil); err != nil {
+		utils.InternalServerError(w, r, err)
+		return
+	}
+}
+
+func (h *Handler) getOrders(w http.ResponseWriter, r *http.Request) {
+	limit, offset, err := page(r)
+	if err != nil {
+		utils.BadRequestError(w, r, err)
+		return
+	}
+
+	query := r.URL.Query()
+
+	filter := &orders.OrderFilter{
+		Status: query.Get("status"),
+		UserID: query.Get("user_id"),
+		Limit:  limit,
+		Offset: offset,
+	}
+
+	if err := utils.Validator.Struct(filter); err != nil {
+		utils.BadRequestError(w, r, err)
+		return
+	}
+
+	orders, err := h.orderStore.GetOrders(r.Context(), filter)
+	if err != nil {
+		utils.InternalServerError(w, r, err)
+		return
+	}
+
+	if err := utils.JSONResponse(w, http.StatusOK, orders); err != nil {
+		utils.InternalServerError(w, r, err)
+		return
+	}
+}
+
+func (h *Handler) getOrder(w http.ResponseWriter, r *http.Request) {
+	order := GetOrderFromContext(r)
+
+	if err := utils.JSONResponse(w, http.StatusOK, order); err != nil {
+		utils.InternalServerError(w, r, err)
+		return
+	}
+}
+
+// updateOrderStatus settles or cancels a pending order, as the order
+// service's own admin endpoint does, and records who did it.
+func (h *Handler) updateOrderStatus(w http.ResponseWriter, r *http.Request) {
+	var payload OrderStatusPayload
+
+	if err := utils.ParseJSON(w, r, &payload); err != nil {
+		utils.BadRequestError(w, r, err)
+		return
+	}
+
+	if err := utils.Validator.Struct(payload); err != nil {
+		utils.BadRequestError(w, r, err)
+		return
+	}
+
+	order := GetOrderFromContext(r)
+	ctx := r.Context()
+
+	if order.Status != orders.StatusPending {
+		utils.BadRequestError(w, r, fmt.Errorf("order is already %s", order.Status))
+		return
+	}
+
+	before := *order
+	before.SellerOrders = append([]orders.SellerOrder(nil), order.SellerOrders...)
+	order.Status = orders.OrderStatus(payload.Status)
+
+	if err := h.orderStore.UpdateOrderStatus(ctx, order, orders.Settlement(ctx, h.ledgerStore, order)); err != nil {
+		switch err {
+		case utils.ErrorNotFound:
+			utils.NotFoundResponse(w, r, err)
+		default:
+			utils.InternalServerError(w, r, err)
+		}
+		return
+	}
+
+	h.audit(r, "order.status", "order", order.ID, &before, order)
+
+	if err := utils.JSONResponse(w, http.StatusOK, order); err != nil {
+		utils.InternalServerError(w, r, err)
+		return
+	}
+}
+
+// getAuditEntries lists audit entries, newest first. from and to are
+// RFC 3339 times bounding when the entries were written.
+func (h *Handler) getAuditEntries(w http.ResponseWriter, r *http.Request) {
+	limit, offset, err := page(r)
+	if err != nil {
+		utils.BadRequestError(w, r, err)
+		return
+	}
+
+	query := r.URL.Query()
+
+	filter := &audit.Filter{
+		ActorID:    query.Get("actor_id"),
+		Action:     query.Get("action"),
+		TargetType: query.Get("target_type"),
+		TargetID:   query.Get("target_id"),
+		Limit:      limit,
+		Offset:     offset,
+	}
+
+	for name, bound := range map[string]**time.Time{"from": &filter.From, "to": &filter.To} {
+		value := query.Get(name)
+		if value == "" {
+			continue
+		}
+
+		t, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			utils.BadRequestError(w, r, fmt.Errorf("%s must be an RFC 3339 time", name))
+			return
+		}
+		*bound = &t
+	}
+
+	if err := utils.Validator.Struct(filter); err != nil {
+		utils.BadRequestError(w, r, err)
+		return
+	}
+
+	entries, err := h.auditStore.GetEntries(r.Context(), filter)
+	if err != nil {
+		utils.InternalServerError(w, r, err)
+		return
+	}
+
+	if err := utils.JSONResponse(w, http.StatusOK, entries); err != nil {
+		utils.InternalServerError(w, r, err)
+		return
+	}
+}
+
+// verifyAuditLog recomputes the audit log's hash chain and reports the
+// first entry that has been altered, removed or inserted out of band.
+func (h *Handler) verifyAuditLog(w http.ResponseWriter, r *http.Request) {
+	result, err := h.auditStore.Verify(r.Context())
+	if err != nil {
+		utils.InternalServerError(w, r, err)
+		return
+	}
+
+	if err := utils.JSONResponse(w, http.StatusOK, result); err != nil {
+		utils.InternalServerError(w, r, err)
+		return
+	}
+}
@@ -0,0 +1,70 @@
+package admin
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/umeh-promise/ecommerce/utils"
+)
+
+type Store struct {
+	db *sql.DB
+}
+
+func NewStore(db *sql.DB) *Store {
+	return &Store{db: db}
+}
+
+func (s *Store) GetStats(ctx context.Context) (*Stats, error) {
+	ctx, cancel := context.WithTimeout(ctx, utils.QueryTimeout)
+	defer cancel()
+
+	stats := &Stats{Products: map[string]int{}, Orders: map[string]int{}}
+
+	err := s.db.QueryRowContext(ctx, `
+		SELECT count(*), count(*) FILTER (WHERE role = 'admin'), count(*) FILTER (WHERE suspended_at IS NOT NULL)
+		FROM users
+	`).Scan(&stats.Users.Total, &stats.Users.Admins, &stats.Users.Suspended)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := countByStatus(ctx, s.db, `SELECT status, count(*) FROM products GROUP BY status`, stats.Products); err != nil {
+		return nil, err
+	}
+
+	if err := countByStatus(ctx, s.db, `SELECT status, count(*) FROM orders GROUP BY status`, stats.Orders); err != nil {
+		return nil, err
+	}
+
+	err = s.db.QueryRowContext(ctx,
+		`SELECT COALESCE(sum(total - refunded_total), 0) FROM orders WHERE status = 'paid'`,
+	).Scan(&stats.Revenue)
+	if err != nil {
+		return nil, err
+	}
+
+	return stats, nil
+}
+
+func countByStatus(ctx context.Context, db *sql.DB, query string, counts map[string]int) error {
+	rows, err := db.QueryContext(ctx, query)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var (
+			status string
+			count  int
+		)
+		if err := rows.Scan(&status, &count); err != nil {
+			return err
+		}
+
+		counts[status] = count
+	}
+
+	return rows.Err()
+}
@@ -0,0 +1,50 @@
+package files
+
+import (
+	"io"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/umeh-promise/ecommerce/internal/storage"
+	"github.com/umeh-promise/ecommerce/utils"
+)
+
+type Handler struct {
+	blobs storage.BlobStore
+}
+
+func NewHandler(blobs storage.BlobStore) *Handler {
+	return &Handler{blobs: blobs}
+}
+
+func (h *Handler) RegisterRoute() func(r chi.Router) {
+	return func(r chi.Router) {
+		r.Get("/files/*", h.getFile)
+	}
+}
+
+func (h *Handler) getFile(w http.ResponseWriter, r *http.Request) {
+	key := chi.URLParam(r, "*")
+
+	body, contentType, err := h.blobs.Get(r.Context(), key)
+	if err != nil {
+		switch err {
+		case utils.ErrorNotFound, storage.ErrorInvalidKey:
+			utils.NotFoundResponse(w, r, err)
+		default:
+			utils.InternalServerError(w, r, err)
+		}
+		return
+	}
+	defer body.Close()
+
+	// Keys are never reused, so the content behind a URL never changes.
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Cache-Control", "public, max-age=31536000, immutable")
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.WriteHeader(http.StatusOK)
+
+	if _, err := io.Copy(w, body); err != nil {
+		utils.Logger.Warnw("failed to stream file", "key", key, "error", err.Error())
+	}
+}
@@ -0,0 +1,52 @@
+package guest
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/umeh-promise/ecommerce/utils"
+)
+
+const sessionMaxAge = 30 * 24 * time.Hour
+
+type Handler struct{}
+
+func NewHandler() *Handler {
+	return &Handler{}
+}
+
+func (h *Handler) RegisterRoute() func(r chi.Router) {
+	return func(r chi.Router) {
+		r.Post("/guest/session", h.createSession)
+	}
+}
+
+// createSession issues a guest token. Browsers keep it as a cookie; other
+// clients send it back in the X-Guest-Token header.
+func (h *Handler) createSession(w http.ResponseWriter, r *http.Request) {
+	token, err := NewToken()
+	if err != nil {
+		utils.InternalServerError(w, r, err)
+		return
+	}
+
+	http.SetCookie(w, &http.Cookie{
+		Name:     CookieName,
+		Value:    token,
+		Path:     "/v1",
+		MaxAge:   int(sessionMaxAge.Seconds()),
+		HttpOnly: true,
+		Secure:   r.TLS != nil,
+		SameSite: http.SameSiteLaxMode,
+	})
+
+	type session struct {
+		Token string `json:"token"`
+	}
+
+	if err := utils.JSONResponse(w, http.StatusCreated, &session{Token: token}); err != nil {
+		utils.InternalServerError(w, r, err)
+		return
+	}
+}
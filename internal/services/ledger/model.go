@@ -0,0 +1,66 @@
+package ledger
+
+import (
+	"context"
+	"database/sql"
+)
+
+type TransactionType string
+
+const (
+	TypeSale       TransactionType = "sale"
+	TypeCommission TransactionType = "commission"
+	TypeRefund     TransactionType = "refund"
+	TypePayout     TransactionType = "payout"
+)
+
+// Accounts follow the platform's point of view: seller_payable is what the
+// platform owes each seller, customer_clearing holds money taken from
+// customers until it is attributed.
+const (
+	AccountCustomerClearing = "customer_clearing"
+	AccountSellerPayable    = "seller_payable"
+	AccountPlatformRevenue  = "platform_revenue"
+	AccountPayouts          = "payouts"
+)
+
+type Entry struct {
+	ID            string          `json:"id"`
+	Type          TransactionType `json:"type"`
+	Account       string          `json:"-"`
+	SellerID      string          `json:"-"`
+	SellerOrderID *string         `json:"seller_order_id"`
+	PayoutID      *string         `json:"payout_id"`
+	Amount        int             `json:"amount"`
+	CreatedAt     string          `json:"created_at"`
+}
+
+type Balance struct {
+	Available int     `json:"available"`
+	Entries   []Entry `json:"entries"`
+}
+
+type Payout struct {
+	ID        string `json:"id"`
+	SellerID  string `json:"seller_id"`
+	Amount    int    `json:"amount"`
+	Status    string `json:"status"`
+	CreatedAt string `json:"created_at"`
+}
+
+// Sale is a paid seller sub-order: Gross is what the customer paid for the
+// seller's items, Commission is the platform's cut of it.
+type Sale struct {
+	SellerOrderID string
+	SellerID      string
+	Gross         int
+	Commission    int
+}
+
+type LedgerStore interface {
+	PostSale(context.Context, *sql.Tx, Sale) error
+	PostRefund(context.Context, *sql.Tx, string, string, int) error
+	GetSellerBalance(context.Context, string) (int, error)
+	GetSellerEntries(context.Context, string, int) ([]Entry, error)
+	RunPayouts(context.Context, int) ([]Payout, error)
+}
@@ -0,0 +1,46 @@
+package ledger
+
+import (
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/umeh-promise/ecommerce/internal/services/user"
+	"github.com/umeh-promise/ecommerce/utils"
+)
+
+const balanceEntriesLimit = 50
+
+type Handler struct {
+	store LedgerStore
+}
+
+func NewHandler(store LedgerStore) *Handler {
+	return &Handler{store: store}
+}
+
+func (h *Handler) RegisterRoute(auth *user.Handler) func(r chi.Router) {
+	return func(r chi.Router) {
+		r.With(auth.AuthTokenMiddleware).Get("/seller/balance", h.getBalance)
+	}
+}
+
+func (h *Handler) getBalance(w http.ResponseWriter, r *http.Request) {
+	user := user.GetUserFromContext(r)
+
+	available, err := h.store.GetSellerBalance(r.Context(), user.ID)
+	if err != nil {
+		utils.InternalServerError(w, r, err)
+		return
+	}
+
+	entries, err := h.store.GetSellerEntries(r.Context(), user.ID, balanceEntriesLimit)
+	if err != nil {
+		utils.InternalServerError(w, r, err)
+		return
+	}
+
+	if err := utils.JSONResponse(w, http.StatusOK, &Balance{Available: available, Entries: entries}); err != nil {
+		utils.InternalServerError(w, r, err)
+		return
+	}
+}
@@ -0,0 +1,218 @@
+package ledger
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	uuid "github.com/satori/go.uuid"
+	"github.com/umeh-promise/ecommerce/utils"
+)
+
+type line struct {
+	account  string
+	sellerID *string
+	amount   int
+}
+
+type Store struct {
+	db *sql.DB
+}
+
+func NewStore(db *sql.DB) *Store {
+	return &Store{db: db}
+}
+
+// post writes one balanced transaction. Callers own the surrounding database
+// transaction so postings commit atomically with the change that caused them.
+func post(ctx context.Context, tx *sql.Tx, kind TransactionType, sellerOrderID, payoutID *string, lines ...line) error {
+	sum := 0
+	for _, l := range lines {
+		sum += l.amount
+	}
+	if sum != 0 {
+		return fmt.Errorf("ledger: unbalanced %s transaction (off by %d)", kind, sum)
+	}
+
+	transactionID := uuid.NewV4().String()
+
+	_, err := tx.ExecContext(ctx, `
+		INSERT INTO ledger_transactions (id, type, seller_order_id, payout_id)
+		VALUES ($1, $2, $3, $4)
+	`, transactionID, kind, sellerOrderID, payoutID)
+	if err != nil {
+		return err
+	}
+
+	for _, l := range lines {
+		_, err := tx.ExecContext(ctx, `
+			INSERT INTO ledger_entries (id, transaction_id, account, seller_user_id, amount)
+			VALUES ($1, $2, $3, $4, $5)
+		`, uuid.NewV4().String(), transactionID, l.account, l.sellerID, l.amount)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (s *Store) PostSale(ctx context.Context, tx *sql.Tx, sale Sale) error {
+	err := post(ctx, tx, TypeSale, &sale.SellerOrderID, nil,
+		line{account: AccountCustomerClearing, amount: -sale.Gross},
+		line{account: AccountSellerPayable, sellerID: &sale.SellerID, amount: sale.Gross},
+	)
+	if err != nil || sale.Commission == 0 {
+		return err
+	}
+
+	return post(ctx, tx, TypeCommission, &sale.SellerOrderID, nil,
+		line{account: AccountSellerPayable, sellerID: &sale.SellerID, amount: -sale.Commission},
+		line{account: AccountPlatformRevenue, amount: sale.Commission},
+	)
+}
+
+// PostRefund charges a refund back to the seller of the sub-order.
+func (s *Store) PostRefund(ctx context.Context, tx *sql.Tx, sellerOrderID, sellerID string, amount int) error {
+	return post(ctx, tx, TypeRefund, &sellerOrderID, nil,
+		line{account: AccountSellerPayable, sellerID: &sellerID, amount: -amount},
+		line{account: AccountCustomerClearing, amount: amount},
+	)
+}
+
+func (s *Store) GetSellerBalance(ctx context.Context, sellerID string) (int, error) {
+	query := `
+		SELECT COALESCE(sum(amount), 0) FROM ledger_entries
+		WHERE account = 'seller_payable' AND seller_user_id = $1
+	`
+
+	ctx, cancel := context.WithTimeout(ctx, utils.QueryTimeout)
+	defer cancel()
+
+	var balance int
+	if err := s.db.QueryRowContext(ctx, query, sellerID).Scan(&balance); err != nil {
+		return 0, err
+	}
+
+	return balance, nil
+}
+
+func (s *Store) GetSellerEntries(ctx context.Context, sellerID string, limit int) ([]Entry, error) {
+	query := `
+		SELECT e.id, t.type, e.account, e.seller_user_id, t.seller_order_id, t.payout_id, e.amount, e.created_at
+		FROM ledger_entries e
+		JOIN ledger_transactions t ON t.id = e.transaction_id
+		WHERE e.account = 'seller_payable' AND e.seller_user_id = $1
+		ORDER BY e.created_at DESC
+		LIMIT $2
+	`
+
+	ctx, cancel := context.WithTimeout(ctx, utils.QueryTimeout)
+	defer cancel()
+
+	rows, err := s.db.QueryContext(ctx, query, sellerID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var entries []Entry
+
+	for rows.Next() {
+		var (
+			entry         Entry
+			sellerOrderID sql.NullString
+			payoutID      sql.NullString
+		)
+
+		err := rows.Scan(
+			&entry.ID,
+			&entry.Type,
+			&entry.Account,
+			&entry.SellerID,
+			&sellerOrderID,
+			&payoutID,
+			&entry.Amount,
+			&entry.CreatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		if sellerOrderID.Valid {
+			entry.SellerOrderID = &sellerOrderID.String
+		}
+		if payoutID.Valid {
+			entry.PayoutID = &payoutID.String
+		}
+
+		entries = append(entries, entry)
+	}
+
+	return entries, rows.Err()
+}
+
+// RunPayouts creates a payout for every seller whose balance has reached
+// minAmount and zeroes that balance in the ledger. Concurrent runs are
+// serialised with an advisory lock so a balance is never paid twice.
+func (s *Store) RunPayouts(ctx context.Context, minAmount int) ([]Payout, error) {
+	var payouts []Payout
+
+	err := utils.WithTx(ctx, s.db, func(tx *sql.Tx) error {
+		if _, err := tx.ExecContext(ctx, `SELECT pg_advisory_xact_lock(hashtext('ledger_payout_run'))`); err != nil {
+			return err
+		}
+
+		rows, err := tx.QueryContext(ctx, `
+			SELECT seller_user_id, sum(amount) FROM ledger_entries
+			WHERE account = 'seller_payable'
+			GROUP BY seller_user_id
+			HAVING sum(amount) >= $1 AND sum(amount) > 0
+		`, minAmount)
+		if err != nil {
+			return err
+		}
+
+		for rows.Next() {
+			var payout Payout
+			if err := rows.Scan(&payout.SellerID, &payout.Amount); err != nil {
+				rows.Close()
+				return err
+			}
+			payouts = append(payouts, payout)
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return err
+		}
+
+		for i := range payouts {
+			payout := &payouts[i]
+			payout.ID = uuid.NewV4().String()
+
+			err := tx.QueryRowContext(ctx, `
+				INSERT INTO payouts (id, seller_user_id, amount)
+				VALUES ($1, $2, $3)
+				RETURNING status, created_at
+			`, payout.ID, payout.SellerID, payout.Amount).Scan(&payout.Status, &payout.CreatedAt)
+			if err != nil {
+				return err
+			}
+
+			err = post(ctx, tx, TypePayout, nil, &payout.ID,
+				line{account: AccountSellerPayable, sellerID: &payout.SellerID, amount: -payout.Amount},
+				line{account: AccountPayouts, amount: payout.Amount},
+			)
+			if err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return payouts, nil
+}
@@ -0,0 +1,90 @@
+package notifications
+
+import (
+	"context"
+	"database/sql"
+	"time"
+)
+
+// SendJob is the background job kind that sends a stored notification.
+const SendJob = "notification.send"
+
+type Kind string
+
+const (
+	KindWelcome           Kind = "welcome"
+	KindPasswordChanged   Kind = "password_changed"
+	KindOrderPlaced       Kind = "order_placed"
+	KindOrderPaid         Kind = "order_paid"
+	KindOrderCancelled    Kind = "order_cancelled"
+	KindShipmentShipped   Kind = "shipment_shipped"
+	KindShipmentDelivered Kind = "shipment_delivered"
+)
+
+type Status string
+
+const (
+	StatusQueued Status = "queued"
+	StatusSent   Status = "sent"
+	StatusFailed Status = "failed"
+)
+
+type Notification struct {
+	ID string `json:"id"`
+	// UserID is empty for emails to guests.
+	UserID    string     `json:"-"`
+	EventID   int64      `json:"-"`
+	Kind      Kind       `json:"kind"`
+	Recipient string     `json:"recipient"`
+	Subject   string     `json:"subject"`
+	TextBody  string     `json:"-"`
+	HTMLBody  string     `json:"-"`
+	Status    Status     `json:"status"`
+	LastError string     `json:"-"`
+	SentAt    *time.Time `json:"sent_at"`
+	CreatedAt string     `json:"created_at"`
+}
+
+// Preferences are a user's opt-outs. Account emails are always sent.
+type Preferences struct {
+	UserID          string `json:"-"`
+	OrderUpdates    bool   `json:"order_updates"`
+	ShippingUpdates bool   `json:"shipping_updates"`
+	Version         string `json:"-"`
+}
+
+// Allows reports whether the user wants emails of the given kind.
+func (p *Preferences) Allows(kind Kind) bool {
+	switch kind {
+	case KindOrderPlaced, KindOrderPaid, KindOrderCancelled:
+		return p.OrderUpdates
+	case KindShipmentShipped, KindShipmentDelivered:
+		return p.ShippingUpdates
+	default:
+		return true
+	}
+}
+
+type NotificationStore interface {
+	// CreateNotification stores the notification unless one of the same kind
+	// was already made for the event and recipient, reporting whether it
+	// did. The hook runs in the same transaction.
+	CreateNotification(context.Context, *Notification, func(*sql.Tx) error) (bool, error)
+	GetNotificationByID(context.Context, string) (*Notification, error)
+	GetNotificationsByUserID(ctx context.Context, userID string, limit int) ([]Notification, error)
+	MarkSent(context.Context, *Notification) error
+	MarkFailed(context.Context, *Notification, error) error
+	// GetPreferences returns the defaults for users who never saved any.
+	GetPreferences(context.Context, string) (*Preferences, error)
+	UpdatePreferences(context.Context, *Preferences) error
+}
+
+// SendPayload is the payload of SendJob.
+type SendPayload struct {
+	NotificationID string `json:"notification_id"`
+}
+
+type PreferencesPayload struct {
+	OrderUpdates    *bool `json:"order_updates"`
+	ShippingUpdates *bool `json:"shipping_updates"`
+}
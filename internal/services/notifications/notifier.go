@@ -0,0 +1,212 @@
+package notifications
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"strings"
+
+	"github.com/umeh-promise/ecommerce/internal/events"
+	"github.com/umeh-promise/ecommerce/internal/jobs"
+	"github.com/umeh-promise/ecommerce/internal/mailer"
+	"github.com/umeh-promise/ecommerce/internal/services/orders"
+	"github.com/umeh-promise/ecommerce/internal/services/shipments"
+	"github.com/umeh-promise/ecommerce/internal/services/user"
+	"github.com/umeh-promise/ecommerce/utils"
+)
+
+// EventTypes are the events Notifier.Handle sends email for.
+var EventTypes = []string{
+	events.UserRegistered,
+	events.UserPasswordChanged,
+	events.OrderPlaced,
+	events.OrderPaid,
+	events.OrderCancelled,
+	events.ShipmentCreated,
+	events.ShipmentUpdated,
+}
+
+// Notifier turns domain events into emails. Each email is rendered and
+// stored when the event is handled, then sent by a background job.
+type Notifier struct {
+	store         NotificationStore
+	userStore     user.UserStore
+	orderStore    orders.OrderStore
+	jobs          jobs.Enqueuer
+	publicBaseURL string
+}
+
+func NewNotifier(store NotificationStore, userStore user.UserStore, orderStore orders.OrderStore, jobs jobs.Enqueuer, publicBaseURL string) *Notifier {
+	return &Notifier{
+		store:         store,
+		userStore:     userStore,
+		orderStore:    orderStore,
+		jobs:          jobs,
+		publicBaseURL: strings.TrimRight(publicBaseURL, "/"),
+	}
+}
+
+// recipient is who an email goes to. userID is empty for guests, who have
+// no preferences and only receive emails about their own orders.
+type recipient struct {
+	userID string
+	email  string
+	name   string
+}
+
+func (n *Notifier) Handle(ctx context.Context, event events.Event) error {
+	switch event.Type {
+	case events.UserRegistered:
+		return n.notifyUser(ctx, event, KindWelcome)
+
+	case events.UserPasswordChanged:
+		return n.notifyUser(ctx, event, KindPasswordChanged)
+
+	case events.OrderPlaced:
+		return n.notifyOrder(ctx, event, KindOrderPlaced, event.AggregateID, nil)
+
+	case events.OrderPaid:
+		return n.notifyOrder(ctx, event, KindOrderPaid, event.AggregateID, nil)
+
+	case events.OrderCancelled:
+		return n.notifyOrder(ctx, event, KindOrderCancelled, event.AggregateID, nil)
+
+	case events.ShipmentCreated, events.ShipmentUpdated:
+		var shipment shipments.ShipmentEvent
+		if err := json.Unmarshal(event.Payload, &shipment); err != nil {
+			return err
+		}
+
+		switch {
+		case event.Type == events.ShipmentCreated:
+			return n.notifyOrder(ctx, event, KindShipmentShipped, shipment.OrderID, &shipment)
+		case shipment.Status == shipments.StatusDelivered:
+			return n.notifyOrder(ctx, event, KindShipmentDelivered, shipment.OrderID, &shipment)
+		}
+	}
+
+	return nil
+}
+
+func (n *Notifier) notifyUser(ctx context.Context, event events.Event, kind Kind) error {
+	account, err := n.userStore.GetUserByID(ctx, event.AggregateID)
+	if err != nil {
+		switch err {
+		case utils.ErrorNotFound:
+			return nil
+		default:
+			return err
+		}
+	}
+
+	to := recipient{userID: account.ID, email: account.Email, name: account.FirstName}
+
+	return n.notify(ctx, event, kind, to, &templateData{Name: to.name})
+}
+
+func (n *Notifier) notifyOrder(ctx context.Context, event events.Event, kind Kind, orderID string, shipment *shipments.ShipmentEvent) error {
+	order, err := n.orderStore.GetOrderByID(ctx, orderID)
+	if err != nil {
+		switch err {
+		case utils.ErrorNotFound:
+			return nil
+		default:
+			return err
+		}
+	}
+
+	data := &templateData{Order: order, Shipment: shipment}
+
+	var to recipient
+
+	switch {
+	case order.UserID != "":
+		account, err := n.userStore.GetUserByID(ctx, order.UserID)
+		if err != nil {
+			switch err {
+			case utils.ErrorNotFound:
+				return nil
+			default:
+				return err
+			}
+		}
+
+		to = recipient{userID: account.ID, email: account.Email, name: account.FirstName}
+		data.Name = to.name
+
+	case order.GuestEmail != nil:
+		to = recipient{email: *order.GuestEmail}
+		if order.LookupToken != nil {
+			data.LookupURL = n.publicBaseURL + "/v1/orders/lookup/" + *order.LookupToken
+		}
+
+	default:
+		return nil
+	}
+
+	return n.notify(ctx, event, kind, to, data)
+}
+
+func (n *Notifier) notify(ctx context.Context, event events.Event, kind Kind, to recipient, data *templateData) error {
+	if to.userID != "" {
+		preferences, err := n.store.GetPreferences(ctx, to.userID)
+		if err != nil {
+			return err
+		}
+
+		if !preferences.Allows(kind) {
+			return nil
+		}
+	}
+
+	notification := &Notification{
+		UserID:    to.userID,
+		EventID:   event.ID,
+		Kind:      kind,
+		Recipient: to.email,
+	}
+
+	if err := render(notification, data); err != nil {
+		return err
+	}
+
+	_, err := n.store.CreateNotification(ctx, notification, func(tx *sql.Tx) error {
+		return n.jobs.EnqueueTx(ctx, tx, SendJob, &SendPayload{NotificationID: notification.ID})
+	})
+	return err
+}
+
+// Send returns the SendJob handler. A failed send is recorded on the
+// notification and retried by the job queue.
+func Send(store NotificationStore, mail mailer.Mailer) func(context.Context, SendPayload) error {
+	return func(ctx context.Context, payload SendPayload) error {
+		notification, err := store.GetNotificationByID(ctx, payload.NotificationID)
+		if err != nil {
+			switch err {
+			case utils.ErrorNotFound:
+				return nil
+			default:
+				return err
+			}
+		}
+
+		if notification.Status == StatusSent {
+			return nil
+		}
+
+		err = mail.Send(ctx, mailer.Message{
+			To:      notification.Recipient,
+			Subject: notification.Subject,
+			Body:    notification.TextBody,
+			HTML:    notification.HTMLBody,
+		})
+		if err != nil {
+			if markErr := store.MarkFailed(ctx, notification, err); markErr != nil {
+				utils.Logger.Warnw("failed to record notification error", "notification", notification.ID, "error", markErr.Error())
+			}
+			return err
+		}
+
+		return store.MarkSent(context.WithoutCancel(ctx), notification)
+	}
+}
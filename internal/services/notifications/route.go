@@ -0,0 +1,97 @@
+package notifications
+
+import (
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/umeh-promise/ecommerce/internal/services/user"
+	"github.com/umeh-promise/ecommerce/utils"
+)
+
+const historyLimit = 50
+
+type Handler struct {
+	store NotificationStore
+}
+
+func NewHandler(store NotificationStore) *Handler {
+	return &Handler{store: store}
+}
+
+func (h *Handler) RegisterRoute(auth *user.Handler) func(r chi.Router) {
+	return func(r chi.Router) {
+		r.Route("/notifications", func(r chi.Router) {
+			r.Use(auth.AuthTokenMiddleware)
+			r.Get("/", h.getNotifications)
+			r.Get("/preferences", h.getPreferences)
+			r.Put("/preferences", h.updatePreferences)
+		})
+	}
+}
+
+// getNotifications lists the emails most recently sent to the caller.
+func (h *Handler) getNotifications(w http.ResponseWriter, r *http.Request) {
+	user := user.GetUserFromContext(r)
+
+	notifications, err := h.store.GetNotificationsByUserID(r.Context(), user.ID, historyLimit)
+	if err != nil {
+		utils.InternalServerError(w, r, err)
+		return
+	}
+
+	if err := utils.JSONResponse(w, http.StatusOK, notifications); err != nil {
+		utils.InternalServerError(w, r, err)
+		return
+	}
+}
+
+func (h *Handler) getPreferences(w http.ResponseWriter, r *http.Request) {
+	user := user.GetUserFromContext(r)
+
+	preferences, err := h.store.GetPreferences(r.Context(), user.ID)
+	if err != nil {
+		utils.InternalServerError(w, r, err)
+		return
+	}
+
+	if err := utils.JSONResponse(w, http.StatusOK, preferences); err != nil {
+		utils.InternalServerError(w, r, err)
+		return
+	}
+}
+
+func (h *Handler) updatePreferences(w http.ResponseWriter, r *http.Request) {
+	var payload PreferencesPayload
+
+	if err := utils.ParseJSON(w, r, &payload); err != nil {
+		utils.BadRequestError(w, r, err)
+		return
+	}
+
+	if err := utils.Validator.Struct(payload); err != nil {
+		utils.BadRequestError(w, r, err)
+		return
+	}
+
+	user := user.GetUserFromContext(r)
+	ctx := r.Context()
+
+	preferences, err := h.store.GetPreferences(ctx, user.ID)
+	if err != nil {
+		utils.InternalServerError(w, r, err)
+		return
+	}
+
+	utils.AssignIfNotNil(&preferences.OrderUpdates, payload.OrderUpdates)
+	utils.AssignIfNotNil(&preferences.ShippingUpdates, payload.ShippingUpdates)
+
+	if err := h.store.UpdatePreferences(ctx, preferences); err != nil {
+		utils.InternalServerError(w, r, err)
+		return
+	}
+
+	if err := utils.JSONResponse(w, http.StatusOK, preferences); err != nil {
+		utils.InternalServerError(w, r, err)
+		return
+	}
+}
@@ -0,0 +1,223 @@
+package notifications
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	uuid "github.com/satori/go.uuid"
+	"github.com/umeh-promise/ecommerce/utils"
+)
+
+const notificationColumns = `id, user_id, event_id, kind, recipient, subject, text_body, html_body, status,
+	last_error, sent_at, created_at`
+
+type scanner interface {
+	Scan(dest ...any) error
+}
+
+func scanNotification(row scanner, notification *Notification) error {
+	var (
+		userID sql.NullString
+		sentAt sql.NullTime
+	)
+
+	err := row.Scan(
+		&notification.ID,
+		&userID,
+		&notification.EventID,
+		&notification.Kind,
+		&notification.Recipient,
+		&notification.Subject,
+		&notification.TextBody,
+		&notification.HTMLBody,
+		&notification.Status,
+		&notification.LastError,
+		&sentAt,
+		&notification.CreatedAt,
+	)
+	if err != nil {
+		return err
+	}
+
+	notification.UserID = userID.String
+	notification.SentAt = nil
+	if sentAt.Valid {
+		notification.SentAt = &sentAt.Time
+	}
+
+	return nil
+}
+
+type Store struct {
+	db *sql.DB
+}
+
+func NewStore(db *sql.DB) *Store {
+	return &Store{db: db}
+}
+
+func (s *Store) CreateNotification(ctx context.Context, notification *Notification, hook func(*sql.Tx) error) (bool, error) {
+	query := `
+		INSERT INTO notifications (id, user_id, event_id, kind, recipient, subject, text_body, html_body)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		ON CONFLICT ON CONSTRAINT notifications_event_kind_recipient_key DO NOTHING
+		RETURNING status, created_at
+	`
+
+	notification.ID = uuid.NewV4().String()
+
+	var userID any
+	if notification.UserID != "" {
+		userID = notification.UserID
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, utils.QueryTimeout)
+	defer cancel()
+
+	created := false
+
+	err := utils.WithTx(ctx, s.db, func(tx *sql.Tx) error {
+		err := tx.QueryRowContext(ctx, query,
+			notification.ID, userID, notification.EventID, notification.Kind, notification.Recipient,
+			notification.Subject, notification.TextBody, notification.HTMLBody,
+		).Scan(&notification.Status, &notification.CreatedAt)
+		if err != nil {
+			switch {
+			case errors.Is(err, sql.ErrNoRows):
+				return nil
+			default:
+				return err
+			}
+		}
+
+		created = true
+
+		if hook != nil {
+			return hook(tx)
+		}
+
+		return nil
+	})
+
+	return created, err
+}
+
+func (s *Store) GetNotificationByID(ctx context.Context, id string) (*Notification, error) {
+	query := `SELECT ` + notificationColumns + ` FROM notifications WHERE id = $1`
+
+	ctx, cancel := context.WithTimeout(ctx, utils.QueryTimeout)
+	defer cancel()
+
+	var notification Notification
+
+	err := scanNotification(s.db.QueryRowContext(ctx, query, id), &notification)
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return nil, utils.ErrorNotFound
+		default:
+			return nil, err
+		}
+	}
+
+	return &notification, nil
+}
+
+func (s *Store) GetNotificationsByUserID(ctx context.Context, userID string, limit int) ([]Notification, error) {
+	query := `SELECT ` + notificationColumns + ` FROM notifications WHERE user_id = $1 ORDER BY created_at DESC LIMIT $2`
+
+	ctx, cancel := context.WithTimeout(ctx, utils.QueryTimeout)
+	defer cancel()
+
+	rows, err := s.db.QueryContext(ctx, query, userID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var notifications []Notification
+
+	for rows.Next() {
+		notification := Notification{}
+		if err := scanNotification(rows, &notification); err != nil {
+			return nil, err
+		}
+
+		notifications = append(notifications, notification)
+	}
+
+	return notifications, rows.Err()
+}
+
+func (s *Store) MarkSent(ctx context.Context, notification *Notification) error {
+	query := `
+		UPDATE notifications
+		SET status = $1, last_error = '', sent_at = now()
+		WHERE id = $2
+		RETURNING sent_at
+	`
+
+	ctx, cancel := context.WithTimeout(ctx, utils.QueryTimeout)
+	defer cancel()
+
+	notification.Status = StatusSent
+
+	return s.db.QueryRowContext(ctx, query, notification.Status, notification.ID).Scan(&notification.SentAt)
+}
+
+func (s *Store) MarkFailed(ctx context.Context, notification *Notification, sendErr error) error {
+	ctx, cancel := context.WithTimeout(ctx, utils.QueryTimeout)
+	defer cancel()
+
+	notification.Status = StatusFailed
+	notification.LastError = sendErr.Error()
+
+	_, err := s.db.ExecContext(ctx,
+		`UPDATE notifications SET status = $1, last_error = $2 WHERE id = $3`,
+		notification.Status, notification.LastError, notification.ID)
+	return err
+}
+
+func (s *Store) GetPreferences(ctx context.Context, userID string) (*Preferences, error) {
+	query := `SELECT order_updates, shipping_updates, version FROM notification_preferences WHERE user_id = $1`
+
+	ctx, cancel := context.WithTimeout(ctx, utils.QueryTimeout)
+	defer cancel()
+
+	preferences := Preferences{UserID: userID}
+
+	err := s.db.QueryRowContext(ctx, query, userID).Scan(
+		&preferences.OrderUpdates,
+		&preferences.ShippingUpdates,
+		&preferences.Version,
+	)
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return &Preferences{UserID: userID, OrderUpdates: true, ShippingUpdates: true, Version: "0"}, nil
+		default:
+			return nil, err
+		}
+	}
+
+	return &preferences, nil
+}
+
+func (s *Store) UpdatePreferences(ctx context.Context, preferences *Preferences) error {
+	query := `
+		INSERT INTO notification_preferences (user_id, order_updates, shipping_updates)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (user_id) DO UPDATE
+		SET order_updates = EXCLUDED.order_updates, shipping_updates = EXCLUDED.shipping_updates,
+			version = notification_preferences.version + 1, updated_at = now()
+		RETURNING version
+	`
+
+	ctx, cancel := context.WithTimeout(ctx, utils.QueryTimeout)
+	defer cancel()
+
+	return s.db.QueryRowContext(ctx, query,
+		preferences.UserID, preferences.OrderUpdates, preferences.ShippingUpdates,
+	).Scan(&preferences.Version)
+}
@@ -0,0 +1,90 @@
+package notifications
+
+import (
+	"bytes"
+	"embed"
+	"fmt"
+	htmltemplate "html/template"
+	"strings"
+	texttemplate "text/template"
+
+	"github.com/umeh-promise/ecommerce/internal/services/orders"
+	"github.com/umeh-promise/ecommerce/internal/services/shipments"
+)
+
+//go:embed templates
+var templateFS embed.FS
+
+// Each kind has a .txt file defining "subject" and "text", and a .html file
+// defining "content", which layout.html wraps.
+var (
+	textTemplates = map[Kind]*texttemplate.Template{}
+	htmlTemplates = map[Kind]*htmltemplate.Template{}
+)
+
+var templateFuncs = map[string]any{
+	"money": money,
+}
+
+func init() {
+	for _, kind := range []Kind{
+		KindWelcome, KindPasswordChanged, KindOrderPlaced, KindOrderPaid, KindOrderCancelled,
+		KindShipmentShipped, KindShipmentDelivered,
+	} {
+		textTemplates[kind] = texttemplate.Must(texttemplate.New("").Funcs(templateFuncs).
+			ParseFS(templateFS, "templates/"+string(kind)+".txt"))
+		htmlTemplates[kind] = htmltemplate.Must(htmltemplate.New("").Funcs(templateFuncs).
+			ParseFS(templateFS, "templates/layout.html", "templates/"+string(kind)+".html"))
+	}
+}
+
+// templateData is what templates can refer to. Order and Shipment are only
+// set for the kinds they apply to.
+type templateData struct {
+	Name    string
+	Subject string
+	Order   *orders.Order
+	// LookupURL links guests to their order, since they can't sign in.
+	LookupURL string
+	Shipment  *shipments.ShipmentEvent
+}
+
+// money formats an amount in minor units.
+func money(amount int) string {
+	sign := ""
+	if amount < 0 {
+		sign = "-"
+		amount = -amount
+	}
+
+	return fmt.Sprintf("%s%d.%02d", sign, amount/100, amount%100)
+}
+
+func render(notification *Notification, data *templateData) error {
+	text, ok := textTemplates[notification.Kind]
+	if !ok {
+		return fmt.Errorf("no template for %s", notification.Kind)
+	}
+
+	var buf bytes.Buffer
+
+	if err := text.ExecuteTemplate(&buf, "subject", data); err != nil {
+		return err
+	}
+	notification.Subject = strings.TrimSpace(buf.String())
+	data.Subject = notification.Subject
+
+	buf.Reset()
+	if err := text.ExecuteTemplate(&buf, "text", data); err != nil {
+		return err
+	}
+	notification.TextBody = buf.String()
+
+	buf.Reset()
+	if err := htmlTemplates[notification.Kind].ExecuteTemplate(&buf, "layout", data); err != nil {
+		return err
+	}
+	notification.HTMLBody = buf.String()
+
+	return nil
+}
@@ -0,0 +1,47 @@
+package orders
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/umeh-promise/ecommerce/internal/services/user"
+	"github.com/umeh-promise/ecommerce/utils"
+)
+
+type orderKey string
+
+var orderCtx orderKey = "order"
+
+// OrderMiddleware loads the order in the URL and hides it from anyone but
+// its owner and admins. It must be mounted after AuthTokenMiddleware.
+func (middleware *Handler) OrderMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		orderID := chi.URLParam(r, "id")
+		ctx := r.Context()
+
+		order, err := middleware.store.GetOrderByID(ctx, orderID)
+		if err != nil {
+			switch err {
+			case utils.ErrorNotFound:
+				utils.NotFoundResponse(w, r, err)
+			default:
+				utils.InternalServerError(w, r, err)
+			}
+			return
+		}
+
+		caller := user.GetUserFromContext(r)
+		if order.UserID != caller.ID && caller.Role != user.RoleAdmin {
+			utils.NotFoundResponse(w, r, utils.ErrorNotFound)
+			return
+		}
+
+		ctx = context.WithValue(ctx, orderCtx, order)
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+func GetOrderFromContext(r *http.Request) *Order {
+	return r.Context().Value(orderCtx).(*Order)
+}
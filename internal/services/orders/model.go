@@ -0,0 +1,283 @@
+package orders
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/umeh-promise/ecommerce/internal/events"
+	"github.com/umeh-promise/ecommerce/internal/services/addresses"
+	"github.com/umeh-promise/ecommerce/internal/services/tax"
+)
+
+type OrderStatus string
+
+const (
+	StatusPending   OrderStatus = "pending"
+	StatusPaid      OrderStatus = "paid"
+	StatusCancelled OrderStatus = "cancelled"
+)
+
+type Order struct {
+	ID string `json:"id"`
+	// UserID is empty for guest orders that have not been claimed yet.
+	UserID         string      `json:"user_id"`
+	GuestEmail     *string     `json:"guest_email"`
+	GuestToken     string      `json:"-"`
+	LookupToken    *string     `json:"lookup_token,omitempty"`
+	Status         OrderStatus `json:"status"`
+	Items          []OrderItem `json:"items"`
+	Subtotal       int         `json:"subtotal"`
+	DiscountTotal  int         `json:"discount_total"`
+	ShippingTotal  int         `json:"shipping_total"`
+	ShippingMethod *string     `json:"shipping_method"`
+	TaxTotal       int         `json:"tax_total"`
+	Total          int         `json:"total"`
+	RefundedTotal  int         `json:"refunded_total"`
+	CouponCode     *string     `json:"coupon_code"`
+	// ShippingAddress is a snapshot taken at checkout, not a reference.
+	ShippingAddress *addresses.Address `json:"shipping_address"`
+	SellerOrders    []SellerOrder      `json:"seller_orders"`
+	Version         string             `json:"-"`
+	CreatedAt       string             `json:"created_at"`
+	UpdatedAt       string             `json:"-"`
+}
+
+type OrderItem struct {
+	ID          string `json:"id"`
+	OrderID     string `json:"-"`
+	ProductID   string `json:"product_id"`
+	ProductName string `json:"product_name"`
+	UnitPrice   int    `json:"unit_price"`
+	Quantity    int    `json:"quantity"`
+	LineTotal   int    `json:"line_total"`
+	// DiscountAmount is the line's share of the order discount. It is nil
+	// for discounted orders placed before shares were stored.
+	DiscountAmount *int   `json:"discount_amount"`
+	TaxRateBps     int    `json:"tax_rate_bps"`
+	TaxAmount      int    `json:"tax_amount"`
+	TaxInclusive   bool   `json:"tax_inclusive"`
+	TaxClass       string `json:"-"`
+	SellerID       string `json:"-"`
+	// SellerOrderID is empty for orders placed before orders were split
+	// per seller.
+	SellerOrderID string `json:"-"`
+}
+
+// SellerOrder is the part of an order fulfilled by a single seller. The
+// order's coupon discount is shared between sellers in proportion to their
+// subtotals, and commission is taken from what remains.
+type SellerOrder struct {
+	ID                string      `json:"id"`
+	OrderID           string      `json:"-"`
+	SellerID          string      `json:"seller_id"`
+	Status            OrderStatus `json:"status"`
+	Subtotal          int         `json:"subtotal"`
+	DiscountShare     int         `json:"discount_share"`
+	CommissionRateBps int         `json:"-"`
+	Commission        int         `json:"-"`
+	NetAmount         int         `json:"-"`
+	CreatedAt         string      `json:"created_at"`
+}
+
+// Gross is what the customer paid for this seller's items.
+func (so *SellerOrder) Gross() int {
+	return so.Subtotal - so.DiscountShare
+}
+
+func (so *SellerOrder) applyCommission(rateBps int) {
+	so.CommissionRateBps = rateBps
+	so.Commission = so.Gross() * rateBps / 10000
+	so.NetAmount = so.Gross() - so.Commission
+}
+
+// allocate shares amount out in proportion to weights. Rounding leftovers go
+// to the last share so the shares always add up to amount.
+func allocate(amount int, weights []int) []int {
+	total := 0
+	for _, weight := range weights {
+		total += weight
+	}
+
+	shares := make([]int, len(weights))
+	remaining := amount
+
+	for i, weight := range weights {
+		share := remaining
+		if i < len(weights)-1 && total > 0 {
+			share = amount * weight / total
+		}
+		shares[i] = share
+		remaining -= share
+	}
+
+	return shares
+}
+
+// splitBySeller groups the order's items by seller, in the order sellers
+// first appear in the cart, sharing the discount between them.
+func splitBySeller(order *Order) []SellerOrder {
+	var sellerOrders []SellerOrder
+	index := map[string]int{}
+
+	for _, item := range order.Items {
+		i, ok := index[item.SellerID]
+		if !ok {
+			i = len(sellerOrders)
+			index[item.SellerID] = i
+			sellerOrders = append(sellerOrders, SellerOrder{SellerID: item.SellerID, Status: order.Status})
+		}
+		sellerOrders[i].Subtotal += item.LineTotal
+	}
+
+	subtotals := make([]int, len(sellerOrders))
+	for i := range sellerOrders {
+		subtotals[i] = sellerOrders[i].Subtotal
+	}
+
+	for i, share := range allocate(order.DiscountTotal, subtotals) {
+		sellerOrders[i].DiscountShare = share
+	}
+
+	return sellerOrders
+}
+
+// applyTax computes tax on each item after its share of the discount and
+// records it on the order. Only exclusive tax is added to the total by the
+// caller; inclusive tax is already part of the prices.
+func applyTax(ctx context.Context, calculator tax.Calculator, order *Order) (int, error) {
+	lineTotals := make([]int, len(order.Items))
+	for i, item := range order.Items {
+		lineTotals[i] = item.LineTotal
+	}
+
+	request := &tax.Request{
+		Country: order.ShippingAddress.Country,
+		Region:  order.ShippingAddress.Region,
+	}
+
+	for i, discount := range allocate(order.DiscountTotal, lineTotals) {
+		order.Items[i].DiscountAmount = &discount
+		request.Lines = append(request.Lines, tax.Line{
+			ProductID: order.Items[i].ProductID,
+			TaxClass:  order.Items[i].TaxClass,
+			Amount:    order.Items[i].LineTotal - discount,
+		})
+	}
+
+	result, err := calculator.Calculate(ctx, request)
+	if err != nil {
+		return 0, err
+	}
+
+	for i, line := range result.Lines {
+		order.Items[i].TaxRateBps = line.RateBps
+		order.Items[i].TaxAmount = line.Amount
+		order.Items[i].TaxInclusive = line.Inclusive
+	}
+	order.TaxTotal = result.Total
+
+	return result.Exclusive, nil
+}
+
+// PaidAmounts returns what the customer paid for each item: the line total
+// less its share of the discount, plus any tax added on top. Shares stored
+// at checkout are used as they are; older orders have theirs re-derived.
+func (o *Order) PaidAmounts() []int {
+	lineTotals := make([]int, len(o.Items))
+	stored := true
+	for i, item := range o.Items {
+		lineTotals[i] = item.LineTotal
+		stored = stored && item.DiscountAmount != nil
+	}
+
+	paid := allocate(o.DiscountTotal, lineTotals)
+	for i, item := range o.Items {
+		if stored {
+			paid[i] = *item.DiscountAmount
+		}
+		paid[i] = item.LineTotal - paid[i]
+		if !item.TaxInclusive {
+			paid[i] += item.TaxAmount
+		}
+	}
+
+	return paid
+}
+
+// OrderEvent is the payload of order events. It leaves out the address and
+// guest details so subscribers only see what they need to act on.
+type OrderEvent struct {
+	OrderID   string      `json:"order_id"`
+	UserID    string      `json:"user_id"`
+	Status    OrderStatus `json:"status"`
+	Total     int         `json:"total"`
+	SellerIDs []string    `json:"seller_ids"`
+}
+
+func newOrderEvent(order *Order) *OrderEvent {
+	event := &OrderEvent{
+		OrderID: order.ID,
+		UserID:  order.UserID,
+		Status:  order.Status,
+		Total:   order.Total,
+	}
+
+	for _, sellerOrder := range order.SellerOrders {
+		event.SellerIDs = append(event.SellerIDs, sellerOrder.SellerID)
+	}
+
+	return event
+}
+
+type OrderStore interface {
+	// CreateOrder inserts the order and its items in one transaction. The
+	// optional hook runs inside that transaction before it commits.
+	CreateOrder(context.Context, *Order, func(*sql.Tx) error) error
+	GetOrderByID(context.Context, string) (*Order, error)
+	GetOrderByLookupToken(context.Context, string) (*Order, error)
+	GetOrdersByUserID(context.Context, string) ([]Order, error)
+	// GetOrders lists orders of every customer for the back office.
+	GetOrders(context.Context, *OrderFilter) ([]Order, error)
+	// GetOrderEvents returns the order's stream events with IDs after
+	// afterID, oldest first.
+	GetOrderEvents(ctx context.Context, orderID string, afterID int64) ([]events.Event, error)
+	// GetStalePendingOrderIDs returns up to limit orders still awaiting
+	// payment that were placed before the given time, oldest first.
+	GetStalePendingOrderIDs(ctx context.Context, before time.Time, limit int) ([]string, error)
+	// UpdateOrderStatus moves the order and its seller orders to order.Status
+	// if the order is still at order.Version, running hook in the same
+	// transaction.
+	UpdateOrderStatus(context.Context, *Order, func(*sql.Tx) error) error
+}
+
+// OrderFilter narrows GetOrders.
+type OrderFilter struct {
+	Status string `validate:"omitempty,oneof=pending paid cancelled"`
+	UserID string `validate:"omitempty,uuid"`
+	Limit  int    `validate:"gte=1,lte=200"`
+	Offset int    `validate:"gte=0"`
+}
+
+type OrderItemPayload struct {
+	ProductID string `json:"product_id" validate:"required,uuid"`
+	Quantity  int    `json:"quantity" validate:"required,gt=0,lte=1000"`
+}
+
+type CheckoutPayload struct {
+	Items      []OrderItemPayload `json:"items" validate:"required,min=1,dive"`
+	CouponCode string             `json:"coupon_code" validate:"omitempty,max=50"`
+	// AddressID defaults to the user's default shipping address.
+	AddressID string `json:"address_id" validate:"omitempty,uuid"`
+	// ShippingOption is an option ID from a shipping quote; the cheapest
+	// option is used when it is empty.
+	ShippingOption string `json:"shipping_option" validate:"omitempty,max=100"`
+	// Email and Address are required when checking out as a guest, who has
+	// no saved addresses. Signed-in users may also send a one-off address.
+	Email   string                    `json:"email" validate:"omitempty,email,max=255"`
+	Address *addresses.AddressPayload `json:"address" validate:"omitempty"`
+}
+
+type OrderStatusPayload struct {
+	Status OrderStatus `json:"status" validate:"required,oneof=paid cancelled"`
+}
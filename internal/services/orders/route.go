@@ -0,0 +1,416 @@
+package orders
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/umeh-promise/ecommerce/internal/audit"
+	"github.com/umeh-promise/ecommerce/internal/pubsub"
+	"github.com/umeh-promise/ecommerce/internal/services/addresses"
+	"github.com/umeh-promise/ecommerce/internal/services/guest"
+	"github.com/umeh-promise/ecommerce/internal/services/ledger"
+	"github.com/umeh-promise/ecommerce/internal/services/products"
+	"github.com/umeh-promise/ecommerce/internal/services/promotions"
+	"github.com/umeh-promise/ecommerce/internal/services/shipping"
+	"github.com/umeh-promise/ecommerce/internal/services/tax"
+	"github.com/umeh-promise/ecommerce/internal/services/user"
+	"github.com/umeh-promise/ecommerce/utils"
+)
+
+type Handler struct {
+	store          OrderStore
+	productStore   products.ProductStore
+	promotionStore promotions.PromotionStore
+	ledgerStore    ledger.LedgerStore
+	addressStore   addresses.AddressStore
+	shipping       []shipping.RateProvider
+	tax            tax.Calculator
+	broker         *pubsub.Broker
+	audit          audit.AuditStore
+}
+
+func NewHandler(store OrderStore, productStore products.ProductStore, promotionStore promotions.PromotionStore, ledgerStore ledger.LedgerStore, addressStore addresses.AddressStore, shippingProviders []shipping.RateProvider, taxCalculator tax.Calculator, broker *pubsub.Broker, auditStore audit.AuditStore) *Handler {
+	return &Handler{
+		store:          store,
+		productStore:   productStore,
+		promotionStore: promotionStore,
+		ledgerStore:    ledgerStore,
+		addressStore:   addressStore,
+		shipping:       shippingProviders,
+		tax:            taxCalculator,
+		broker:         broker,
+		audit:          auditStore,
+	}
+}
+
+func (h *Handler) RegisterRoute(auth *user.Handler) func(r chi.Router) {
+	return func(r chi.Router) {
+		r.Route("/orders", func(r chi.Router) {
+			r.With(auth.OptionalAuthMiddleware, guest.Middleware).Post("/", h.createOrder)
+			r.Get("/lookup/{token}", h.lookupOrder)
+
+			r.Group(func(r chi.Router) {
+				r.Use(auth.AuthTokenMiddleware)
+				r.Get("/", h.getOrders)
+				r.Route("/{id}", func(r chi.Router) {
+					r.Use(h.OrderMiddleware)
+					r.Get("/", h.getOrder)
+					r.Get("/events", h.streamOrderEvents)
+					r.With(auth.AdminMiddleware).Put("/status", h.updateOrderStatus)
+				})
+			})
+		})
+	}
+}
+
+func (h *Handler) createOrder(w http.ResponseWriter, r *http.Request) {
+	var payload CheckoutPayload
+
+	if err := utils.ParseJSON(w, r, &payload); err != nil {
+		utils.BadRequestError(w, r, err)
+		return
+	}
+
+	if err := utils.Validator.Struct(payload); err != nil {
+		utils.BadRequestError(w, r, err)
+		return
+	}
+
+	ctx := r.Context()
+	order := &Order{}
+
+	caller, signedIn := user.UserFromContext(r)
+	if signedIn {
+		order.UserID = caller.ID
+	} else {
+		token := guest.TokenFromContext(r)
+		if token == "" {
+			utils.UnAuthorizedRequestError(w, r, fmt.Errorf("sign in or start a guest session to check out"))
+			return
+		}
+
+		if payload.Email == "" {
+			utils.BadRequestError(w, r, fmt.Errorf("email is required for guest checkout"))
+			return
+		}
+
+		// Coupon redemptions are counted per account, so guests can't use them.
+		if payload.CouponCode != "" {
+			utils.BadRequestError(w, r, fmt.Errorf("sign in to use a coupon"))
+			return
+		}
+
+		lookupToken, err := guest.NewToken()
+		if err != nil {
+			utils.InternalServerError(w, r, err)
+			return
+		}
+
+		email := strings.ToLower(payload.Email)
+		order.GuestEmail = &email
+		order.GuestToken = token
+		order.LookupToken = &lookupToken
+	}
+
+	var address *addresses.Address
+
+	if payload.Address != nil {
+		address = inlineAddress(payload.Address)
+		if err := address.Validate(); err != nil {
+			utils.BadRequestError(w, r, err)
+			return
+		}
+	} else {
+		saved, err := h.shippingAddress(r, payload.AddressID)
+		if err != nil {
+			switch err {
+			case utils.ErrorNotFound:
+				utils.BadRequestError(w, r, fmt.Errorf("a valid shipping address is required"))
+			default:
+				utils.InternalServerError(w, r, err)
+			}
+			return
+		}
+		address = saved
+	}
+
+	order.ShippingAddress = address
+	lines := make([]promotions.Line, 0, len(payload.Items))
+	parcel := &shipping.Parcel{
+		Destination: shipping.Destination{
+			Country:    address.Country,
+			Region:     address.Region,
+			PostalCode: address.PostalCode,
+		},
+	}
+
+	for _, item := range payload.Items {
+		product, err := h.productStore.GetPostByID(ctx, item.ProductID)
+		if err != nil {
+			switch err {
+			case utils.ErrorNotFound:
+				utils.BadRequestError(w, r, fmt.Errorf("product (%s) does not exist", item.ProductID))
+			default:
+				utils.InternalServerError(w, r, err)
+			}
+			return
+		}
+
+		if product.Status != products.StatusPublished {
+			utils.BadRequestError(w, r, fmt.Errorf("product (%s) is not available", item.ProductID))
+			return
+		}
+
+		lineTotal := product.EffectivePrice * item.Quantity
+
+		order.Items = append(order.Items, OrderItem{
+			ProductID:   product.ID,
+			ProductName: product.Name,
+			UnitPrice:   product.EffectivePrice,
+			Quantity:    item.Quantity,
+			LineTotal:   lineTotal,
+			TaxClass:    product.TaxClass,
+			SellerID:    product.UserID,
+		})
+		lines = append(lines, promotions.Line{
+			ProductID: product.ID,
+			Category:  product.Category,
+			Amount:    lineTotal,
+		})
+		parcel.Items = append(parcel.Items, shipping.NewItem(product, item.Quantity))
+		order.Subtotal += lineTotal
+	}
+
+	parcel.Subtotal = order.Subtotal
+
+	options, err := shipping.Quote(ctx, h.shipping, parcel)
+	if err != nil {
+		utils.InternalServerError(w, r, err)
+		return
+	}
+
+	option, err := shipping.Select(options, payload.ShippingOption)
+	if err != nil {
+		utils.BadRequestError(w, r, err)
+		return
+	}
+
+	order.ShippingMethod = &option.Name
+	order.ShippingTotal = option.Amount
+
+	var redeem func(*sql.Tx) error
+
+	if payload.CouponCode != "" {
+		result, err := promotions.Quote(ctx, h.promotionStore, payload.CouponCode, caller.ID, lines)
+		if err != nil {
+			switch {
+			case promotions.IsCouponError(err):
+				utils.BadRequestError(w, r, err)
+			default:
+				utils.InternalServerError(w, r, err)
+			}
+			return
+		}
+
+		order.CouponCode = &result.Code
+		order.DiscountTotal = result.Discount
+		if result.FreeShipping {
+			order.ShippingTotal = 0
+		}
+
+		redeem = func(tx *sql.Tx) error {
+			return h.promotionStore.Redeem(ctx, tx, result.Code, &promotions.Redemption{
+				UserID:         caller.ID,
+				OrderID:        order.ID,
+				DiscountAmount: result.Discount,
+			})
+		}
+	}
+
+	exclusiveTax, err := applyTax(ctx, h.tax, order)
+	if err != nil {
+		utils.InternalServerError(w, r, err)
+		return
+	}
+
+	order.Total = order.Subtotal - order.DiscountTotal + order.ShippingTotal + exclusiveTax
+
+	// The coupon is redeemed in the same transaction as the order.
+	if err := h.store.CreateOrder(ctx, order, redeem); err != nil {
+		switch {
+		case promotions.IsCouponError(err), errors.Is(err, utils.ErrorOutOfStock):
+			utils.BadRequestError(w, r, err)
+		default:
+			utils.InternalServerError(w, r, err)
+		}
+		return
+	}
+
+	// Guest orders have no actor; the order itself records the guest email.
+	audit.Record(h.audit, r, order.UserID, "order.create", "order", order.ID, nil, order)
+
+	if err := utils.JSONResponse(w, http.StatusCreated, order); err != nil {
+		utils.InternalServerError(w, r, err)
+		return
+	}
+}
+
+// Settlement is the UpdateOrderStatus hook that credits every seller in the
+// ledger when an order is marked paid. Other changes need no hook.
+func Settlement(ctx context.Context, ledgerStore ledger.LedgerStore, order *Order) func(*sql.Tx) error {
+	if order.Status != StatusPaid {
+		return nil
+	}
+
+	return func(tx *sql.Tx) error {
+		for _, sellerOrder := range order.SellerOrders {
+			err := ledgerStore.PostSale(ctx, tx, ledger.Sale{
+				SellerOrderID: sellerOrder.ID,
+				SellerID:      sellerOrder.SellerID,
+				Gross:         sellerOrder.Gross(),
+				Commission:    sellerOrder.Commission,
+			})
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+}
+
+// inlineAddress turns a one-off address sent with the checkout into the
+// snapshot stored on the order. It is never saved to an address book.
+func inlineAddress(payload *addresses.AddressPayload) *addresses.Address {
+	address := &addresses.Address{
+		FullName:    payload.FullName,
+		Line1:       payload.Line1,
+		Line2:       payload.Line2,
+		City:        payload.City,
+		Region:      payload.Region,
+		PostalCode:  payload.PostalCode,
+		Country:     payload.Country,
+		PhoneNumber: payload.PhoneNumber,
+	}
+	address.Normalize()
+
+	return address
+}
+
+// shippingAddress resolves a saved address for checkout, falling back to the
+// caller's default. Addresses belonging to other users, and any saved
+// address requested by a guest, are reported as missing.
+func (h *Handler) shippingAddress(r *http.Request, addressID string) (*addresses.Address, error) {
+	user, ok := user.UserFromContext(r)
+	if !ok {
+		return nil, utils.ErrorNotFound
+	}
+
+	if addressID == "" {
+		return h.addressStore.GetDefaultShippingAddress(r.Context(), user.ID)
+	}
+
+	address, err := h.addressStore.GetAddressByID(r.Context(), addressID)
+	if err != nil {
+		return nil, err
+	}
+
+	if address.UserID != user.ID {
+		return nil, utils.ErrorNotFound
+	}
+
+	return address, nil
+}
+
+func (h *Handler) getOrders(w http.ResponseWriter, r *http.Request) {
+	user := user.GetUserFromContext(r)
+
+	orders, err := h.store.GetOrdersByUserID(r.Context(), user.ID)
+	if err != nil {
+		utils.InternalServerError(w, r, err)
+		return
+	}
+
+	if err := utils.JSONResponse(w, http.StatusOK, orders); err != nil {
+		utils.InternalServerError(w, r, err)
+		return
+	}
+}
+
+// lookupOrder serves the link emailed to guests. The token itself grants
+// access, like a shared wishlist link.
+func (h *Handler) lookupOrder(w http.ResponseWriter, r *http.Request) {
+	order, err := h.store.GetOrderByLookupToken(r.Context(), chi.URLParam(r, "token"))
+	if err != nil {
+		switch err {
+		case utils.ErrorNotFound:
+			utils.NotFoundResponse(w, r, err)
+		default:
+			utils.InternalServerError(w, r, err)
+		}
+		return
+	}
+
+	if err := utils.JSONResponse(w, http.StatusOK, order); err != nil {
+		utils.InternalServerError(w, r, err)
+		return
+	}
+}
+
+func (h *Handler) getOrder(w http.ResponseWriter, r *http.Request) {
+	order := GetOrderFromContext(r)
+
+	if err := utils.JSONResponse(w, http.StatusOK, order); err != nil {
+		utils.InternalServerError(w, r, err)
+		return
+	}
+}
+
+// updateOrderStatus settles or cancels a pending order. Marking it paid
+// credits every seller in the ledger within the same transaction.
+func (h *Handler) updateOrderStatus(w http.ResponseWriter, r *http.Request) {
+	var payload OrderStatusPayload
+
+	if err := utils.ParseJSON(w, r, &payload); err != nil {
+		utils.BadRequestError(w, r, err)
+		return
+	}
+
+	if err := utils.Validator.Struct(payload); err != nil {
+		utils.BadRequestError(w, r, err)
+		return
+	}
+
+	order := GetOrderFromContext(r)
+	ctx := r.Context()
+
+	if order.Status != StatusPending {
+		utils.BadRequestError(w, r, fmt.Errorf("order is already %s", order.Status))
+		return
+	}
+
+	before := *order
+	before.SellerOrders = append([]SellerOrder(nil), order.SellerOrders...)
+	order.Status = payload.Status
+
+	if err := h.store.UpdateOrderStatus(ctx, order, Settlement(ctx, h.ledgerStore, order)); err != nil {
+		switch err {
+		case utils.ErrorNotFound:
+			utils.NotFoundResponse(w, r, err)
+		default:
+			utils.InternalServerError(w, r, err)
+		}
+		return
+	}
+
+	audit.Record(h.audit, r, user.GetUserFromContext(r).ID, "order.status", "order", order.ID, &before, order)
+
+	if err := utils.JSONResponse(w, http.StatusOK, order); err != nil {
+		utils.InternalServerError(w, r, err)
+		return
+	}
+}
@@ -0,0 +1,539 @@
+package orders
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/lib/pq"
+	uuid "github.com/satori/go.uuid"
+	"github.com/umeh-promise/ecommerce/internal/events"
+	"github.com/umeh-promise/ecommerce/utils"
+)
+
+const orderColumns = `id, user_id, guest_email, lookup_token, status, subtotal, discount_total, shipping_total, shipping_method, tax_total,
+	total, refunded_total, coupon_code, shipping_address, version, created_at, updated_at`
+
+type scanner interface {
+	Scan(dest ...any) error
+}
+
+func scanOrder(row scanner, order *Order) error {
+	var (
+		userID          sql.NullString
+		guestEmail      sql.NullString
+		lookupToken     sql.NullString
+		couponCode      sql.NullString
+		shippingMethod  sql.NullString
+		shippingAddress []byte
+	)
+
+	err := row.Scan(
+		&order.ID,
+		&userID,
+		&guestEmail,
+		&lookupToken,
+		&order.Status,
+		&order.Subtotal,
+		&order.DiscountTotal,
+		&order.ShippingTotal,
+		&shippingMethod,
+		&order.TaxTotal,
+		&order.Total,
+		&order.RefundedTotal,
+		&couponCode,
+		&shippingAddress,
+		&order.Version,
+		&order.CreatedAt,
+		&order.UpdatedAt,
+	)
+	if err != nil {
+		return err
+	}
+
+	order.UserID = userID.String
+
+	if guestEmail.Valid {
+		order.GuestEmail = &guestEmail.String
+	}
+
+	if lookupToken.Valid {
+		order.LookupToken = &lookupToken.String
+	}
+
+	if couponCode.Valid {
+		order.CouponCode = &couponCode.String
+	}
+
+	if shippingMethod.Valid {
+		order.ShippingMethod = &shippingMethod.String
+	}
+
+	if shippingAddress != nil {
+		if err := json.Unmarshal(shippingAddress, &order.ShippingAddress); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+type Store struct {
+	db *sql.DB
+}
+
+func NewStore(db *sql.DB) *Store {
+	return &Store{db: db}
+}
+
+func (s *Store) CreateOrder(ctx context.Context, order *Order, hook func(*sql.Tx) error) error {
+	query := `
+		INSERT INTO orders
+			(id, user_id, guest_email, guest_token, lookup_token, status, subtotal, discount_total, shipping_total,
+			shipping_method, tax_total, total, coupon_code, shipping_address)
+		VALUES
+			($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14)
+		RETURNING version, created_at, updated_at
+	`
+
+	var shippingAddress []byte
+	if order.ShippingAddress != nil {
+		var err error
+		if shippingAddress, err = json.Marshal(order.ShippingAddress); err != nil {
+			return err
+		}
+	}
+
+	var userID, guestToken sql.NullString
+	if order.UserID != "" {
+		userID = sql.NullString{String: order.UserID, Valid: true}
+	}
+	if order.GuestToken != "" {
+		guestToken = sql.NullString{String: order.GuestToken, Valid: true}
+	}
+
+	order.ID = uuid.NewV4().String()
+	if order.Status == "" {
+		order.Status = StatusPending
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, utils.QueryTimeout)
+	defer cancel()
+
+	return utils.WithTx(ctx, s.db, func(tx *sql.Tx) error {
+		err := tx.QueryRowContext(ctx, query,
+			order.ID, userID, order.GuestEmail, guestToken, order.LookupToken, order.Status,
+			order.Subtotal, order.DiscountTotal, order.ShippingTotal, order.ShippingMethod, order.TaxTotal, order.Total,
+			order.CouponCode, shippingAddress,
+		).Scan(&order.Version, &order.CreatedAt, &order.UpdatedAt)
+		if err != nil {
+			return err
+		}
+
+		order.SellerOrders = splitBySeller(order)
+		sellerOrderIDs := map[string]string{}
+
+		for i := range order.SellerOrders {
+			if err := insertSellerOrder(ctx, tx, order.ID, &order.SellerOrders[i]); err != nil {
+				return err
+			}
+			sellerOrderIDs[order.SellerOrders[i].SellerID] = order.SellerOrders[i].ID
+		}
+
+		for i := range order.Items {
+			item := &order.Items[i]
+			item.ID = uuid.NewV4().String()
+			item.OrderID = order.ID
+
+			_, err := tx.ExecContext(ctx, `
+				INSERT INTO order_items
+					(id, order_id, seller_order_id, product_id, product_name, unit_price, quantity, line_total,
+					discount_amount, tax_rate_bps, tax_amount, tax_inclusive)
+				VALUES
+					($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)
+			`, item.ID, item.OrderID, sellerOrderIDs[item.SellerID], item.ProductID, item.ProductName,
+				item.UnitPrice, item.Quantity, item.LineTotal, item.DiscountAmount, item.TaxRateBps, item.TaxAmount,
+				item.TaxInclusive)
+			if err != nil {
+				return err
+			}
+
+			if err := reserveStock(ctx, tx, item.ProductID, item.Quantity); err != nil {
+				return err
+			}
+		}
+
+		if err := events.Publish(ctx, tx, events.OrderPlaced, order.ID, newOrderEvent(order)); err != nil {
+			return err
+		}
+
+		if hook != nil {
+			return hook(tx)
+		}
+
+		return nil
+	})
+}
+
+// reserveStock takes the ordered quantity out of a product's stock. Products
+// without tracked stock are always available.
+func reserveStock(ctx context.Context, tx *sql.Tx, productID string, quantity int) error {
+	_, err := tx.ExecContext(ctx, `
+		UPDATE products
+		SET stock = stock - $1, version = version + 1, updated_at = now()
+		WHERE id = $2 AND stock IS NOT NULL
+	`, quantity, productID)
+	if err != nil {
+		switch {
+		case err.Error() == `pq: new row for relation "products" violates check constraint "products_stock_check"`:
+			return utils.ErrorOutOfStock
+		default:
+			return err
+		}
+	}
+
+	return nil
+}
+
+// insertSellerOrder fixes the seller's commission rate at checkout time so
+// later changes to the rate do not alter what was agreed for this sale.
+func insertSellerOrder(ctx context.Context, tx *sql.Tx, orderID string, sellerOrder *SellerOrder) error {
+	var rateBps sql.NullInt64
+
+	err := tx.QueryRowContext(ctx,
+		`SELECT commission_rate_bps FROM sellers WHERE user_id = $1`, sellerOrder.SellerID,
+	).Scan(&rateBps)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return err
+	}
+
+	if rateBps.Valid {
+		sellerOrder.applyCommission(int(rateBps.Int64))
+	} else {
+		sellerOrder.applyCommission(utils.DefaultCommissionRateBps)
+	}
+
+	sellerOrder.ID = uuid.NewV4().String()
+	sellerOrder.OrderID = orderID
+
+	return tx.QueryRowContext(ctx, `
+		INSERT INTO seller_orders
+			(id, order_id, seller_user_id, status, subtotal, discount_share, commission_rate_bps, commission, net_amount)
+		VALUES
+			($1, $2, $3, $4, $5, $6, $7, $8, $9)
+		RETURNING created_at
+	`, sellerOrder.ID, sellerOrder.OrderID, sellerOrder.SellerID, sellerOrder.Status,
+		sellerOrder.Subtotal, sellerOrder.DiscountShare, sellerOrder.CommissionRateBps,
+		sellerOrder.Commission, sellerOrder.NetAmount,
+	).Scan(&sellerOrder.CreatedAt)
+}
+
+func (s *Store) GetOrderByID(ctx context.Context, id string) (*Order, error) {
+	return s.getOrder(ctx, `id = $1`, id)
+}
+
+// GetOrderByLookupToken finds the order behind a lookup link emailed to a
+// guest.
+func (s *Store) GetOrderByLookupToken(ctx context.Context, token string) (*Order, error) {
+	return s.getOrder(ctx, `lookup_token = $1`, token)
+}
+
+func (s *Store) getOrder(ctx context.Context, where string, arg any) (*Order, error) {
+	var order Order
+
+	query := `SELECT ` + orderColumns + ` FROM orders WHERE ` + where
+
+	ctx, cancel := context.WithTimeout(ctx, utils.QueryTimeout)
+	defer cancel()
+
+	err := scanOrder(s.db.QueryRowContext(ctx, query, arg), &order)
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return nil, utils.ErrorNotFound
+		default:
+			return nil, err
+		}
+	}
+
+	items, err := s.getOrderItems(ctx, order.ID)
+	if err != nil {
+		return nil, err
+	}
+	order.Items = items
+
+	sellerOrders, err := s.getSellerOrders(ctx, order.ID)
+	if err != nil {
+		return nil, err
+	}
+	order.SellerOrders = sellerOrders
+
+	return &order, nil
+}
+
+func (s *Store) GetOrdersByUserID(ctx context.Context, userID string) ([]Order, error) {
+	query := `SELECT ` + orderColumns + ` FROM orders WHERE user_id = $1 ORDER BY created_at DESC`
+
+	ctx, cancel := context.WithTimeout(ctx, utils.QueryTimeout)
+	defer cancel()
+
+	rows, err := s.db.QueryContext(ctx, query, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var orders []Order
+
+	for rows.Next() {
+		order := Order{}
+		if err := scanOrder(rows, &order); err != nil {
+			return nil, err
+		}
+
+		orders = append(orders, order)
+	}
+
+	return orders, rows.Err()
+}
+
+func (s *Store) GetOrders(ctx context.Context, filter *OrderFilter) ([]Order, error) {
+	query := `SELECT ` + orderColumns + ` FROM orders
+		WHERE ($1 = '' OR status = $1) AND ($2 = '' OR user_id::text = $2)
+		ORDER BY created_at DESC, id
+		LIMIT $3 OFFSET $4`
+
+	ctx, cancel := context.WithTimeout(ctx, utils.QueryTimeout)
+	defer cancel()
+
+	rows, err := s.db.QueryContext(ctx, query, filter.Status, filter.UserID, filter.Limit, filter.Offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var orders []Order
+
+	for rows.Next() {
+		order := Order{}
+		if err := scanOrder(rows, &order); err != nil {
+			return nil, err
+		}
+
+		orders = append(orders, order)
+	}
+
+	return orders, rows.Err()
+}
+
+func (s *Store) GetOrderEvents(ctx context.Context, orderID string, afterID int64) ([]events.Event, error) {
+	query := `
+		SELECT id, event_type, aggregate_id, payload, created_at
+		FROM outbox
+		WHERE payload->>'order_id' = $1 AND id > $2 AND event_type = ANY($3)
+		ORDER BY id
+		LIMIT $4
+	`
+
+	ctx, cancel := context.WithTimeout(ctx, utils.QueryTimeout)
+	defer cancel()
+
+	rows, err := s.db.QueryContext(ctx, query, orderID, afterID, pq.Array(StreamEventTypes), eventPageSize)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var orderEvents []events.Event
+
+	for rows.Next() {
+		event := events.Event{}
+		err := rows.Scan(&event.ID, &event.Type, &event.AggregateID, &event.Payload, &event.CreatedAt)
+		if err != nil {
+			return nil, err
+		}
+
+		orderEvents = append(orderEvents, event)
+	}
+
+	return orderEvents, rows.Err()
+}
+
+func (s *Store) GetStalePendingOrderIDs(ctx context.Context, before time.Time, limit int) ([]string, error) {
+	query := `SELECT id FROM orders WHERE status = $1 AND created_at < $2 ORDER BY created_at LIMIT $3`
+
+	ctx, cancel := context.WithTimeout(ctx, utils.QueryTimeout)
+	defer cancel()
+
+	rows, err := s.db.QueryContext(ctx, query, StatusPending, before, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var ids []string
+
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+
+		ids = append(ids, id)
+	}
+
+	return ids, rows.Err()
+}
+
+func (s *Store) UpdateOrderStatus(ctx context.Context, order *Order, hook func(*sql.Tx) error) error {
+	query := `
+		UPDATE orders
+		SET status = $1, version = version + 1, updated_at = now()
+		WHERE id = $2 AND version = $3
+		RETURNING version, updated_at
+	`
+
+	ctx, cancel := context.WithTimeout(ctx, utils.QueryTimeout)
+	defer cancel()
+
+	return utils.WithTx(ctx, s.db, func(tx *sql.Tx) error {
+		err := tx.QueryRowContext(ctx, query, order.Status, order.ID, order.Version).
+			Scan(&order.Version, &order.UpdatedAt)
+		if err != nil {
+			switch {
+			case errors.Is(err, sql.ErrNoRows):
+				return utils.ErrorNotFound
+			default:
+				return err
+			}
+		}
+
+		_, err = tx.ExecContext(ctx,
+			`UPDATE seller_orders SET status = $1, updated_at = now() WHERE order_id = $2`,
+			order.Status, order.ID,
+		)
+		if err != nil {
+			return err
+		}
+
+		for i := range order.SellerOrders {
+			order.SellerOrders[i].Status = order.Status
+		}
+
+		// A cancelled order never ships, so its items go back on sale.
+		if order.Status == StatusCancelled {
+			_, err = tx.ExecContext(ctx, `
+				UPDATE products p
+				SET stock = p.stock + oi.quantity, version = p.version + 1, updated_at = now()
+				FROM (
+					SELECT product_id, sum(quantity) AS quantity FROM order_items
+					WHERE order_id = $1 GROUP BY product_id
+				) oi
+				WHERE p.id = oi.product_id AND p.stock IS NOT NULL
+			`, order.ID)
+			if err != nil {
+				return err
+			}
+		}
+
+		eventType := events.OrderPaid
+		if order.Status == StatusCancelled {
+			eventType = events.OrderCancelled
+		}
+		if err := events.Publish(ctx, tx, eventType, order.ID, newOrderEvent(order)); err != nil {
+			return err
+		}
+
+		if hook != nil {
+			return hook(tx)
+		}
+
+		return nil
+	})
+}
+
+func (s *Store) getSellerOrders(ctx context.Context, orderID string) ([]SellerOrder, error) {
+	query := `
+		SELECT id, order_id, seller_user_id, status, subtotal, discount_share,
+			commission_rate_bps, commission, net_amount, created_at
+		FROM seller_orders
+		WHERE order_id = $1
+		ORDER BY created_at, id
+	`
+
+	rows, err := s.db.QueryContext(ctx, query, orderID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var sellerOrders []SellerOrder
+
+	for rows.Next() {
+		sellerOrder := SellerOrder{}
+		err := rows.Scan(
+			&sellerOrder.ID,
+			&sellerOrder.OrderID,
+			&sellerOrder.SellerID,
+			&sellerOrder.Status,
+			&sellerOrder.Subtotal,
+			&sellerOrder.DiscountShare,
+			&sellerOrder.CommissionRateBps,
+			&sellerOrder.Commission,
+			&sellerOrder.NetAmount,
+			&sellerOrder.CreatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		sellerOrders = append(sellerOrders, sellerOrder)
+	}
+
+	return sellerOrders, rows.Err()
+}
+
+func (s *Store) getOrderItems(ctx context.Context, orderID string) ([]OrderItem, error) {
+	query := `
+		SELECT id, order_id, COALESCE(seller_order_id::text, ''), product_id, product_name, unit_price, quantity, line_total,
+			discount_amount, tax_rate_bps, tax_amount, tax_inclusive
+		FROM order_items
+		WHERE order_id = $1
+		ORDER BY id
+	`
+
+	rows, err := s.db.QueryContext(ctx, query, orderID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var items []OrderItem
+
+	for rows.Next() {
+		item := OrderItem{}
+		err := rows.Scan(
+			&item.ID,
+			&item.OrderID,
+			&item.SellerOrderID,
+			&item.ProductID,
+			&item.ProductName,
+			&item.UnitPrice,
+			&item.Quantity,
+			&item.LineTotal,
+			&item.DiscountAmount,
+			&item.TaxRateBps,
+			&item.TaxAmount,
+			&item.TaxInclusive,
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		items = append(items, item)
+	}
+
+	return items, rows.Err()
+}
@@ -0,0 +1,152 @@
+package orders
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/umeh-promise/ecommerce/internal/events"
+	"github.com/umeh-promise/ecommerce/internal/pubsub"
+	"github.com/umeh-promise/ecommerce/utils"
+)
+
+const (
+	heartbeatInterval = 15 * time.Second
+	// streamWriteTimeout replaces the server's WriteTimeout for each write
+	// to a stream, so a client that stops reading is noticed.
+	streamWriteTimeout = 10 * time.Second
+	streamRetry        = 3 * time.Second
+	// eventPageSize is how many events are read from the outbox at a time.
+	eventPageSize = 100
+)
+
+// StreamEventTypes are the events sent to order streams. Their payloads all
+// carry the order_id.
+var StreamEventTypes = []string{
+	events.OrderPlaced,
+	events.OrderPaid,
+	events.OrderCancelled,
+	events.ShipmentCreated,
+	events.ShipmentUpdated,
+}
+
+func streamTopic(orderID string) string {
+	return "order:" + orderID
+}
+
+// NotifyStreams returns the event subscriber that wakes up streams
+// following the event's order, on every replica.
+func NotifyStreams(broker *pubsub.Broker) events.Handler {
+	return func(ctx context.Context, event events.Event) error {
+		var ref struct {
+			OrderID string `json:"order_id"`
+		}
+		if err := json.Unmarshal(event.Payload, &ref); err != nil || ref.OrderID == "" {
+			return err
+		}
+
+		return broker.Publish(ctx, streamTopic(ref.OrderID), event.ID)
+	}
+}
+
+// streamOrderEvents sends the order's status changes as Server-Sent Events.
+// Event IDs are outbox IDs: a client reconnecting with Last-Event-ID gets
+// what it missed, and a new client gets the order's history first.
+// Notifications only wake the stream; events are always read from the
+// outbox, so they arrive in order and none are skipped.
+func (h *Handler) streamOrderEvents(w http.ResponseWriter, r *http.Request) {
+	if !utils.IsEventStream(r) {
+		utils.BadRequestError(w, r, fmt.Errorf("this endpoint needs an Accept: text/event-stream header"))
+		return
+	}
+
+	var lastID int64
+
+	if header := r.Header.Get("Last-Event-ID"); header != "" {
+		id, err := strconv.ParseInt(header, 10, 64)
+		if err != nil || id < 0 {
+			utils.BadRequestError(w, r, fmt.Errorf("invalid Last-Event-ID"))
+			return
+		}
+		lastID = id
+	}
+
+	order := GetOrderFromContext(r)
+	ctx := r.Context()
+
+	// Subscribe before reading the history so nothing published in between
+	// is missed.
+	messages, unsubscribe := h.broker.Subscribe(streamTopic(order.ID))
+	defer unsubscribe()
+
+	controller := http.NewResponseController(w)
+
+	write := func(format string, args ...any) error {
+		if err := controller.SetWriteDeadline(time.Now().Add(streamWriteTimeout)); err != nil {
+			return err
+		}
+		if _, err := fmt.Fprintf(w, format, args...); err != nil {
+			return err
+		}
+		return controller.Flush()
+	}
+
+	catchUp := func() error {
+		for {
+			orderEvents, err := h.store.GetOrderEvents(ctx, order.ID, lastID)
+			if err != nil {
+				return err
+			}
+
+			for _, event := range orderEvents {
+				if err := write("id: %d\nevent: %s\ndata: %s\n\n", event.ID, event.Type, event.Payload); err != nil {
+					return err
+				}
+				lastID = event.ID
+			}
+
+			if len(orderEvents) < eventPageSize {
+				return nil
+			}
+		}
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+
+	err := write("retry: %d\n\n", streamRetry.Milliseconds())
+	if err == nil {
+		err = catchUp()
+	}
+
+	heartbeat := time.NewTicker(heartbeatInterval)
+	defer heartbeat.Stop()
+
+	for err == nil {
+		select {
+		case <-ctx.Done():
+			return
+
+		case _, ok := <-messages:
+			// The broker closes the channel on shutdown or when we fall
+			// behind; the client reconnects with Last-Event-ID.
+			if !ok {
+				return
+			}
+			err = catchUp()
+
+		case <-heartbeat.C:
+			err = write(": ping\n\n")
+		}
+	}
+
+	if ctx.Err() == nil {
+		utils.Logger.Infow("order stream closed", "order", order.ID, "error", err.Error())
+	}
+}
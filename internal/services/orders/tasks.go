@@ -0,0 +1,50 @@
+package orders
+
+import (
+	"context"
+	"time"
+
+	"github.com/umeh-promise/ecommerce/utils"
+)
+
+// expiryBatchSize caps how many orders one run cancels, so a backlog is
+// worked off over several runs instead of in one long one.
+const expiryBatchSize = 200
+
+// ExpireUnpaidOrders returns a scheduled task that cancels orders left
+// unpaid for longer than ttl. Cancelling puts their stock back on sale and
+// publishes OrderCancelled, exactly as an admin cancelling them would.
+func ExpireUnpaidOrders(store OrderStore, ttl time.Duration) func(context.Context) error {
+	return func(ctx context.Context) error {
+		ids, err := store.GetStalePendingOrderIDs(ctx, time.Now().Add(-ttl), expiryBatchSize)
+		if err != nil {
+			return err
+		}
+
+		for _, id := range ids {
+			order, err := store.GetOrderByID(ctx, id)
+			if err != nil {
+				return err
+			}
+
+			if order.Status != StatusPending {
+				continue
+			}
+
+			order.Status = StatusCancelled
+
+			// A conflict means the order was paid or cancelled while we
+			// looked at it, which is fine.
+			err = store.UpdateOrderStatus(ctx, order, nil)
+			if err != nil && err != utils.ErrorNotFound {
+				return err
+			}
+		}
+
+		if len(ids) > 0 {
+			utils.Logger.Infow("expired unpaid orders", "count", len(ids))
+		}
+
+		return nil
+	}
+}
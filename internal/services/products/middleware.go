@@ -5,6 +5,7 @@ import (
 	"net/http"
 
 	"github.com/go-chi/chi/v5"
+	"github.com/umeh-promise/ecommerce/internal/services/user"
 	"github.com/umeh-promise/ecommerce/utils"
 )
 
@@ -12,6 +13,8 @@ type productKey string
 
 var productCtx productKey = "product"
 
+// ProductMiddleware should be mounted after OptionalAuthMiddleware so that
+// sellers can reach their own unpublished products.
 func (middleware *Handler) ProductMiddleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		productID := chi.URLParam(r, "id")
@@ -28,12 +31,34 @@ func (middleware *Handler) ProductMiddleware(next http.Handler) http.Handler {
 			return
 		}
 
+		// Drafts and archived products are only visible to their seller.
+		if product.Status != StatusPublished {
+			viewer, ok := user.UserFromContext(r)
+			if !ok || viewer.ID != product.UserID {
+				utils.NotFoundResponse(w, r, utils.ErrorNotFound)
+				return
+			}
+		}
+
 		ctx = context.WithValue(ctx, productCtx, product)
 		next.ServeHTTP(w, r.WithContext(ctx))
 	})
 
 }
 
+// ProductOwnerMiddleware must be mounted after ProductMiddleware and
+// AuthTokenMiddleware.
+func (middleware *Handler) ProductOwnerMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if GetProductFromMiddleware(r).UserID != user.GetUserFromContext(r).ID {
+			utils.ForbiddenServerError(w, r)
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
 func GetProductFromMiddleware(r *http.Request) *Product {
 	return r.Context().Value(productCtx).(*Product)
 }
@@ -1,32 +1,202 @@
 package products
 
-import "context"
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/umeh-promise/ecommerce/utils"
+)
+
+type DiscountType string
+
+const (
+	DiscountPercentage DiscountType = "percentage"
+	DiscountFixed      DiscountType = "fixed"
+)
+
+type Discount struct {
+	Type     DiscountType `json:"type"`
+	Value    int          `json:"value"`
+	StartsAt *time.Time   `json:"starts_at"`
+	EndsAt   *time.Time   `json:"ends_at"`
+}
+
+// IsActive reports whether the discount applies at the given time.
+func (d *Discount) IsActive(at time.Time) bool {
+	if d == nil {
+		return false
+	}
+	if d.StartsAt != nil && d.StartsAt.After(at) {
+		return false
+	}
+	if d.EndsAt != nil && !d.EndsAt.After(at) {
+		return false
+	}
+	return true
+}
+
+// Apply returns the price after the discount, never going below zero.
+func (d *Discount) Apply(price int) int {
+	switch d.Type {
+	case DiscountPercentage:
+		return price - price*d.Value/100
+	case DiscountFixed:
+		return max(price-d.Value, 0)
+	default:
+		return price
+	}
+}
+
+// Validate checks the discount against the price it will be applied to.
+func (d *Discount) Validate(price int) error {
+	switch d.Type {
+	case DiscountPercentage:
+		if d.Value > 100 {
+			return fmt.Errorf("percentage discount cannot exceed 100")
+		}
+	case DiscountFixed:
+		if d.Value > price {
+			return fmt.Errorf("discount (%d) cannot exceed the price (%d)", d.Value, price)
+		}
+	default:
+		return fmt.Errorf("unknown discount type %q", d.Type)
+	}
+
+	if d.StartsAt != nil && d.EndsAt != nil && !d.EndsAt.After(*d.StartsAt) {
+		return fmt.Errorf("discount must end after it starts")
+	}
+
+	return nil
+}
+
+type ProductStatus string
+
+const (
+	StatusDraft     ProductStatus = "draft"
+	StatusPublished ProductStatus = "published"
+	StatusArchived  ProductStatus = "archived"
+	// StatusHidden is set by admins taking a product down. Only an admin
+	// can move it out again.
+	StatusHidden ProductStatus = "hidden"
+)
 
 type Product struct {
-	ID          string `json:"id"`
-	UserID      string `json:"user_id"`
-	Discount    string `json:"discount"`
-	Name        string `json:"name"`
-	Price       string `json:"price"`
-	Description string `json:"discription"`
-	Image       string `json:"image"`
-	Version     string `json:"-"`
-	CreatedAt   string `json:"-"`
-	UpdatedAt   string `json:"-"`
+	ID             string         `json:"id"`
+	UserID         string         `json:"-"`
+	Seller         *SellerSummary `json:"seller"`
+	Status         ProductStatus  `json:"status"`
+	Discount       *Discount      `json:"discount"`
+	Name           string         `json:"name"`
+	Price          int            `json:"price"`
+	EffectivePrice int            `json:"effective_price"`
+	Description    string         `json:"discription"`
+	Category       string         `json:"category"`
+	Image          string         `json:"image"`
+	Images         []ProductImage `json:"images,omitempty"`
+	WeightGrams    int            `json:"weight_grams"`
+	LengthMm       int            `json:"length_mm"`
+	WidthMm        int            `json:"width_mm"`
+	HeightMm       int            `json:"height_mm"`
+	TaxClass       string         `json:"tax_class"`
+	// Stock is nil for products whose inventory isn't tracked.
+	Stock         *int    `json:"stock"`
+	RatingAverage float64 `json:"rating_average"`
+	RatingCount   int     `json:"rating_count"`
+	Version       string  `json:"-"`
+	CreatedAt     string  `json:"-"`
+	UpdatedAt     string  `json:"-"`
+}
+
+// ETag changes whenever the product is edited and when its price or rating
+// moves without an edit, as happens when a discount starts or a review is
+// approved.
+func (p *Product) ETag() string {
+	return utils.ETag(p.Version, strconv.Itoa(p.EffectivePrice), strconv.Itoa(p.RatingCount),
+		strconv.FormatFloat(p.RatingAverage, 'f', 2, 64))
+}
+
+// SellerSummary is the compact storefront shown alongside a product.
+type SellerSummary struct {
+	DisplayName string `json:"display_name"`
+	Slug        string `json:"slug"`
+	Logo        string `json:"logo"`
+}
+
+type ProductImage struct {
+	ID           string `json:"id"`
+	ProductID    string `json:"-"`
+	URL          string `json:"url"`
+	ThumbnailURL string `json:"thumbnail_url"`
+	BlobKey      string `json:"-"`
+	ThumbnailKey string `json:"-"`
+	AltText      string `json:"alt_text"`
+	Position     int    `json:"position"`
+	CreatedAt    string `json:"-"`
 }
 
 type ProductStore interface {
 	CreateProduct(context.Context, *Product) error
 	GetAllProduct(context.Context) ([]Product, error)
+	GetProductsByUserID(context.Context, string) ([]Product, error)
+	GetPublishedProductsByUserID(context.Context, string) ([]Product, error)
+	// GetProducts lists products of any status for the back office.
+	GetProducts(context.Context, *ProductFilter) ([]Product, error)
 	UpdateProduct(context.Context, *Product) error
 	DeleteProduct(context.Context, string) error
 	GetPostByID(context.Context, string) (*Product, error)
+	AddProductImage(context.Context, *ProductImage) error
+	GetProductImages(context.Context, string) ([]ProductImage, error)
+	ReorderProductImages(context.Context, string, []string) error
+	DeleteProductImage(context.Context, string, string) (*ProductImage, error)
+}
+
+// ProductFilter narrows GetProducts. Query matches anywhere in the name.
+type ProductFilter struct {
+	Query  string `validate:"max=100"`
+	Status string `validate:"omitempty,oneof=draft published archived hidden"`
+	UserID string `validate:"omitempty,uuid"`
+	Limit  int    `validate:"gte=1,lte=200"`
+	Offset int    `validate:"gte=0"`
+}
+
+type DiscountPayload struct {
+	Type     DiscountType `json:"type" validate:"required,oneof=percentage fixed"`
+	Value    int          `json:"value" validate:"required,gt=0"`
+	StartsAt *time.Time   `json:"starts_at"`
+	EndsAt   *time.Time   `json:"ends_at"`
+}
+
+func (p *DiscountPayload) toDiscount() *Discount {
+	if p == nil {
+		return nil
+	}
+
+	return &Discount{
+		Type:     p.Type,
+		Value:    p.Value,
+		StartsAt: p.StartsAt,
+		EndsAt:   p.EndsAt,
+	}
 }
 
 type ProductPayload struct {
-	Name        string `json:"name" validate:"required,min=2,max=100"`
-	Description string `json:"description" validate:"required,min=2"`
-	Image       string `json:"image" validate:"required"`
-	Price       string `json:"price" validate:"required"`
-	Discount    string `json:"discount"`
+	Name        string           `json:"name" validate:"required,min=2,max=100"`
+	Description string           `json:"description" validate:"required,min=2"`
+	Image       string           `json:"image" validate:"omitempty,max=255"`
+	Category    string           `json:"category" validate:"omitempty,max=100"`
+	Price       int              `json:"price" validate:"required,gt=0"`
+	Discount    *DiscountPayload `json:"discount" validate:"omitempty"`
+	Status      ProductStatus    `json:"status" validate:"omitempty,oneof=draft published"`
+	WeightGrams int              `json:"weight_grams" validate:"gte=0"`
+	LengthMm    int              `json:"length_mm" validate:"gte=0"`
+	WidthMm     int              `json:"width_mm" validate:"gte=0"`
+	HeightMm    int              `json:"height_mm" validate:"gte=0"`
+	TaxClass    string           `json:"tax_class" validate:"omitempty,slug,max=50"`
+	Stock       *int             `json:"stock" validate:"omitempty,gte=0"`
+}
+
+type ReorderImagesPayload struct {
+	ImageIDs []string `json:"image_ids" validate:"required,min=1,dive,uuid"`
 }
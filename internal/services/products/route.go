@@ -1,19 +1,26 @@
 package products
 
 import (
+	"fmt"
 	"net/http"
+	"strings"
 
 	"github.com/go-chi/chi/v5"
+	uuid "github.com/satori/go.uuid"
+	"github.com/umeh-promise/ecommerce/internal/audit"
 	"github.com/umeh-promise/ecommerce/internal/services/user"
+	"github.com/umeh-promise/ecommerce/internal/storage"
 	"github.com/umeh-promise/ecommerce/utils"
 )
 
 type Handler struct {
 	store ProductStore
+	blobs storage.BlobStore
+	audit audit.AuditStore
 }
 
-func NewHandler(store ProductStore) *Handler {
-	return &Handler{store: store}
+func NewHandler(store ProductStore, blobs storage.BlobStore, auditStore audit.AuditStore) *Handler {
+	return &Handler{store: store, blobs: blobs, audit: auditStore}
 }
 
 func (h *Handler) RegisterRoute(auth *user.Handler) func(r chi.Router) {
@@ -21,11 +28,27 @@ func (h *Handler) RegisterRoute(auth *user.Handler) func(r chi.Router) {
 		r.Route("/products", func(r chi.Router) {
 			r.With(auth.AuthTokenMiddleware).Post("/", h.createProduct)
 			r.Get("/", h.getAllProduct)
+			r.With(auth.AuthTokenMiddleware).Get("/mine", h.getMyProducts)
 			r.Route("/{id}", func(r chi.Router) {
-				r.Use(h.ProductMiddleware)
+				r.Use(auth.OptionalAuthMiddleware, h.ProductMiddleware)
 				r.Get("/", h.getProduct)
-				r.Put("/", h.updateProduct)
-				r.Delete("/", h.deleteProduct)
+				r.Group(func(r chi.Router) {
+					r.Use(auth.AuthTokenMiddleware, h.ProductOwnerMiddleware)
+					r.Put("/", h.updateProduct)
+					r.Delete("/", h.deleteProduct)
+					r.Post("/publish", h.publishProduct)
+					r.Post("/unpublish", h.unpublishProduct)
+				})
+				r.With(auth.AuthTokenMiddleware, h.ProductOwnerMiddleware).Post("/image", h.uploadProductImage)
+				r.Route("/images", func(r chi.Router) {
+					r.Get("/", h.getProductImages)
+					r.Group(func(r chi.Router) {
+						r.Use(auth.AuthTokenMiddleware, h.ProductOwnerMiddleware)
+						r.Post("/", h.addProductImage)
+						r.Put("/order", h.reorderProductImages)
+						r.Delete("/{imageID}", h.deleteProductImage)
+					})
+				})
 			})
 		})
 	}
@@ -53,9 +76,24 @@ func (h *Handler) createProduct(w http.ResponseWriter, r *http.Request) {
 		Name:        payload.Name,
 		Description: payload.Description,
 		Image:       payload.Image,
+		Category:    payload.Category,
 		UserID:      user.ID,
-		Discount:    payload.Discount,
+		Status:      payload.Status,
+		Discount:    payload.Discount.toDiscount(),
 		Price:       payload.Price,
+		WeightGrams: payload.WeightGrams,
+		LengthMm:    payload.LengthMm,
+		WidthMm:     payload.WidthMm,
+		HeightMm:    payload.HeightMm,
+		TaxClass:    payload.TaxClass,
+		Stock:       payload.Stock,
+	}
+
+	if product.Discount != nil {
+		if err := product.Discount.Validate(product.Price); err != nil {
+			utils.BadRequestError(w, r, err)
+			return
+		}
 	}
 
 	if err := h.store.CreateProduct(ctx, product); err != nil {
@@ -63,6 +101,8 @@ func (h *Handler) createProduct(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	audit.Record(h.audit, r, user.ID, "product.create", "product", product.ID, nil, product)
+
 	if err := utils.JSONResponse(w, http.StatusCreated, product); err != nil {
 		utils.InternalServerError(w, r, err)
 		return
@@ -84,9 +124,35 @@ func (h *Handler) getAllProduct(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+func (h *Handler) getMyProducts(w http.ResponseWriter, r *http.Request) {
+	user := user.GetUserFromContext(r)
+
+	products, err := h.store.GetProductsByUserID(r.Context(), user.ID)
+	if err != nil {
+		utils.InternalServerError(w, r, err)
+		return
+	}
+
+	if err := utils.JSONResponse(w, http.StatusOK, products); err != nil {
+		utils.InternalServerError(w, r, err)
+		return
+	}
+}
+
 func (h *Handler) getProduct(w http.ResponseWriter, r *http.Request) {
 	product := GetProductFromMiddleware(r)
 
+	images, err := h.store.GetProductImages(r.Context(), product.ID)
+	if err != nil {
+		utils.InternalServerError(w, r, err)
+		return
+	}
+	product.Images = images
+
+	if utils.NotModified(w, r, product.ETag()) {
+		return
+	}
+
 	if err := utils.JSONResponse(w, http.StatusOK, product); err != nil {
 		utils.InternalServerError(w, r, err)
 		return
@@ -95,15 +161,29 @@ func (h *Handler) getProduct(w http.ResponseWriter, r *http.Request) {
 
 func (h *Handler) updateProduct(w http.ResponseWriter, r *http.Request) {
 	var payload struct {
-		Name        *string `json:"name" validate:"omitempty"`
-		Description *string `json:"description" validate:"omitempty"`
-		Price       *string `json:"price" validate:"omitempty"`
-		Image       *string `json:"image" validate:"omitempty"`
-		Discount    *string `json:"discount" validate:"omitempty"`
+		Name        *string          `json:"name" validate:"omitempty"`
+		Description *string          `json:"description" validate:"omitempty"`
+		Price       *int             `json:"price" validate:"omitempty,gt=0"`
+		Image       *string          `json:"image" validate:"omitempty"`
+		Category    *string          `json:"category" validate:"omitempty,max=100"`
+		Discount    *DiscountPayload `json:"discount" validate:"omitempty"`
+		WeightGrams *int             `json:"weight_grams" validate:"omitempty,gte=0"`
+		LengthMm    *int             `json:"length_mm" validate:"omitempty,gte=0"`
+		WidthMm     *int             `json:"width_mm" validate:"omitempty,gte=0"`
+		HeightMm    *int             `json:"height_mm" validate:"omitempty,gte=0"`
+		TaxClass    *string          `json:"tax_class" validate:"omitempty,slug,max=50"`
+		Stock       *int             `json:"stock" validate:"omitempty,gte=0"`
+		// ClearDiscount removes the discount; a null discount leaves it as is.
+		ClearDiscount bool `json:"clear_discount"`
 	}
 
 	product := GetProductFromMiddleware(r)
 
+	if err := utils.CheckIfMatch(r, product.ETag()); err != nil {
+		utils.PreconditionFailedResponse(w, r, err)
+		return
+	}
+
 	if err := utils.ParseJSON(w, r, &payload); err != nil {
 		utils.BadRequestError(w, r, err)
 		return
@@ -114,23 +194,55 @@ func (h *Handler) updateProduct(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if payload.ClearDiscount && payload.Discount != nil {
+		utils.BadRequestError(w, r, fmt.Errorf("send either discount or clear_discount, not both"))
+		return
+	}
+
+	before := *product
+
 	utils.AssignIfNotNil(&product.Name, payload.Name)
 	utils.AssignIfNotNil(&product.Description, payload.Description)
 	utils.AssignIfNotNil(&product.Price, payload.Price)
 	utils.AssignIfNotNil(&product.Image, payload.Image)
-	utils.AssignIfNotNil(&product.Discount, payload.Discount)
+	utils.AssignIfNotNil(&product.Category, payload.Category)
+	utils.AssignIfNotNil(&product.WeightGrams, payload.WeightGrams)
+	utils.AssignIfNotNil(&product.LengthMm, payload.LengthMm)
+	utils.AssignIfNotNil(&product.WidthMm, payload.WidthMm)
+	utils.AssignIfNotNil(&product.HeightMm, payload.HeightMm)
+	utils.AssignIfNotNil(&product.TaxClass, payload.TaxClass)
+	if payload.Stock != nil {
+		product.Stock = payload.Stock
+	}
+	if payload.Discount != nil {
+		product.Discount = payload.Discount.toDiscount()
+	}
+	if payload.ClearDiscount {
+		product.Discount = nil
+	}
+
+	if product.Discount != nil {
+		if err := product.Discount.Validate(product.Price); err != nil {
+			utils.BadRequestError(w, r, err)
+			return
+		}
+	}
 
 	err := h.store.UpdateProduct(r.Context(), product)
 	if err != nil {
 		switch err {
-		case utils.ErrorNotFound:
-			utils.NotFoundResponse(w, r, err)
+		case utils.ErrorEditConflict:
+			utils.ConflictResponse(w, r, err)
 		default:
 			utils.InternalServerError(w, r, err)
 		}
 		return
 	}
 
+	audit.Record(h.audit, r, user.GetUserFromContext(r).ID, "product.update", "product", product.ID, &before, product)
+
+	w.Header().Set("ETag", product.ETag())
+
 	if err := utils.JSONResponse(w, http.StatusOK, product); err != nil {
 		utils.InternalServerError(w, r, err)
 		return
@@ -138,16 +250,277 @@ func (h *Handler) updateProduct(w http.ResponseWriter, r *http.Request) {
 
 }
 
+func (h *Handler) publishProduct(w http.ResponseWriter, r *http.Request) {
+	h.setProductStatus(w, r, StatusPublished, "product.publish")
+}
+
+func (h *Handler) unpublishProduct(w http.ResponseWriter, r *http.Request) {
+	h.setProductStatus(w, r, StatusDraft, "product.unpublish")
+}
+
+func (h *Handler) setProductStatus(w http.ResponseWriter, r *http.Request, status ProductStatus, action string) {
+	product := GetProductFromMiddleware(r)
+
+	if product.Status == StatusHidden {
+		utils.ForbiddenResponse(w, r, fmt.Errorf("this product was hidden by an administrator"))
+		return
+	}
+
+	before := *product
+	product.Status = status
+
+	if err := h.store.UpdateProduct(r.Context(), product); err != nil {
+		switch err {
+		case utils.ErrorEditConflict:
+			utils.ConflictResponse(w, r, err)
+		default:
+			utils.InternalServerError(w, r, err)
+		}
+		return
+	}
+
+	audit.Record(h.audit, r, user.GetUserFromContext(r).ID, action, "product", product.ID, &before, product)
+
+	w.Header().Set("ETag", product.ETag())
+
+	if err := utils.JSONResponse(w, http.StatusOK, product); err != nil {
+		utils.InternalServerError(w, r, err)
+		return
+	}
+}
+
 func (h *Handler) deleteProduct(w http.ResponseWriter, r *http.Request) {
 	product := GetProductFromMiddleware(r)
 
 	if err := h.store.DeleteProduct(r.Context(), product.ID); err != nil {
+		switch err {
+		case utils.ErrorNotFound:
+			utils.NotFoundResponse(w, r, err)
+		default:
+			utils.InternalServerError(w, r, err)
+		}
+		return
+	}
+
+	audit.Record(h.audit, r, user.GetUserFromContext(r).ID, "product.delete", "product", product.ID, product, nil)
+
+	if err := utils.JSONResponse(w, http.StatusNoContent, nil); err != nil {
+		utils.InternalServerError(w, r, err)
+		return
+	}
+}
+
+func (h *Handler) uploadProductImage(w http.ResponseWriter, r *http.Request) {
+	product := GetProductFromMiddleware(r)
+
+	upload, err := storage.ReadImage(w, r, "image", utils.MaxUploadBytes)
+	if err != nil {
+		utils.BadRequestError(w, r, err)
+		return
+	}
+
+	key := fmt.Sprintf("products/%s/%s%s", product.ID, uuid.NewV4().String(), upload.Extension)
+	if err := h.blobs.Put(r.Context(), key, upload.Reader(), upload.ContentType); err != nil {
+		utils.InternalServerError(w, r, err)
+		return
+	}
+
+	previous := product.Image
+	product.Image = h.blobs.URL(key)
+
+	if err := h.store.UpdateProduct(r.Context(), product); err != nil {
+		switch err {
+		case utils.ErrorEditConflict:
+			utils.ConflictResponse(w, r, err)
+		default:
+			utils.InternalServerError(w, r, err)
+		}
+		return
+	}
+
+	h.removeReplacedImage(r, product, previous)
+
+	w.Header().Set("ETag", product.ETag())
+
+	if err := utils.JSONResponse(w, http.StatusOK, product); err != nil {
+		utils.InternalServerError(w, r, err)
+		return
+	}
+}
+
+func (h *Handler) getProductImages(w http.ResponseWriter, r *http.Request) {
+	product := GetProductFromMiddleware(r)
+
+	images, err := h.store.GetProductImages(r.Context(), product.ID)
+	if err != nil {
+		utils.InternalServerError(w, r, err)
+		return
+	}
+
+	if err := utils.JSONResponse(w, http.StatusOK, images); err != nil {
+		utils.InternalServerError(w, r, err)
+		return
+	}
+}
+
+func (h *Handler) addProductImage(w http.ResponseWriter, r *http.Request) {
+	product := GetProductFromMiddleware(r)
+	ctx := r.Context()
+
+	upload, err := storage.ReadImage(w, r, "image", utils.MaxUploadBytes)
+	if err != nil {
+		utils.BadRequestError(w, r, err)
+		return
+	}
+
+	altText := r.FormValue("alt_text")
+	if err := utils.Validator.Var(altText, "max=255"); err != nil {
+		utils.BadRequestError(w, r, err)
+		return
+	}
+
+	thumbnail, err := storage.Thumbnail(upload.Data, storage.ThumbnailSize)
+	if err != nil {
+		utils.BadRequestError(w, r, fmt.Errorf("could not decode image: %w", err))
+		return
+	}
+
+	name := uuid.NewV4().String()
+	image := &ProductImage{
+		ProductID:    product.ID,
+		BlobKey:      fmt.Sprintf("products/%s/%s%s", product.ID, name, upload.Extension),
+		ThumbnailKey: fmt.Sprintf("products/%s/%s_thumb%s", product.ID, name, thumbnail.Extension),
+		AltText:      altText,
+	}
+	image.URL = h.blobs.URL(image.BlobKey)
+	image.ThumbnailURL = h.blobs.URL(image.ThumbnailKey)
+
+	if err := h.blobs.Put(ctx, image.BlobKey, upload.Reader(), upload.ContentType); err != nil {
+		utils.InternalServerError(w, r, err)
+		return
+	}
+
+	if err := h.blobs.Put(ctx, image.ThumbnailKey, thumbnail.Reader(), thumbnail.ContentType); err != nil {
+		h.removeBlobs(r, image.BlobKey)
+		utils.InternalServerError(w, r, err)
+		return
+	}
+
+	if err := h.store.AddProductImage(ctx, image); err != nil {
+		h.removeBlobs(r, image.BlobKey, image.ThumbnailKey)
+		switch err {
+		case utils.ErrorTooManyImages:
+			utils.BadRequestError(w, r, err)
+		case utils.ErrorNotFound:
+			utils.NotFoundResponse(w, r, err)
+		default:
+			utils.InternalServerError(w, r, err)
+		}
+		return
+	}
+
+	if err := utils.JSONResponse(w, http.StatusCreated, image); err != nil {
 		utils.InternalServerError(w, r, err)
 		return
 	}
+}
+
+func (h *Handler) reorderProductImages(w http.ResponseWriter, r *http.Request) {
+	var payload ReorderImagesPayload
+
+	product := GetProductFromMiddleware(r)
+
+	if err := utils.CheckIfMatch(r, product.ETag()); err != nil {
+		utils.PreconditionFailedResponse(w, r, err)
+		return
+	}
+
+	if err := utils.ParseJSON(w, r, &payload); err != nil {
+		utils.BadRequestError(w, r, err)
+		return
+	}
+
+	if err := utils.Validator.Struct(payload); err != nil {
+		utils.BadRequestError(w, r, err)
+		return
+	}
+
+	if err := h.store.ReorderProductImages(r.Context(), product.ID, payload.ImageIDs); err != nil {
+		switch err {
+		case utils.ErrorInvalidImageOrder:
+			utils.BadRequestError(w, r, err)
+		case utils.ErrorNotFound:
+			utils.NotFoundResponse(w, r, err)
+		default:
+			utils.InternalServerError(w, r, err)
+		}
+		return
+	}
+
+	images, err := h.store.GetProductImages(r.Context(), product.ID)
+	if err != nil {
+		utils.InternalServerError(w, r, err)
+		return
+	}
+
+	if err := utils.JSONResponse(w, http.StatusOK, images); err != nil {
+		utils.InternalServerError(w, r, err)
+		return
+	}
+}
+
+func (h *Handler) deleteProductImage(w http.ResponseWriter, r *http.Request) {
+	product := GetProductFromMiddleware(r)
+
+	image, err := h.store.DeleteProductImage(r.Context(), product.ID, chi.URLParam(r, "imageID"))
+	if err != nil {
+		switch err {
+		case utils.ErrorNotFound:
+			utils.NotFoundResponse(w, r, err)
+		default:
+			utils.InternalServerError(w, r, err)
+		}
+		return
+	}
+
+	h.removeBlobs(r, image.BlobKey, image.ThumbnailKey)
 
 	if err := utils.JSONResponse(w, http.StatusNoContent, nil); err != nil {
 		utils.InternalServerError(w, r, err)
 		return
 	}
 }
+
+// removeReplacedImage deletes the main image a new upload replaced. It is
+// kept if it was set by hand to a URL outside this product's files, or to
+// one of the product's gallery images.
+func (h *Handler) removeReplacedImage(r *http.Request, product *Product, previous string) {
+	key, ok := storage.KeyFromURL(h.blobs, previous)
+	if !ok || !strings.HasPrefix(key, "products/"+product.ID+"/") {
+		return
+	}
+
+	images, err := h.store.GetProductImages(r.Context(), product.ID)
+	if err != nil {
+		utils.Logger.Warnw("failed to delete blob", "key", key, "error", err.Error())
+		return
+	}
+
+	for _, image := range images {
+		if image.BlobKey == key || image.ThumbnailKey == key {
+			return
+		}
+	}
+
+	h.removeBlobs(r, key)
+}
+
+// removeBlobs is best effort: an orphaned file is preferable to failing a
+// request whose database change already happened.
+func (h *Handler) removeBlobs(r *http.Request, keys ...string) {
+	for _, key := range keys {
+		if err := h.blobs.Delete(r.Context(), key); err != nil {
+			utils.Logger.Warnw("failed to delete blob", "key", key, "error", err.Error())
+		}
+	}
+}
@@ -4,11 +4,136 @@ import (
 	"context"
 	"database/sql"
 	"errors"
+	"slices"
 
+	"github.com/lib/pq"
 	uuid "github.com/satori/go.uuid"
+	"github.com/umeh-promise/ecommerce/internal/events"
+	"github.com/umeh-promise/ecommerce/internal/services/tax"
 	"github.com/umeh-promise/ecommerce/utils"
 )
 
+// EffectivePriceSQL computes the selling price in SQL so that only discounts
+// whose sale window contains now() are applied.
+const EffectivePriceSQL = `
+	CASE
+		WHEN discount_type IS NULL
+			OR (discount_starts_at IS NOT NULL AND discount_starts_at > now())
+			OR (discount_ends_at IS NOT NULL AND discount_ends_at <= now()) THEN price
+		WHEN discount_type = 'percentage' THEN price - price * discount_value / 100
+		ELSE GREATEST(price - discount_value, 0)
+	END`
+
+// productColumns is selected from productSource, which joins in the seller
+// so responses can carry a storefront summary.
+const productColumns = `p.id, p.user_id, p.status, p.name, p.price, p.description, p.category, p.image,
+	p.discount_type, p.discount_value, p.discount_starts_at, p.discount_ends_at,
+	` + EffectivePriceSQL + ` AS effective_price,
+	p.rating_average, p.rating_count,
+	p.weight_grams, p.length_mm, p.width_mm, p.height_mm, p.tax_class, p.stock,
+	s.display_name, s.slug, s.logo,
+	p.version, p.created_at, p.updated_at`
+
+const productSource = `products p LEFT JOIN sellers s ON s.user_id = p.user_id`
+
+type scanner interface {
+	Scan(dest ...any) error
+}
+
+func scanProduct(row scanner, product *Product) error {
+	var (
+		discountType  sql.NullString
+		discountValue int
+		startsAt      sql.NullTime
+		endsAt        sql.NullTime
+		sellerName    sql.NullString
+		sellerSlug    sql.NullString
+		sellerLogo    sql.NullString
+		stock         sql.NullInt64
+	)
+
+	err := row.Scan(
+		&product.ID,
+		&product.UserID,
+		&product.Status,
+		&product.Name,
+		&product.Price,
+		&product.Description,
+		&product.Category,
+		&product.Image,
+		&discountType,
+		&discountValue,
+		&startsAt,
+		&endsAt,
+		&product.EffectivePrice,
+		&product.RatingAverage,
+		&product.RatingCount,
+		&product.WeightGrams,
+		&product.LengthMm,
+		&product.WidthMm,
+		&product.HeightMm,
+		&product.TaxClass,
+		&stock,
+		&sellerName,
+		&sellerSlug,
+		&sellerLogo,
+		&product.Version,
+		&product.CreatedAt,
+		&product.UpdatedAt,
+	)
+	if err != nil {
+		return err
+	}
+
+	product.Stock = nil
+	if stock.Valid {
+		n := int(stock.Int64)
+		product.Stock = &n
+	}
+
+	product.Seller = nil
+	if sellerSlug.Valid {
+		product.Seller = &SellerSummary{
+			DisplayName: sellerName.String,
+			Slug:        sellerSlug.String,
+			Logo:        sellerLogo.String,
+		}
+	}
+
+	product.Discount = nil
+	if discountType.Valid {
+		product.Discount = &Discount{
+			Type:  DiscountType(discountType.String),
+			Value: discountValue,
+		}
+		if startsAt.Valid {
+			product.Discount.StartsAt = &startsAt.Time
+		}
+		if endsAt.Valid {
+			product.Discount.EndsAt = &endsAt.Time
+		}
+	}
+
+	return nil
+}
+
+// discountArgs flattens a discount into its nullable column values.
+func discountArgs(discount *Discount) (any, int, any, any) {
+	if discount == nil {
+		return nil, 0, nil, nil
+	}
+
+	var startsAt, endsAt any
+	if discount.StartsAt != nil {
+		startsAt = *discount.StartsAt
+	}
+	if discount.EndsAt != nil {
+		endsAt = *discount.EndsAt
+	}
+
+	return string(discount.Type), discount.Value, startsAt, endsAt
+}
+
 type Store struct {
 	db *sql.DB
 }
@@ -20,43 +145,98 @@ func NewStore(db *sql.DB) *Store {
 func (s *Store) CreateProduct(ctx context.Context, product *Product) error {
 	query := `
 		INSERT INTO products
-			(id,user_id,discount,name,price,description,image) 
-		VALUES 
-			($1, $2, $3, $4, $5, $6, $7)
-		RETURNING id, version, created_at, updated_at
-		
+			(id, user_id, status, name, price, description, category, image, discount_type, discount_value, discount_starts_at, discount_ends_at,
+			weight_grams, length_mm, width_mm, height_mm, tax_class, stock)
+		VALUES
+			($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18)
+		RETURNING id, ` + EffectivePriceSQL + `, version, created_at, updated_at
 	`
 
 	product.ID = uuid.NewV4().String()
+	if product.Status == "" {
+		product.Status = StatusDraft
+	}
+	if product.TaxClass == "" {
+		product.TaxClass = tax.ClassStandard
+	}
+	discountType, discountValue, startsAt, endsAt := discountArgs(product.Discount)
 
 	ctx, cancel := context.WithTimeout(ctx, utils.QueryTimeout)
 	defer cancel()
 
-	err := s.db.QueryRowContext(ctx, query, product.ID, product.UserID, product.Discount, product.Name, product.Price, product.Description, product.Image).Scan(
-		&product.ID,
-		&product.Version,
-		&product.CreatedAt,
-		&product.UpdatedAt,
-	)
-
-	if err != nil {
-		return err
-	}
+	return utils.WithTx(ctx, s.db, func(tx *sql.Tx) error {
+		err := tx.QueryRowContext(ctx, query,
+			product.ID, product.UserID, product.Status, product.Name, product.Price, product.Description, product.Category, product.Image,
+			discountType, discountValue, startsAt, endsAt,
+			product.WeightGrams, product.LengthMm, product.WidthMm, product.HeightMm, product.TaxClass, product.Stock,
+		).Scan(
+			&product.ID,
+			&product.EffectivePrice,
+			&product.Version,
+			&product.CreatedAt,
+			&product.UpdatedAt,
+		)
+		if err != nil {
+			return err
+		}
 
-	return nil
+		return events.Publish(ctx, tx, events.ProductCreated, product.ID, product)
+	})
 }
 
+// GetAllProduct lists the public catalogue, i.e. published products only.
 func (s *Store) GetAllProduct(ctx context.Context) ([]Product, error) {
 
-	query := `SELECT  
-		id, user_id, name, price, description, discount, image, version, created_at, updated_at
-		FROM products 
+	query := `SELECT ` + productColumns + `
+		FROM ` + productSource + `
+		WHERE p.status = 'published'
+	`
+
+	return s.queryProducts(ctx, query)
+}
+
+// GetPublishedProductsByUserID lists a seller's public storefront.
+func (s *Store) GetPublishedProductsByUserID(ctx context.Context, userID string) ([]Product, error) {
+	query := `SELECT ` + productColumns + `
+		FROM ` + productSource + `
+		WHERE p.user_id = $1 AND p.status = 'published'
+		ORDER BY p.created_at DESC
 	`
 
+	return s.queryProducts(ctx, query, userID)
+}
+
+// GetProductsByUserID lists every product a seller owns, whatever its status.
+func (s *Store) GetProductsByUserID(ctx context.Context, userID string) ([]Product, error) {
+	query := `SELECT ` + productColumns + `
+		FROM ` + productSource + `
+		WHERE p.user_id = $1
+		ORDER BY p.created_at DESC
+	`
+
+	return s.queryProducts(ctx, query, userID)
+}
+
+func (s *Store) GetProducts(ctx context.Context, filter *ProductFilter) ([]Product, error) {
+	query := `SELECT ` + productColumns + `
+		FROM ` + productSource + `
+		WHERE ($1 = '' OR p.name ILIKE '%' || $1 || '%')
+			AND ($2 = '' OR p.status = $2)
+			AND ($3 = '' OR p.user_id::text = $3)
+		ORDER BY p.created_at DESC, p.id
+		LIMIT $4 OFFSET $5
+	`
+
+	search := utils.EscapeLike(filter.Query)
+
+	return s.queryProducts(ctx, query, search, filter.Status, filter.UserID, filter.Limit, filter.Offset)
+}
+
+func (s *Store) queryProducts(ctx context.Context, query string, args ...any) ([]Product, error) {
 	ctx, cancel := context.WithTimeout(ctx, utils.QueryTimeout)
 	defer cancel()
 
-	rows, err := s.db.QueryContext(ctx, query)
+	rows, err := s.db.QueryContext(ctx, query, args...)
 	if err != nil {
 		return nil, err
 	}
@@ -66,49 +246,27 @@ func (s *Store) GetAllProduct(ctx context.Context) ([]Product, error) {
 
 	for rows.Next() {
 		product := Product{}
-		err := rows.Scan(
-			&product.ID,
-			&product.UserID,
-			&product.Name,
-			&product.Price,
-			&product.Description,
-			&product.Discount,
-			&product.Image,
-			&product.Version,
-			&product.CreatedAt,
-			&product.UpdatedAt,
-		)
-		if err != nil {
+		if err := scanProduct(rows, &product); err != nil {
 			return nil, err
 		}
 
 		products = append(products, product)
 	}
 
-	return products, nil
+	return products, rows.Err()
 }
 
 func (s *Store) GetPostByID(ctx context.Context, id string) (*Product, error) {
 
 	var product Product
 
-	query := `SELECT id,user_id,discount,name,price,description,image, version, created_at, updated_at FROM products
-	WHERE id = $1`
+	query := `SELECT ` + productColumns + ` FROM ` + productSource + `
+	WHERE p.id = $1`
 
 	ctx, cancel := context.WithTimeout(ctx, utils.QueryTimeout)
 	defer cancel()
 
-	err := s.db.QueryRowContext(ctx, query, id).Scan(&product.ID,
-		&product.UserID,
-		&product.Discount,
-		&product.Name,
-		&product.Price,
-		&product.Description,
-		&product.Image,
-		&product.Version,
-		&product.CreatedAt,
-		&product.UpdatedAt)
-
+	err := scanProduct(s.db.QueryRowContext(ctx, query, id), &product)
 	if err != nil {
 		switch {
 		case errors.Is(err, sql.ErrNoRows):
@@ -123,42 +281,278 @@ func (s *Store) GetPostByID(ctx context.Context, id string) (*Product, error) {
 
 func (s *Store) UpdateProduct(ctx context.Context, product *Product) error {
 
-	query := `UPDATE products 
-	SET name = $1, description = $2, image = $3, price = $4, discount=$5,  version = version + 1
-	WHERE id = $6 AND version = $7
-	RETURNING version
+	query := `UPDATE products
+	SET name = $1, description = $2, image = $3, price = $4, category = $5, status = $6,
+		discount_type = $7, discount_value = $8, discount_starts_at = $9, discount_ends_at = $10,
+		weight_grams = $11, length_mm = $12, width_mm = $13, height_mm = $14, tax_class = $15, stock = $16,
+		version = version + 1, updated_at = now()
+	WHERE id = $17 AND version = $18
+	RETURNING ` + EffectivePriceSQL + `, version
 `
 
+	discountType, discountValue, startsAt, endsAt := discountArgs(product.Discount)
+
 	ctx, cancel := context.WithTimeout(ctx, utils.QueryTimeout)
 	defer cancel()
 
-	err := s.db.QueryRowContext(ctx, query, product.Name, product.Description, product.Image, product.Price, product.Discount, product.ID, product.Version).Scan(
-		&product.Version,
-	)
+	return utils.WithTx(ctx, s.db, func(tx *sql.Tx) error {
+		err := tx.QueryRowContext(ctx, query,
+			product.Name, product.Description, product.Image, product.Price, product.Category, product.Status,
+			discountType, discountValue, startsAt, endsAt,
+			product.WeightGrams, product.LengthMm, product.WidthMm, product.HeightMm, product.TaxClass, product.Stock,
+			product.ID, product.Version,
+		).Scan(
+			&product.EffectivePrice,
+			&product.Version,
+		)
+		if err != nil {
+			switch err {
+			case sql.ErrNoRows:
+				return utils.ErrorEditConflict
+			default:
+				return err
+			}
+		}
 
-	if err != nil {
-		switch err {
-		case sql.ErrNoRows:
+		return events.Publish(ctx, tx, events.ProductUpdated, product.ID, product)
+	})
+}
+
+// DeleteProduct archives the product rather than removing the row, so order
+// items that reference it stay intact.
+func (s *Store) DeleteProduct(ctx context.Context, id string) error {
+	query := `UPDATE products
+	SET status = 'archived', version = version + 1, updated_at = now()
+	WHERE id = $1
+	`
+
+	ctx, cancel := context.WithTimeout(ctx, utils.QueryTimeout)
+	defer cancel()
+
+	return utils.WithTx(ctx, s.db, func(tx *sql.Tx) error {
+		result, err := tx.ExecContext(ctx, query, id)
+		if err != nil {
+			return err
+		}
+
+		rows, err := result.RowsAffected()
+		if err != nil {
+			return err
+		}
+
+		if rows == 0 {
 			return utils.ErrorNotFound
-		default:
+		}
+
+		return events.Publish(ctx, tx, events.ProductDeleted, id, map[string]string{"id": id})
+	})
+}
+
+const maxProductImages = 10
+
+const productImageColumns = `id, product_id, url, thumbnail_url, blob_key, thumbnail_key, alt_text, position, created_at`
+
+func scanProductImage(row scanner, image *ProductImage) error {
+	return row.Scan(
+		&image.ID,
+		&image.ProductID,
+		&image.URL,
+		&image.ThumbnailURL,
+		&image.BlobKey,
+		&image.ThumbnailKey,
+		&image.AltText,
+		&image.Position,
+		&image.CreatedAt,
+	)
+}
+
+// syncCoverImage keeps products.image pointing at the first gallery image.
+// It only takes over an image that is empty or already a gallery image (or
+// one of removed, gallery URLs just deleted), so a main image uploaded or
+// set by hand is left alone. It bumps the version too, since the gallery is
+// part of the product.
+func syncCoverImage(ctx context.Context, tx *sql.Tx, productID string, removed ...string) error {
+	if removed == nil {
+		removed = []string{}
+	}
+
+	_, err := tx.ExecContext(ctx, `
+		UPDATE products
+		SET image = CASE
+				WHEN image = '' OR image = ANY($2::text[])
+					OR image IN (SELECT url FROM product_images WHERE product_id = $1)
+				THEN COALESCE((
+					SELECT url FROM product_images WHERE product_id = $1 ORDER BY position LIMIT 1
+				), '')
+				ELSE image
+			END,
+			version = version + 1, updated_at = now()
+		WHERE id = $1
+	`, productID, pq.Array(removed))
+
+	return err
+}
+
+func lockProduct(ctx context.Context, tx *sql.Tx, productID string) error {
+	var id string
+	err := tx.QueryRowContext(ctx, `SELECT id FROM products WHERE id = $1 FOR UPDATE`, productID).Scan(&id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return utils.ErrorNotFound
+	}
+
+	return err
+}
+
+func (s *Store) AddProductImage(ctx context.Context, image *ProductImage) error {
+	image.ID = uuid.NewV4().String()
+
+	ctx, cancel := context.WithTimeout(ctx, utils.QueryTimeout)
+	defer cancel()
+
+	return utils.WithTx(ctx, s.db, func(tx *sql.Tx) error {
+		if err := lockProduct(ctx, tx, image.ProductID); err != nil {
 			return err
 		}
+
+		var count int
+		err := tx.QueryRowContext(ctx, `SELECT count(*) FROM product_images WHERE product_id = $1`, image.ProductID).Scan(&count)
+		if err != nil {
+			return err
+		}
+
+		if count >= maxProductImages {
+			return utils.ErrorTooManyImages
+		}
+
+		image.Position = count
+
+		err = tx.QueryRowContext(ctx, `
+			INSERT INTO product_images
+				(id, product_id, url, thumbnail_url, blob_key, thumbnail_key, alt_text, position)
+			VALUES
+				($1, $2, $3, $4, $5, $6, $7, $8)
+			RETURNING created_at
+		`, image.ID, image.ProductID, image.URL, image.ThumbnailURL, image.BlobKey, image.ThumbnailKey, image.AltText, image.Position,
+		).Scan(&image.CreatedAt)
+		if err != nil {
+			return err
+		}
+
+		return syncCoverImage(ctx, tx, image.ProductID)
+	})
+}
+
+func (s *Store) GetProductImages(ctx context.Context, productID string) ([]ProductImage, error) {
+	query := `SELECT ` + productImageColumns + ` FROM product_images WHERE product_id = $1 ORDER BY position`
+
+	ctx, cancel := context.WithTimeout(ctx, utils.QueryTimeout)
+	defer cancel()
+
+	rows, err := s.db.QueryContext(ctx, query, productID)
+	if err != nil {
+		return nil, err
 	}
+	defer rows.Close()
 
-	return nil
+	var images []ProductImage
+
+	for rows.Next() {
+		image := ProductImage{}
+		if err := scanProductImage(rows, &image); err != nil {
+			return nil, err
+		}
+
+		images = append(images, image)
+	}
+
+	return images, rows.Err()
 }
 
-func (s *Store) DeleteProduct(ctx context.Context, id string) error {
-	query := `DELETE FROM products
-	WHERE id = $1
-	`
+// ReorderProductImages assigns positions following imageIDs, which must name
+// every image of the product exactly once.
+func (s *Store) ReorderProductImages(ctx context.Context, productID string, imageIDs []string) error {
+	ctx, cancel := context.WithTimeout(ctx, utils.QueryTimeout)
+	defer cancel()
+
+	return utils.WithTx(ctx, s.db, func(tx *sql.Tx) error {
+		if err := lockProduct(ctx, tx, productID); err != nil {
+			return err
+		}
+
+		rows, err := tx.QueryContext(ctx, `SELECT id FROM product_images WHERE product_id = $1`, productID)
+		if err != nil {
+			return err
+		}
+
+		var existing []string
+		for rows.Next() {
+			var id string
+			if err := rows.Scan(&id); err != nil {
+				rows.Close()
+				return err
+			}
+			existing = append(existing, id)
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return err
+		}
+
+		wanted := slices.Clone(imageIDs)
+		slices.Sort(existing)
+		slices.Sort(wanted)
+		wanted = slices.Compact(wanted)
+		if len(wanted) != len(imageIDs) || !slices.Equal(existing, wanted) {
+			return utils.ErrorInvalidImageOrder
+		}
+
+		for position, id := range imageIDs {
+			_, err := tx.ExecContext(ctx, `UPDATE product_images SET position = $1 WHERE id = $2`, position, id)
+			if err != nil {
+				return err
+			}
+		}
+
+		return syncCoverImage(ctx, tx, productID)
+	})
+}
+
+func (s *Store) DeleteProductImage(ctx context.Context, productID, imageID string) (*ProductImage, error) {
+	var image ProductImage
 
 	ctx, cancel := context.WithTimeout(ctx, utils.QueryTimeout)
 	defer cancel()
 
-	_, err := s.db.ExecContext(ctx, query, id)
+	err := utils.WithTx(ctx, s.db, func(tx *sql.Tx) error {
+		if err := lockProduct(ctx, tx, productID); err != nil {
+			return err
+		}
+
+		err := scanProductImage(tx.QueryRowContext(ctx, `
+			DELETE FROM product_images WHERE id = $1 AND product_id = $2
+			RETURNING `+productImageColumns,
+			imageID, productID,
+		), &image)
+		if err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				return utils.ErrorNotFound
+			}
+			return err
+		}
+
+		_, err = tx.ExecContext(ctx, `
+			UPDATE product_images SET position = position - 1
+			WHERE product_id = $1 AND position > $2
+		`, productID, image.Position)
+		if err != nil {
+			return err
+		}
+
+		return syncCoverImage(ctx, tx, productID, image.URL)
+	})
 	if err != nil {
-		return err
+		return nil, err
 	}
-	return nil
+
+	return &image, nil
 }
@@ -0,0 +1,180 @@
+package promotions
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/umeh-promise/ecommerce/utils"
+)
+
+type CouponType string
+
+const (
+	CouponPercentage   CouponType = "percentage"
+	CouponFixed        CouponType = "fixed"
+	CouponFreeShipping CouponType = "free_shipping"
+)
+
+type Promotion struct {
+	ID            string     `json:"id"`
+	Code          string     `json:"code"`
+	Type          CouponType `json:"type"`
+	Value         int        `json:"value"`
+	MinOrderValue int        `json:"min_order_value"`
+	UsageLimit    *int       `json:"usage_limit"`
+	PerUserLimit  *int       `json:"per_user_limit"`
+	TimesUsed     int        `json:"times_used"`
+	ProductIDs    []string   `json:"product_ids"`
+	Categories    []string   `json:"categories"`
+	StartsAt      *time.Time `json:"starts_at"`
+	ExpiresAt     *time.Time `json:"expires_at"`
+	Active        bool       `json:"active"`
+	Version       string     `json:"-"`
+	CreatedAt     string     `json:"-"`
+	UpdatedAt     string     `json:"-"`
+}
+
+// Line is a priced order line the coupon is evaluated against.
+type Line struct {
+	ProductID string
+	Category  string
+	Amount    int
+}
+
+type Result struct {
+	Code         string `json:"code"`
+	Discount     int    `json:"discount"`
+	FreeShipping bool   `json:"free_shipping"`
+}
+
+// IsLive reports whether the promotion can be used at the given time.
+func (p *Promotion) IsLive(at time.Time) bool {
+	if !p.Active {
+		return false
+	}
+	if p.StartsAt != nil && p.StartsAt.After(at) {
+		return false
+	}
+	if p.ExpiresAt != nil && !p.ExpiresAt.After(at) {
+		return false
+	}
+	return true
+}
+
+func (p *Promotion) eligible(line Line) bool {
+	if len(p.ProductIDs) == 0 && len(p.Categories) == 0 {
+		return true
+	}
+
+	return slices.Contains(p.ProductIDs, line.ProductID) ||
+		(line.Category != "" && slices.Contains(p.Categories, line.Category))
+}
+
+// Evaluate computes the discount the promotion grants on the given lines.
+// Usage limits are not checked here since they depend on stored redemptions.
+func (p *Promotion) Evaluate(lines []Line, at time.Time) (*Result, error) {
+	if !p.IsLive(at) {
+		return nil, utils.ErrorInvalidCoupon
+	}
+
+	subtotal, eligible := 0, 0
+	for _, line := range lines {
+		subtotal += line.Amount
+		if p.eligible(line) {
+			eligible += line.Amount
+		}
+	}
+
+	if subtotal < p.MinOrderValue {
+		return nil, fmt.Errorf("%w: minimum order value is %d", utils.ErrorCouponNotApplicable, p.MinOrderValue)
+	}
+
+	if eligible == 0 {
+		return nil, utils.ErrorCouponNotApplicable
+	}
+
+	result := &Result{Code: p.Code}
+
+	switch p.Type {
+	case CouponPercentage:
+		result.Discount = eligible * p.Value / 100
+	case CouponFixed:
+		result.Discount = min(p.Value, eligible)
+	case CouponFreeShipping:
+		result.FreeShipping = true
+	}
+
+	return result, nil
+}
+
+type Redemption struct {
+	ID             string `json:"id"`
+	PromotionID    string `json:"promotion_id"`
+	UserID         string `json:"user_id"`
+	OrderID        string `json:"order_id"`
+	DiscountAmount int    `json:"discount_amount"`
+	CreatedAt      string `json:"created_at"`
+}
+
+type PromotionStore interface {
+	CreatePromotion(context.Context, *Promotion) error
+	GetAllPromotions(context.Context) ([]Promotion, error)
+	GetPromotionByCode(context.Context, string) (*Promotion, error)
+	CountUserRedemptions(context.Context, string, string) (int, error)
+	Redeem(context.Context, *sql.Tx, string, *Redemption) error
+}
+
+// Quote checks that the code can still be used by the user and evaluates it
+// against the order lines.
+func Quote(ctx context.Context, store PromotionStore, code, userID string, lines []Line) (*Result, error) {
+	promotion, err := store.GetPromotionByCode(ctx, code)
+	if err != nil {
+		if errors.Is(err, utils.ErrorNotFound) {
+			return nil, utils.ErrorInvalidCoupon
+		}
+		return nil, err
+	}
+
+	if promotion.UsageLimit != nil && promotion.TimesUsed >= *promotion.UsageLimit {
+		return nil, utils.ErrorCouponUsageExceeded
+	}
+
+	if promotion.PerUserLimit != nil {
+		used, err := store.CountUserRedemptions(ctx, promotion.ID, userID)
+		if err != nil {
+			return nil, err
+		}
+		if used >= *promotion.PerUserLimit {
+			return nil, utils.ErrorCouponUsageExceeded
+		}
+	}
+
+	return promotion.Evaluate(lines, time.Now())
+}
+
+type PromotionPayload struct {
+	Code          string     `json:"code" validate:"required,alphanum,min=3,max=50"`
+	Type          CouponType `json:"type" validate:"required,oneof=percentage fixed free_shipping"`
+	Value         int        `json:"value" validate:"gte=0"`
+	MinOrderValue int        `json:"min_order_value" validate:"gte=0"`
+	UsageLimit    *int       `json:"usage_limit" validate:"omitempty,gt=0"`
+	PerUserLimit  *int       `json:"per_user_limit" validate:"omitempty,gt=0"`
+	ProductIDs    []string   `json:"product_ids" validate:"omitempty,dive,uuid"`
+	Categories    []string   `json:"categories" validate:"omitempty,dive,max=100"`
+	StartsAt      *time.Time `json:"starts_at"`
+	ExpiresAt     *time.Time `json:"expires_at"`
+}
+
+type ItemPayload struct {
+	ProductID string `json:"product_id" validate:"required,uuid"`
+	Quantity  int    `json:"quantity" validate:"required,gt=0"`
+}
+
+type ValidateCouponPayload struct {
+	Code  string        `json:"code" validate:"required"`
+	Items []ItemPayload `json:"items" validate:"required,min=1,dive"`
+}
@@ -0,0 +1,176 @@
+package promotions
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/umeh-promise/ecommerce/internal/services/products"
+	"github.com/umeh-promise/ecommerce/internal/services/user"
+	"github.com/umeh-promise/ecommerce/utils"
+)
+
+type Handler struct {
+	store        PromotionStore
+	productStore products.ProductStore
+}
+
+func NewHandler(store PromotionStore, productStore products.ProductStore) *Handler {
+	return &Handler{store: store, productStore: productStore}
+}
+
+func (h *Handler) RegisterRoute(auth *user.Handler) func(r chi.Router) {
+	return func(r chi.Router) {
+		r.Route("/promotions", func(r chi.Router) {
+			r.Use(auth.AuthTokenMiddleware)
+			r.Post("/validate", h.validateCoupon)
+
+			r.Group(func(r chi.Router) {
+				r.Use(auth.AdminMiddleware)
+				r.Post("/", h.createPromotion)
+				r.Get("/", h.getAllPromotions)
+			})
+		})
+	}
+}
+
+// IsCouponError reports whether err should be surfaced to the client as a
+// rejected coupon rather than a server failure.
+func IsCouponError(err error) bool {
+	return errors.Is(err, utils.ErrorInvalidCoupon) ||
+		errors.Is(err, utils.ErrorCouponUsageExceeded) ||
+		errors.Is(err, utils.ErrorCouponNotApplicable)
+}
+
+func (h *Handler) createPromotion(w http.ResponseWriter, r *http.Request) {
+	var payload PromotionPayload
+
+	if err := utils.ParseJSON(w, r, &payload); err != nil {
+		utils.BadRequestError(w, r, err)
+		return
+	}
+
+	if err := utils.Validator.Struct(payload); err != nil {
+		utils.BadRequestError(w, r, err)
+		return
+	}
+
+	if payload.Type == CouponPercentage && payload.Value > 100 {
+		utils.BadRequestError(w, r, fmt.Errorf("percentage coupon cannot exceed 100"))
+		return
+	}
+
+	if payload.StartsAt != nil && payload.ExpiresAt != nil && !payload.ExpiresAt.After(*payload.StartsAt) {
+		utils.BadRequestError(w, r, fmt.Errorf("coupon must expire after it starts"))
+		return
+	}
+
+	promotion := &Promotion{
+		Code:          payload.Code,
+		Type:          payload.Type,
+		Value:         payload.Value,
+		MinOrderValue: payload.MinOrderValue,
+		UsageLimit:    payload.UsageLimit,
+		PerUserLimit:  payload.PerUserLimit,
+		ProductIDs:    payload.ProductIDs,
+		Categories:    payload.Categories,
+		StartsAt:      payload.StartsAt,
+		ExpiresAt:     payload.ExpiresAt,
+	}
+
+	if err := h.store.CreatePromotion(r.Context(), promotion); err != nil {
+		switch err {
+		case utils.ErrorDuplicateCouponCode:
+			utils.BadRequestError(w, r, err)
+		default:
+			utils.InternalServerError(w, r, err)
+		}
+		return
+	}
+
+	if err := utils.JSONResponse(w, http.StatusCreated, promotion); err != nil {
+		utils.InternalServerError(w, r, err)
+		return
+	}
+}
+
+func (h *Handler) getAllPromotions(w http.ResponseWriter, r *http.Request) {
+	promotions, err := h.store.GetAllPromotions(r.Context())
+	if err != nil {
+		utils.InternalServerError(w, r, err)
+		return
+	}
+
+	if err := utils.JSONResponse(w, http.StatusOK, promotions); err != nil {
+		utils.InternalServerError(w, r, err)
+		return
+	}
+}
+
+func (h *Handler) validateCoupon(w http.ResponseWriter, r *http.Request) {
+	var payload ValidateCouponPayload
+
+	if err := utils.ParseJSON(w, r, &payload); err != nil {
+		utils.BadRequestError(w, r, err)
+		return
+	}
+
+	if err := utils.Validator.Struct(payload); err != nil {
+		utils.BadRequestError(w, r, err)
+		return
+	}
+
+	user := user.GetUserFromContext(r)
+
+	lines, err := h.priceItems(r.Context(), payload.Items)
+	if err != nil {
+		switch err {
+		case utils.ErrorNotFound:
+			utils.BadRequestError(w, r, fmt.Errorf("one or more products do not exist"))
+		default:
+			utils.InternalServerError(w, r, err)
+		}
+		return
+	}
+
+	result, err := Quote(r.Context(), h.store, payload.Code, user.ID, lines)
+	if err != nil {
+		switch {
+		case IsCouponError(err):
+			utils.BadRequestError(w, r, err)
+		default:
+			utils.InternalServerError(w, r, err)
+		}
+		return
+	}
+
+	if err := utils.JSONResponse(w, http.StatusOK, result); err != nil {
+		utils.InternalServerError(w, r, err)
+		return
+	}
+}
+
+func (h *Handler) priceItems(ctx context.Context, items []ItemPayload) ([]Line, error) {
+	lines := make([]Line, 0, len(items))
+
+	for _, item := range items {
+		product, err := h.productStore.GetPostByID(ctx, item.ProductID)
+		if err != nil {
+			return nil, err
+		}
+
+		if product.Status != products.StatusPublished {
+			return nil, utils.ErrorNotFound
+		}
+
+		lines = append(lines, Line{
+			ProductID: product.ID,
+			Category:  product.Category,
+			Amount:    product.EffectivePrice * item.Quantity,
+		})
+	}
+
+	return lines, nil
+}
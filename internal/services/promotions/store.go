@@ -0,0 +1,246 @@
+package promotions
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/lib/pq"
+	uuid "github.com/satori/go.uuid"
+	"github.com/umeh-promise/ecommerce/utils"
+)
+
+const promotionColumns = `id, code, type, value, min_order_value, usage_limit, per_user_limit, times_used,
+	product_ids, categories, starts_at, expires_at, active, version, created_at, updated_at`
+
+type scanner interface {
+	Scan(dest ...any) error
+}
+
+func scanPromotion(row scanner, promotion *Promotion) error {
+	var (
+		usageLimit   sql.NullInt64
+		perUserLimit sql.NullInt64
+		startsAt     sql.NullTime
+		expiresAt    sql.NullTime
+		productIDs   pq.StringArray
+		categories   pq.StringArray
+	)
+
+	err := row.Scan(
+		&promotion.ID,
+		&promotion.Code,
+		&promotion.Type,
+		&promotion.Value,
+		&promotion.MinOrderValue,
+		&usageLimit,
+		&perUserLimit,
+		&promotion.TimesUsed,
+		&productIDs,
+		&categories,
+		&startsAt,
+		&expiresAt,
+		&promotion.Active,
+		&promotion.Version,
+		&promotion.CreatedAt,
+		&promotion.UpdatedAt,
+	)
+	if err != nil {
+		return err
+	}
+
+	promotion.ProductIDs = productIDs
+	promotion.Categories = categories
+	if usageLimit.Valid {
+		limit := int(usageLimit.Int64)
+		promotion.UsageLimit = &limit
+	}
+	if perUserLimit.Valid {
+		limit := int(perUserLimit.Int64)
+		promotion.PerUserLimit = &limit
+	}
+	if startsAt.Valid {
+		promotion.StartsAt = &startsAt.Time
+	}
+	if expiresAt.Valid {
+		promotion.ExpiresAt = &expiresAt.Time
+	}
+
+	return nil
+}
+
+type Store struct {
+	db *sql.DB
+}
+
+func NewStore(db *sql.DB) *Store {
+	return &Store{db: db}
+}
+
+func (s *Store) CreatePromotion(ctx context.Context, promotion *Promotion) error {
+	query := `
+		INSERT INTO promotions
+			(id, code, type, value, min_order_value, usage_limit, per_user_limit, product_ids, categories, starts_at, expires_at)
+		VALUES
+			($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+		RETURNING active, version, created_at, updated_at
+	`
+
+	promotion.ID = uuid.NewV4().String()
+	promotion.Code = strings.ToUpper(promotion.Code)
+
+	// An unrestricted coupon has empty lists; pq would send nil as NULL.
+	if promotion.ProductIDs == nil {
+		promotion.ProductIDs = []string{}
+	}
+	if promotion.Categories == nil {
+		promotion.Categories = []string{}
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, utils.QueryTimeout)
+	defer cancel()
+
+	err := s.db.QueryRowContext(ctx, query,
+		promotion.ID, promotion.Code, promotion.Type, promotion.Value, promotion.MinOrderValue,
+		promotion.UsageLimit, promotion.PerUserLimit,
+		pq.Array(promotion.ProductIDs), pq.Array(promotion.Categories),
+		promotion.StartsAt, promotion.ExpiresAt,
+	).Scan(
+		&promotion.Active,
+		&promotion.Version,
+		&promotion.CreatedAt,
+		&promotion.UpdatedAt,
+	)
+	if err != nil {
+		switch {
+		case err.Error() == `pq: duplicate key value violates unique constraint "promotions_code_key"`:
+			return utils.ErrorDuplicateCouponCode
+		default:
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (s *Store) GetAllPromotions(ctx context.Context) ([]Promotion, error) {
+	query := `SELECT ` + promotionColumns + ` FROM promotions ORDER BY created_at DESC`
+
+	ctx, cancel := context.WithTimeout(ctx, utils.QueryTimeout)
+	defer cancel()
+
+	rows, err := s.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var promotions []Promotion
+
+	for rows.Next() {
+		promotion := Promotion{}
+		if err := scanPromotion(rows, &promotion); err != nil {
+			return nil, err
+		}
+
+		promotions = append(promotions, promotion)
+	}
+
+	return promotions, rows.Err()
+}
+
+func (s *Store) GetPromotionByCode(ctx context.Context, code string) (*Promotion, error) {
+	var promotion Promotion
+
+	query := `SELECT ` + promotionColumns + ` FROM promotions WHERE code = $1`
+
+	ctx, cancel := context.WithTimeout(ctx, utils.QueryTimeout)
+	defer cancel()
+
+	err := scanPromotion(s.db.QueryRowContext(ctx, query, strings.ToUpper(code)), &promotion)
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return nil, utils.ErrorNotFound
+		default:
+			return nil, err
+		}
+	}
+
+	return &promotion, nil
+}
+
+func (s *Store) CountUserRedemptions(ctx context.Context, promotionID, userID string) (int, error) {
+	query := `SELECT count(*) FROM promotion_redemptions WHERE promotion_id = $1 AND user_id = $2`
+
+	ctx, cancel := context.WithTimeout(ctx, utils.QueryTimeout)
+	defer cancel()
+
+	var count int
+	if err := s.db.QueryRowContext(ctx, query, promotionID, userID).Scan(&count); err != nil {
+		return 0, err
+	}
+
+	return count, nil
+}
+
+// Redeem records a redemption inside the caller's transaction. The promotion
+// row is locked so concurrent checkouts cannot push usage past its limits.
+func (s *Store) Redeem(ctx context.Context, tx *sql.Tx, code string, redemption *Redemption) error {
+	var promotion Promotion
+
+	query := `SELECT ` + promotionColumns + ` FROM promotions WHERE code = $1 FOR UPDATE`
+
+	err := scanPromotion(tx.QueryRowContext(ctx, query, strings.ToUpper(code)), &promotion)
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return utils.ErrorInvalidCoupon
+		default:
+			return err
+		}
+	}
+
+	if !promotion.IsLive(time.Now()) {
+		return utils.ErrorInvalidCoupon
+	}
+
+	if promotion.UsageLimit != nil && promotion.TimesUsed >= *promotion.UsageLimit {
+		return utils.ErrorCouponUsageExceeded
+	}
+
+	if promotion.PerUserLimit != nil {
+		var used int
+		err := tx.QueryRowContext(ctx,
+			`SELECT count(*) FROM promotion_redemptions WHERE promotion_id = $1 AND user_id = $2`,
+			promotion.ID, redemption.UserID,
+		).Scan(&used)
+		if err != nil {
+			return err
+		}
+
+		if used >= *promotion.PerUserLimit {
+			return utils.ErrorCouponUsageExceeded
+		}
+	}
+
+	redemption.ID = uuid.NewV4().String()
+	redemption.PromotionID = promotion.ID
+
+	err = tx.QueryRowContext(ctx, `
+		INSERT INTO promotion_redemptions (id, promotion_id, user_id, order_id, discount_amount)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING created_at
+	`, redemption.ID, redemption.PromotionID, redemption.UserID, redemption.OrderID, redemption.DiscountAmount).Scan(&redemption.CreatedAt)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.ExecContext(ctx, `
+		UPDATE promotions SET times_used = times_used + 1, updated_at = now() WHERE id = $1
+	`, promotion.ID)
+
+	return err
+}
@@ -0,0 +1,62 @@
+package returns
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/umeh-promise/ecommerce/internal/services/user"
+	"github.com/umeh-promise/ecommerce/utils"
+)
+
+type returnKey string
+
+var returnCtx returnKey = "return"
+
+// ReturnMiddleware loads the return in the URL for the customer who asked
+// for it, the seller it was sent to, and admins. It must be mounted after
+// AuthTokenMiddleware.
+func (middleware *Handler) ReturnMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		returnID := chi.URLParam(r, "returnID")
+		ctx := r.Context()
+
+		ret, err := middleware.store.GetReturnByID(ctx, returnID)
+		if err != nil {
+			switch err {
+			case utils.ErrorNotFound:
+				utils.NotFoundResponse(w, r, err)
+			default:
+				utils.InternalServerError(w, r, err)
+			}
+			return
+		}
+
+		viewer := user.GetUserFromContext(r)
+		if viewer.ID != ret.UserID && viewer.ID != ret.SellerID && viewer.Role != user.RoleAdmin {
+			utils.NotFoundResponse(w, r, utils.ErrorNotFound)
+			return
+		}
+
+		ctx = context.WithValue(ctx, returnCtx, ret)
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// ReturnSellerMiddleware lets through the seller the return was sent to and
+// admins. It must be mounted after ReturnMiddleware.
+func (middleware *Handler) ReturnSellerMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		viewer := user.GetUserFromContext(r)
+		if GetReturnFromContext(r).SellerID != viewer.ID && viewer.Role != user.RoleAdmin {
+			utils.ForbiddenServerError(w, r)
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+func GetReturnFromContext(r *http.Request) *Return {
+	return r.Context().Value(returnCtx).(*Return)
+}
@@ -0,0 +1,105 @@
+package returns
+
+import (
+	"context"
+	"database/sql"
+)
+
+type ReturnStatus string
+
+const (
+	StatusRequested ReturnStatus = "requested"
+	StatusApproved  ReturnStatus = "approved"
+	StatusRejected  ReturnStatus = "rejected"
+	StatusReceived  ReturnStatus = "received"
+)
+
+type Return struct {
+	ID             string       `json:"id"`
+	OrderID        string       `json:"order_id"`
+	SellerOrderID  string       `json:"seller_order_id"`
+	SellerID       string       `json:"-"`
+	UserID         string       `json:"-"`
+	Status         ReturnStatus `json:"status"`
+	Reason         string       `json:"reason"`
+	ResolutionNote string       `json:"resolution_note"`
+	RefundAmount   int          `json:"refund_amount"`
+	Restocked      bool         `json:"restocked"`
+	Items          []ReturnItem `json:"items"`
+	Version        string       `json:"-"`
+	CreatedAt      string       `json:"created_at"`
+	UpdatedAt      string       `json:"-"`
+}
+
+type RefundStatus string
+
+const (
+	RefundPending RefundStatus = "pending"
+	RefundIssued  RefundStatus = "issued"
+)
+
+// RefundJob is the background job kind that pays out a pending refund.
+const RefundJob = "refund.issue"
+
+// Refund is money owed back to the customer for a return. It is recorded as
+// pending with the return and paid out through the gateway by RefundJob.
+type Refund struct {
+	ID                string
+	OrderID           string
+	ReturnID          string
+	Reason            string
+	Amount            int
+	Provider          string
+	ProviderReference string
+	Status            RefundStatus
+}
+
+type RefundPayload struct {
+	RefundID string `json:"refund_id"`
+}
+
+type ReturnItem struct {
+	OrderItemID string `json:"order_item_id"`
+	ProductName string `json:"product_name"`
+	Quantity    int    `json:"quantity"`
+}
+
+type ReturnStore interface {
+	// CreateReturn records the request, rejecting items whose quantity
+	// would exceed what was bought less what is already being returned.
+	CreateReturn(context.Context, *Return) error
+	GetReturnByID(context.Context, string) (*Return, error)
+	GetReturnsByOrderID(context.Context, string) ([]Return, error)
+	// UpdateReturn saves the return if it is still at r.Version, running
+	// hook in the same transaction.
+	UpdateReturn(context.Context, *Return, func(*sql.Tx) error) error
+	// RecordRefund adds the refund to the order and saves it as pending,
+	// failing if it would take the order past what was paid.
+	RecordRefund(context.Context, *sql.Tx, *Refund) error
+	GetRefundByID(context.Context, string) (*Refund, error)
+	// MarkRefundIssued records the provider's reference for a paid refund.
+	MarkRefundIssued(context.Context, *Refund) error
+	Restock(context.Context, *sql.Tx, *Return) error
+}
+
+type ReturnItemPayload struct {
+	OrderItemID string `json:"order_item_id" validate:"required,uuid"`
+	Quantity    int    `json:"quantity" validate:"required,gt=0"`
+}
+
+type ReturnPayload struct {
+	Reason string              `json:"reason" validate:"required,min=3,max=1000"`
+	Items  []ReturnItemPayload `json:"items" validate:"required,min=1,dive"`
+}
+
+// ResolvePayload approves or rejects a return. RefundAmount allows a partial
+// refund; by default the full amount paid for the items is refunded.
+type ResolvePayload struct {
+	Status       ReturnStatus `json:"status" validate:"required,oneof=approved rejected"`
+	Note         string       `json:"note" validate:"max=1000"`
+	RefundAmount *int         `json:"refund_amount" validate:"omitempty,gt=0"`
+}
+
+type ReceivePayload struct {
+	Restock bool `json:"restock"`
+}
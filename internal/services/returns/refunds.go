@@ -0,0 +1,48 @@
+package returns
+
+import (
+	"context"
+
+	"github.com/umeh-promise/ecommerce/internal/payments"
+	"github.com/umeh-promise/ecommerce/utils"
+)
+
+// IssueRefund returns the RefundJob handler. The return's ID is the
+// gateway's idempotency key, so a job retried after the payout went through
+// but before it was recorded doesn't pay the customer twice.
+func IssueRefund(store ReturnStore, gateway payments.Gateway) func(context.Context, RefundPayload) error {
+	return func(ctx context.Context, payload RefundPayload) error {
+		refund, err := store.GetRefundByID(ctx, payload.RefundID)
+		if err != nil {
+			switch err {
+			case utils.ErrorNotFound:
+				return nil
+			default:
+				return err
+			}
+		}
+
+		if refund.Status == RefundIssued {
+			return nil
+		}
+
+		key := refund.ReturnID
+		if key == "" {
+			key = refund.ID
+		}
+
+		reference, err := gateway.Refund(ctx, payments.Refund{
+			OrderID:        refund.OrderID,
+			Amount:         refund.Amount,
+			Reason:         refund.Reason,
+			IdempotencyKey: key,
+		})
+		if err != nil {
+			return err
+		}
+
+		refund.ProviderReference = reference
+
+		return store.MarkRefundIssued(context.WithoutCancel(ctx), refund)
+	}
+}
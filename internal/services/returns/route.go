@@ -0,0 +1,363 @@
+package returns
+
+import (
+	"database/sql"
+	"fmt"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/umeh-promise/ecommerce/internal/jobs"
+	"github.com/umeh-promise/ecommerce/internal/payments"
+	"github.com/umeh-promise/ecommerce/internal/services/ledger"
+	"github.com/umeh-promise/ecommerce/internal/services/orders"
+	"github.com/umeh-promise/ecommerce/internal/services/user"
+	"github.com/umeh-promise/ecommerce/utils"
+)
+
+type Handler struct {
+	store       ReturnStore
+	orderStore  orders.OrderStore
+	ledgerStore ledger.LedgerStore
+	gateway     payments.Gateway
+	jobs        jobs.Enqueuer
+}
+
+func NewHandler(store ReturnStore, orderStore orders.OrderStore, ledgerStore ledger.LedgerStore, gateway payments.Gateway, jobs jobs.Enqueuer) *Handler {
+	return &Handler{
+		store:       store,
+		orderStore:  orderStore,
+		ledgerStore: ledgerStore,
+		gateway:     gateway,
+		jobs:        jobs,
+	}
+}
+
+func (h *Handler) RegisterRoute(auth *user.Handler) func(r chi.Router) {
+	return func(r chi.Router) {
+		r.Route("/orders/{orderID}/returns", func(r chi.Router) {
+			r.Use(auth.AuthTokenMiddleware)
+			r.Get("/", h.getOrderReturns)
+			r.Post("/", h.createReturn)
+		})
+
+		r.Route("/returns/{returnID}", func(r chi.Router) {
+			r.Use(auth.AuthTokenMiddleware, h.ReturnMiddleware)
+			r.Get("/", h.getReturn)
+
+			r.Group(func(r chi.Router) {
+				r.Use(h.ReturnSellerMiddleware)
+				r.Put("/status", h.resolveReturn)
+				r.Post("/receive", h.receiveReturn)
+			})
+		})
+	}
+}
+
+// order loads the order in the URL, reporting it as missing to anyone who
+// neither placed it, sells in it, nor is an admin.
+func (h *Handler) order(w http.ResponseWriter, r *http.Request) (*orders.Order, bool) {
+	order, err := h.orderStore.GetOrderByID(r.Context(), chi.URLParam(r, "orderID"))
+	if err != nil {
+		switch err {
+		case utils.ErrorNotFound:
+			utils.NotFoundResponse(w, r, err)
+		default:
+			utils.InternalServerError(w, r, err)
+		}
+		return nil, false
+	}
+
+	viewer := user.GetUserFromContext(r)
+	if order.UserID != viewer.ID && viewer.Role != user.RoleAdmin && !sellsIn(order, viewer.ID) {
+		utils.NotFoundResponse(w, r, utils.ErrorNotFound)
+		return nil, false
+	}
+
+	return order, true
+}
+
+func sellsIn(order *orders.Order, sellerID string) bool {
+	for _, sellerOrder := range order.SellerOrders {
+		if sellerOrder.SellerID == sellerID {
+			return true
+		}
+	}
+	return false
+}
+
+func (h *Handler) getOrderReturns(w http.ResponseWriter, r *http.Request) {
+	order, ok := h.order(w, r)
+	if !ok {
+		return
+	}
+
+	returns, err := h.store.GetReturnsByOrderID(r.Context(), order.ID)
+	if err != nil {
+		utils.InternalServerError(w, r, err)
+		return
+	}
+
+	// Sellers only see returns sent to them.
+	viewer := user.GetUserFromContext(r)
+	if order.UserID != viewer.ID && viewer.Role != user.RoleAdmin {
+		var own []Return
+		for _, ret := range returns {
+			if ret.SellerID == viewer.ID {
+				own = append(own, ret)
+			}
+		}
+		returns = own
+	}
+
+	if err := utils.JSONResponse(w, http.StatusOK, returns); err != nil {
+		utils.InternalServerError(w, r, err)
+		return
+	}
+}
+
+func (h *Handler) createReturn(w http.ResponseWriter, r *http.Request) {
+	var payload ReturnPayload
+
+	if err := utils.ParseJSON(w, r, &payload); err != nil {
+		utils.BadRequestError(w, r, err)
+		return
+	}
+
+	if err := utils.Validator.Struct(payload); err != nil {
+		utils.BadRequestError(w, r, err)
+		return
+	}
+
+	order, ok := h.order(w, r)
+	if !ok {
+		return
+	}
+
+	if order.UserID != user.GetUserFromContext(r).ID {
+		utils.ForbiddenServerError(w, r)
+		return
+	}
+
+	if order.Status != orders.StatusPaid {
+		utils.BadRequestError(w, r, fmt.Errorf("only paid orders can be returned"))
+		return
+	}
+
+	ret := &Return{
+		OrderID: order.ID,
+		UserID:  order.UserID,
+		Reason:  payload.Reason,
+	}
+
+	// The first item decides which seller the return goes to; the store
+	// rejects items sold by anyone else.
+	for _, item := range order.Items {
+		if item.ID == payload.Items[0].OrderItemID {
+			ret.SellerOrderID = item.SellerOrderID
+		}
+	}
+
+	if ret.SellerOrderID == "" {
+		utils.BadRequestError(w, r, fmt.Errorf("order items must belong to this order"))
+		return
+	}
+
+	seen := map[string]bool{}
+	for _, item := range payload.Items {
+		if seen[item.OrderItemID] {
+			utils.BadRequestError(w, r, fmt.Errorf("order item (%s) is listed more than once", item.OrderItemID))
+			return
+		}
+		seen[item.OrderItemID] = true
+
+		ret.Items = append(ret.Items, ReturnItem{
+			OrderItemID: item.OrderItemID,
+			Quantity:    item.Quantity,
+		})
+	}
+
+	if err := h.store.CreateReturn(r.Context(), ret); err != nil {
+		switch err {
+		case utils.ErrorNotFound:
+			utils.BadRequestError(w, r, fmt.Errorf("every item must come from the same seller in this order"))
+		case utils.ErrorOverReturn:
+			utils.BadRequestError(w, r, err)
+		default:
+			utils.InternalServerError(w, r, err)
+		}
+		return
+	}
+
+	if err := utils.JSONResponse(w, http.StatusCreated, ret); err != nil {
+		utils.InternalServerError(w, r, err)
+		return
+	}
+}
+
+func (h *Handler) getReturn(w http.ResponseWriter, r *http.Request) {
+	ret := GetReturnFromContext(r)
+
+	if err := utils.JSONResponse(w, http.StatusOK, ret); err != nil {
+		utils.InternalServerError(w, r, err)
+		return
+	}
+}
+
+// refundable works out what the customer paid for the returned items and
+// how much of that came out of the seller's balance (the rest is tax).
+func refundable(order *orders.Order, ret *Return) (total int, goods int) {
+	paid := order.PaidAmounts()
+
+	for _, returned := range ret.Items {
+		for i, item := range order.Items {
+			if item.ID != returned.OrderItemID {
+				continue
+			}
+
+			amount := paid[i] * returned.Quantity / item.Quantity
+			total += amount
+
+			tax := 0
+			if !item.TaxInclusive {
+				tax = item.TaxAmount * returned.Quantity / item.Quantity
+			}
+			goods += amount - tax
+		}
+	}
+
+	return total, goods
+}
+
+func (h *Handler) resolveReturn(w http.ResponseWriter, r *http.Request) {
+	var payload ResolvePayload
+
+	if err := utils.ParseJSON(w, r, &payload); err != nil {
+		utils.BadRequestError(w, r, err)
+		return
+	}
+
+	if err := utils.Validator.Struct(payload); err != nil {
+		utils.BadRequestError(w, r, err)
+		return
+	}
+
+	ret := GetReturnFromContext(r)
+	ctx := r.Context()
+
+	if ret.Status != StatusRequested {
+		utils.BadRequestError(w, r, fmt.Errorf("return is already %s", ret.Status))
+		return
+	}
+
+	ret.Status = payload.Status
+	ret.ResolutionNote = payload.Note
+
+	var refund func(*sql.Tx) error
+
+	if ret.Status == StatusApproved {
+		order, err := h.orderStore.GetOrderByID(ctx, ret.OrderID)
+		if err != nil {
+			utils.InternalServerError(w, r, err)
+			return
+		}
+
+		total, goods := refundable(order, ret)
+		ret.RefundAmount = total
+
+		if payload.RefundAmount != nil {
+			if *payload.RefundAmount > total {
+				utils.BadRequestError(w, r, fmt.Errorf("refund cannot exceed %d", total))
+				return
+			}
+			ret.RefundAmount = *payload.RefundAmount
+		}
+
+		refund = func(tx *sql.Tx) error {
+			if ret.RefundAmount == 0 {
+				return nil
+			}
+
+			// The seller gives back what they were credited for the goods;
+			// refunded tax comes out of the platform's clearing account.
+			if charge := min(ret.RefundAmount, goods); charge > 0 {
+				err := h.ledgerStore.PostRefund(ctx, tx, ret.SellerOrderID, ret.SellerID, charge)
+				if err != nil {
+					return err
+				}
+			}
+
+			// The payout happens after commit, in RefundJob, so a rollback
+			// here never leaves the customer paid with nothing recorded.
+			refund := &Refund{
+				OrderID:  ret.OrderID,
+				ReturnID: ret.ID,
+				Amount:   ret.RefundAmount,
+				Provider: h.gateway.Name(),
+			}
+			if err := h.store.RecordRefund(ctx, tx, refund); err != nil {
+				return err
+			}
+
+			return h.jobs.EnqueueTx(ctx, tx, RefundJob, RefundPayload{RefundID: refund.ID})
+		}
+	}
+
+	if err := h.store.UpdateReturn(ctx, ret, refund); err != nil {
+		switch err {
+		case utils.ErrorNotFound:
+			utils.NotFoundResponse(w, r, err)
+		case utils.ErrorRefundExceedsTotal:
+			utils.BadRequestError(w, r, err)
+		default:
+			utils.InternalServerError(w, r, err)
+		}
+		return
+	}
+
+	if err := utils.JSONResponse(w, http.StatusOK, ret); err != nil {
+		utils.InternalServerError(w, r, err)
+		return
+	}
+}
+
+func (h *Handler) receiveReturn(w http.ResponseWriter, r *http.Request) {
+	var payload ReceivePayload
+
+	if err := utils.ParseJSON(w, r, &payload); err != nil {
+		utils.BadRequestError(w, r, err)
+		return
+	}
+
+	ret := GetReturnFromContext(r)
+	ctx := r.Context()
+
+	if ret.Status != StatusApproved {
+		utils.BadRequestError(w, r, fmt.Errorf("only approved returns can be received"))
+		return
+	}
+
+	ret.Status = StatusReceived
+	ret.Restocked = payload.Restock
+
+	var restock func(*sql.Tx) error
+	if ret.Restocked {
+		restock = func(tx *sql.Tx) error {
+			return h.store.Restock(ctx, tx, ret)
+		}
+	}
+
+	if err := h.store.UpdateReturn(ctx, ret, restock); err != nil {
+		switch err {
+		case utils.ErrorNotFound:
+			utils.NotFoundResponse(w, r, err)
+		default:
+			utils.InternalServerError(w, r, err)
+		}
+		return
+	}
+
+	if err := utils.JSONResponse(w, http.StatusOK, ret); err != nil {
+		utils.InternalServerError(w, r, err)
+		return
+	}
+}
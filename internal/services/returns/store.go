@@ -0,0 +1,335 @@
+package returns
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	uuid "github.com/satori/go.uuid"
+	"github.com/umeh-promise/ecommerce/utils"
+)
+
+const returnColumns = `rt.id, rt.order_id, rt.seller_order_id, so.seller_user_id, rt.user_id, rt.status, rt.reason,
+	rt.resolution_note, rt.refund_amount, rt.restocked, rt.version, rt.created_at, rt.updated_at`
+
+const returnSource = `returns rt JOIN seller_orders so ON so.id = rt.seller_order_id`
+
+type scanner interface {
+	Scan(dest ...any) error
+}
+
+func scanReturn(row scanner, r *Return) error {
+	return row.Scan(
+		&r.ID,
+		&r.OrderID,
+		&r.SellerOrderID,
+		&r.SellerID,
+		&r.UserID,
+		&r.Status,
+		&r.Reason,
+		&r.ResolutionNote,
+		&r.RefundAmount,
+		&r.Restocked,
+		&r.Version,
+		&r.CreatedAt,
+		&r.UpdatedAt,
+	)
+}
+
+type Store struct {
+	db *sql.DB
+}
+
+func NewStore(db *sql.DB) *Store {
+	return &Store{db: db}
+}
+
+func (s *Store) CreateReturn(ctx context.Context, r *Return) error {
+	r.ID = uuid.NewV4().String()
+	r.Status = StatusRequested
+
+	ctx, cancel := context.WithTimeout(ctx, utils.QueryTimeout)
+	defer cancel()
+
+	return utils.WithTx(ctx, s.db, func(tx *sql.Tx) error {
+		err := tx.QueryRowContext(ctx, `
+			INSERT INTO returns (id, order_id, seller_order_id, user_id, status, reason)
+			VALUES ($1, $2, $3, $4, $5, $6)
+			RETURNING version, created_at, updated_at
+		`, r.ID, r.OrderID, r.SellerOrderID, r.UserID, r.Status, r.Reason).Scan(&r.Version, &r.CreatedAt, &r.UpdatedAt)
+		if err != nil {
+			return err
+		}
+
+		for i := range r.Items {
+			item := &r.Items[i]
+
+			// Lock the order item so two requests can't both return the
+			// last unit.
+			var bought, returned int
+			err := tx.QueryRowContext(ctx, `
+				SELECT product_name, quantity FROM order_items
+				WHERE id = $1 AND seller_order_id = $2
+				FOR UPDATE
+			`, item.OrderItemID, r.SellerOrderID).Scan(&item.ProductName, &bought)
+			if err != nil {
+				switch {
+				case errors.Is(err, sql.ErrNoRows):
+					return utils.ErrorNotFound
+				default:
+					return err
+				}
+			}
+
+			err = tx.QueryRowContext(ctx, `
+				SELECT COALESCE(sum(ri.quantity), 0)
+				FROM return_items ri
+				JOIN returns rt ON rt.id = ri.return_id
+				WHERE ri.order_item_id = $1 AND rt.status <> 'rejected'
+			`, item.OrderItemID).Scan(&returned)
+			if err != nil {
+				return err
+			}
+
+			if item.Quantity > bought-returned {
+				return utils.ErrorOverReturn
+			}
+
+			_, err = tx.ExecContext(ctx, `
+				INSERT INTO return_items (return_id, order_item_id, quantity)
+				VALUES ($1, $2, $3)
+			`, r.ID, item.OrderItemID, item.Quantity)
+			if err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
+
+func (s *Store) GetReturnByID(ctx context.Context, id string) (*Return, error) {
+	var r Return
+
+	query := `SELECT ` + returnColumns + ` FROM ` + returnSource + ` WHERE rt.id = $1`
+
+	ctx, cancel := context.WithTimeout(ctx, utils.QueryTimeout)
+	defer cancel()
+
+	if err := scanReturn(s.db.QueryRowContext(ctx, query, id), &r); err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return nil, utils.ErrorNotFound
+		default:
+			return nil, err
+		}
+	}
+
+	items, err := s.getItems(ctx, r.ID)
+	if err != nil {
+		return nil, err
+	}
+	r.Items = items
+
+	return &r, nil
+}
+
+func (s *Store) GetReturnsByOrderID(ctx context.Context, orderID string) ([]Return, error) {
+	query := `SELECT ` + returnColumns + ` FROM ` + returnSource + `
+		WHERE rt.order_id = $1
+		ORDER BY rt.created_at DESC`
+
+	ctx, cancel := context.WithTimeout(ctx, utils.QueryTimeout)
+	defer cancel()
+
+	rows, err := s.db.QueryContext(ctx, query, orderID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var returns []Return
+
+	for rows.Next() {
+		r := Return{}
+		if err := scanReturn(rows, &r); err != nil {
+			return nil, err
+		}
+
+		returns = append(returns, r)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	for i := range returns {
+		items, err := s.getItems(ctx, returns[i].ID)
+		if err != nil {
+			return nil, err
+		}
+		returns[i].Items = items
+	}
+
+	return returns, nil
+}
+
+func (s *Store) getItems(ctx context.Context, returnID string) ([]ReturnItem, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT ri.order_item_id, oi.product_name, ri.quantity
+		FROM return_items ri
+		JOIN order_items oi ON oi.id = ri.order_item_id
+		WHERE ri.return_id = $1
+		ORDER BY oi.product_name
+	`, returnID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var items []ReturnItem
+
+	for rows.Next() {
+		item := ReturnItem{}
+		if err := rows.Scan(&item.OrderItemID, &item.ProductName, &item.Quantity); err != nil {
+			return nil, err
+		}
+
+		items = append(items, item)
+	}
+
+	return items, rows.Err()
+}
+
+func (s *Store) UpdateReturn(ctx context.Context, r *Return, hook func(*sql.Tx) error) error {
+	query := `
+		UPDATE returns
+		SET status = $1, resolution_note = $2, refund_amount = $3, restocked = $4,
+			version = version + 1, updated_at = now()
+		WHERE id = $5 AND version = $6
+		RETURNING version
+	`
+
+	ctx, cancel := context.WithTimeout(ctx, utils.QueryTimeout)
+	defer cancel()
+
+	return utils.WithTx(ctx, s.db, func(tx *sql.Tx) error {
+		err := tx.QueryRowContext(ctx, query,
+			r.Status, r.ResolutionNote, r.RefundAmount, r.Restocked, r.ID, r.Version,
+		).Scan(&r.Version)
+		if err != nil {
+			switch {
+			case errors.Is(err, sql.ErrNoRows):
+				return utils.ErrorNotFound
+			default:
+				return err
+			}
+		}
+
+		if hook != nil {
+			return hook(tx)
+		}
+
+		return nil
+	})
+}
+
+func (s *Store) RecordRefund(ctx context.Context, tx *sql.Tx, refund *Refund) error {
+	result, err := tx.ExecContext(ctx, `
+		UPDATE orders
+		SET refunded_total = refunded_total + $1, updated_at = now()
+		WHERE id = $2 AND refunded_total + $1 <= total
+	`, refund.Amount, refund.OrderID)
+	if err != nil {
+		return err
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rows == 0 {
+		return utils.ErrorRefundExceedsTotal
+	}
+
+	refund.ID = uuid.NewV4().String()
+	refund.Status = RefundPending
+
+	_, err = tx.ExecContext(ctx, `
+		INSERT INTO refunds (id, order_id, return_id, amount, provider, status)
+		VALUES ($1, $2, $3, $4, $5, $6)
+	`, refund.ID, refund.OrderID, refund.ReturnID, refund.Amount, refund.Provider, refund.Status)
+	return err
+}
+
+func (s *Store) GetRefundByID(ctx context.Context, id string) (*Refund, error) {
+	query := `
+		SELECT f.id, f.order_id, COALESCE(f.return_id::text, ''), COALESCE(r.reason, ''), f.amount,
+			f.provider, f.provider_reference, f.status
+		FROM refunds f
+		LEFT JOIN returns r ON r.id = f.return_id
+		WHERE f.id = $1
+	`
+
+	ctx, cancel := context.WithTimeout(ctx, utils.QueryTimeout)
+	defer cancel()
+
+	refund := &Refund{}
+
+	err := s.db.QueryRowContext(ctx, query, id).Scan(
+		&refund.ID,
+		&refund.OrderID,
+		&refund.ReturnID,
+		&refund.Reason,
+		&refund.Amount,
+		&refund.Provider,
+		&refund.ProviderReference,
+		&refund.Status,
+	)
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return nil, utils.ErrorNotFound
+		default:
+			return nil, err
+		}
+	}
+
+	return refund, nil
+}
+
+func (s *Store) MarkRefundIssued(ctx context.Context, refund *Refund) error {
+	query := `
+		UPDATE refunds
+		SET status = 'issued', provider_reference = $1, issued_at = now()
+		WHERE id = $2 AND status = 'pending'
+	`
+
+	ctx, cancel := context.WithTimeout(ctx, utils.QueryTimeout)
+	defer cancel()
+
+	if _, err := s.db.ExecContext(ctx, query, refund.ProviderReference, refund.ID); err != nil {
+		return err
+	}
+
+	refund.Status = RefundIssued
+
+	return nil
+}
+
+// Restock puts the returned quantities back into tracked product stock.
+func (s *Store) Restock(ctx context.Context, tx *sql.Tx, r *Return) error {
+	_, err := tx.ExecContext(ctx, `
+		UPDATE products p
+		SET stock = p.stock + ri.quantity, version = p.version + 1, updated_at = now()
+		FROM (
+			SELECT oi.product_id, sum(ri.quantity) AS quantity
+			FROM return_items ri
+			JOIN order_items oi ON oi.id = ri.order_item_id
+			WHERE ri.return_id = $1
+			GROUP BY oi.product_id
+		) ri
+		WHERE p.id = ri.product_id AND p.stock IS NOT NULL
+	`, r.ID)
+	return err
+}
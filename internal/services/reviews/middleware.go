@@ -0,0 +1,53 @@
+package reviews
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/umeh-promise/ecommerce/internal/services/user"
+	"github.com/umeh-promise/ecommerce/utils"
+)
+
+type reviewKey string
+
+var reviewCtx reviewKey = "review"
+
+func (middleware *Handler) ReviewMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		reviewID := chi.URLParam(r, "reviewID")
+		ctx := r.Context()
+
+		review, err := middleware.store.GetReviewByID(ctx, reviewID)
+		if err != nil {
+			switch err {
+			case utils.ErrorNotFound:
+				utils.NotFoundResponse(w, r, err)
+			default:
+				utils.InternalServerError(w, r, err)
+			}
+			return
+		}
+
+		ctx = context.WithValue(ctx, reviewCtx, review)
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// ReviewAuthorMiddleware lets through the review's author and admins. It must
+// be mounted after ReviewMiddleware and AuthTokenMiddleware.
+func (middleware *Handler) ReviewAuthorMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		viewer := user.GetUserFromContext(r)
+		if GetReviewFromContext(r).UserID != viewer.ID && viewer.Role != user.RoleAdmin {
+			utils.ForbiddenServerError(w, r)
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+func GetReviewFromContext(r *http.Request) *Review {
+	return r.Context().Value(reviewCtx).(*Review)
+}
@@ -0,0 +1,50 @@
+package reviews
+
+import "context"
+
+type ReviewStatus string
+
+const (
+	StatusPending  ReviewStatus = "pending"
+	StatusApproved ReviewStatus = "approved"
+	StatusRejected ReviewStatus = "rejected"
+)
+
+type Review struct {
+	ID               string       `json:"id"`
+	ProductID        string       `json:"product_id"`
+	UserID           string       `json:"user_id"`
+	Author           string       `json:"author"`
+	Rating           int          `json:"rating"`
+	Title            string       `json:"title"`
+	Body             string       `json:"body"`
+	Status           ReviewStatus `json:"status"`
+	VerifiedPurchase bool         `json:"verified_purchase"`
+	Version          string       `json:"-"`
+	CreatedAt        string       `json:"created_at"`
+	UpdatedAt        string       `json:"updated_at"`
+}
+
+type ReviewStore interface {
+	CreateReview(context.Context, *Review) error
+	GetReviewByID(context.Context, string) (*Review, error)
+	GetProductReviews(context.Context, string) ([]Review, error)
+	UpdateReview(context.Context, *Review) error
+	DeleteReview(context.Context, *Review) error
+}
+
+type ReviewPayload struct {
+	Rating int    `json:"rating" validate:"required,min=1,max=5"`
+	Title  string `json:"title" validate:"omitempty,max=255"`
+	Body   string `json:"body" validate:"omitempty,max=5000"`
+}
+
+type UpdateReviewPayload struct {
+	Rating *int    `json:"rating" validate:"omitempty,min=1,max=5"`
+	Title  *string `json:"title" validate:"omitempty,max=255"`
+	Body   *string `json:"body" validate:"omitempty,max=5000"`
+}
+
+type ModerateReviewPayload struct {
+	Status ReviewStatus `json:"status" validate:"required,oneof=pending approved rejected"`
+}
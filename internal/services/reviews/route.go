@@ -0,0 +1,197 @@
+package reviews
+
+import (
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/umeh-promise/ecommerce/internal/services/products"
+	"github.com/umeh-promise/ecommerce/internal/services/user"
+	"github.com/umeh-promise/ecommerce/utils"
+)
+
+type Handler struct {
+	store        ReviewStore
+	productStore products.ProductStore
+}
+
+func NewHandler(store ReviewStore, productStore products.ProductStore) *Handler {
+	return &Handler{store: store, productStore: productStore}
+}
+
+func (h *Handler) RegisterRoute(auth *user.Handler) func(r chi.Router) {
+	return func(r chi.Router) {
+		r.Route("/products/{productID}/reviews", func(r chi.Router) {
+			r.Get("/", h.getProductReviews)
+			r.With(auth.AuthTokenMiddleware).Post("/", h.createReview)
+		})
+
+		r.Route("/reviews/{reviewID}", func(r chi.Router) {
+			r.Use(auth.AuthTokenMiddleware, h.ReviewMiddleware)
+			r.With(h.ReviewAuthorMiddleware).Put("/", h.updateReview)
+			r.With(h.ReviewAuthorMiddleware).Delete("/", h.deleteReview)
+			r.With(auth.AdminMiddleware).Put("/status", h.moderateReview)
+		})
+	}
+}
+
+// publishedProduct loads the product in the URL, treating anything that is
+// not on sale as missing.
+func (h *Handler) publishedProduct(w http.ResponseWriter, r *http.Request) (*products.Product, bool) {
+	product, err := h.productStore.GetPostByID(r.Context(), chi.URLParam(r, "productID"))
+	if err != nil {
+		switch err {
+		case utils.ErrorNotFound:
+			utils.NotFoundResponse(w, r, err)
+		default:
+			utils.InternalServerError(w, r, err)
+		}
+		return nil, false
+	}
+
+	if product.Status != products.StatusPublished {
+		utils.NotFoundResponse(w, r, utils.ErrorNotFound)
+		return nil, false
+	}
+
+	return product, true
+}
+
+func (h *Handler) getProductReviews(w http.ResponseWriter, r *http.Request) {
+	product, ok := h.publishedProduct(w, r)
+	if !ok {
+		return
+	}
+
+	reviews, err := h.store.GetProductReviews(r.Context(), product.ID)
+	if err != nil {
+		utils.InternalServerError(w, r, err)
+		return
+	}
+
+	if err := utils.JSONResponse(w, http.StatusOK, reviews); err != nil {
+		utils.InternalServerError(w, r, err)
+		return
+	}
+}
+
+func (h *Handler) createReview(w http.ResponseWriter, r *http.Request) {
+	var payload ReviewPayload
+
+	if err := utils.ParseJSON(w, r, &payload); err != nil {
+		utils.BadRequestError(w, r, err)
+		return
+	}
+
+	if err := utils.Validator.Struct(payload); err != nil {
+		utils.BadRequestError(w, r, err)
+		return
+	}
+
+	product, ok := h.publishedProduct(w, r)
+	if !ok {
+		return
+	}
+
+	user := user.GetUserFromContext(r)
+
+	review := &Review{
+		ProductID: product.ID,
+		UserID:    user.ID,
+		Author:    user.FirstName,
+		Rating:    payload.Rating,
+		Title:     payload.Title,
+		Body:      payload.Body,
+	}
+
+	if err := h.store.CreateReview(r.Context(), review); err != nil {
+		switch err {
+		case utils.ErrorDuplicateReview:
+			utils.BadRequestError(w, r, err)
+		default:
+			utils.InternalServerError(w, r, err)
+		}
+		return
+	}
+
+	if err := utils.JSONResponse(w, http.StatusCreated, review); err != nil {
+		utils.InternalServerError(w, r, err)
+		return
+	}
+}
+
+func (h *Handler) updateReview(w http.ResponseWriter, r *http.Request) {
+	var payload UpdateReviewPayload
+
+	if err := utils.ParseJSON(w, r, &payload); err != nil {
+		utils.BadRequestError(w, r, err)
+		return
+	}
+
+	if err := utils.Validator.Struct(payload); err != nil {
+		utils.BadRequestError(w, r, err)
+		return
+	}
+
+	review := GetReviewFromContext(r)
+
+	utils.AssignIfNotNil(&review.Rating, payload.Rating)
+	utils.AssignIfNotNil(&review.Title, payload.Title)
+	utils.AssignIfNotNil(&review.Body, payload.Body)
+
+	h.saveReview(w, r, review)
+}
+
+func (h *Handler) moderateReview(w http.ResponseWriter, r *http.Request) {
+	var payload ModerateReviewPayload
+
+	if err := utils.ParseJSON(w, r, &payload); err != nil {
+		utils.BadRequestError(w, r, err)
+		return
+	}
+
+	if err := utils.Validator.Struct(payload); err != nil {
+		utils.BadRequestError(w, r, err)
+		return
+	}
+
+	review := GetReviewFromContext(r)
+	review.Status = payload.Status
+
+	h.saveReview(w, r, review)
+}
+
+func (h *Handler) saveReview(w http.ResponseWriter, r *http.Request, review *Review) {
+	if err := h.store.UpdateReview(r.Context(), review); err != nil {
+		switch err {
+		case utils.ErrorNotFound:
+			utils.NotFoundResponse(w, r, err)
+		default:
+			utils.InternalServerError(w, r, err)
+		}
+		return
+	}
+
+	if err := utils.JSONResponse(w, http.StatusOK, review); err != nil {
+		utils.InternalServerError(w, r, err)
+		return
+	}
+}
+
+func (h *Handler) deleteReview(w http.ResponseWriter, r *http.Request) {
+	review := GetReviewFromContext(r)
+
+	if err := h.store.DeleteReview(r.Context(), review); err != nil {
+		switch err {
+		case utils.ErrorNotFound:
+			utils.NotFoundResponse(w, r, err)
+		default:
+			utils.InternalServerError(w, r, err)
+		}
+		return
+	}
+
+	if err := utils.JSONResponse(w, http.StatusNoContent, nil); err != nil {
+		utils.InternalServerError(w, r, err)
+		return
+	}
+}
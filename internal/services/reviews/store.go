@@ -0,0 +1,210 @@
+package reviews
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	uuid "github.com/satori/go.uuid"
+	"github.com/umeh-promise/ecommerce/utils"
+)
+
+const reviewColumns = `r.id, r.product_id, r.user_id, u.first_name, r.rating, r.title, r.body, r.status,
+	r.verified_purchase, r.version, r.created_at, r.updated_at`
+
+type scanner interface {
+	Scan(dest ...any) error
+}
+
+func scanReview(row scanner, review *Review) error {
+	return row.Scan(
+		&review.ID,
+		&review.ProductID,
+		&review.UserID,
+		&review.Author,
+		&review.Rating,
+		&review.Title,
+		&review.Body,
+		&review.Status,
+		&review.VerifiedPurchase,
+		&review.Version,
+		&review.CreatedAt,
+		&review.UpdatedAt,
+	)
+}
+
+// refreshRating recomputes the product's aggregate from approved reviews.
+// The product row is locked first, so concurrent review writes take turns
+// and each aggregate is read after the previous writer has committed.
+func refreshRating(ctx context.Context, tx *sql.Tx, productID string) error {
+	if _, err := tx.ExecContext(ctx, `SELECT 1 FROM products WHERE id = $1 FOR UPDATE`, productID); err != nil {
+		return err
+	}
+
+	_, err := tx.ExecContext(ctx, `
+		UPDATE products SET
+			rating_count = stats.count,
+			rating_average = stats.average
+		FROM (
+			SELECT count(*) AS count, COALESCE(round(avg(rating), 2), 0) AS average
+			FROM reviews
+			WHERE product_id = $1 AND status = 'approved'
+		) AS stats
+		WHERE id = $1
+	`, productID)
+
+	return err
+}
+
+type Store struct {
+	db *sql.DB
+}
+
+func NewStore(db *sql.DB) *Store {
+	return &Store{db: db}
+}
+
+func (s *Store) CreateReview(ctx context.Context, review *Review) error {
+	// A purchase counts once the order has gone past checkout.
+	query := `
+		INSERT INTO reviews (id, product_id, user_id, rating, title, body, verified_purchase)
+		VALUES ($1, $2, $3, $4, $5, $6, EXISTS (
+			SELECT 1 FROM order_items oi
+			JOIN orders o ON o.id = oi.order_id
+			WHERE o.user_id = $3 AND oi.product_id = $2 AND o.status NOT IN ('pending', 'cancelled')
+		))
+		RETURNING status, verified_purchase, version, created_at, updated_at
+	`
+
+	review.ID = uuid.NewV4().String()
+
+	ctx, cancel := context.WithTimeout(ctx, utils.QueryTimeout)
+	defer cancel()
+
+	return utils.WithTx(ctx, s.db, func(tx *sql.Tx) error {
+		err := tx.QueryRowContext(ctx, query,
+			review.ID, review.ProductID, review.UserID, review.Rating, review.Title, review.Body,
+		).Scan(
+			&review.Status,
+			&review.VerifiedPurchase,
+			&review.Version,
+			&review.CreatedAt,
+			&review.UpdatedAt,
+		)
+		if err != nil {
+			switch {
+			case err.Error() == `pq: duplicate key value violates unique constraint "reviews_product_user_key"`:
+				return utils.ErrorDuplicateReview
+			default:
+				return err
+			}
+		}
+
+		return refreshRating(ctx, tx, review.ProductID)
+	})
+}
+
+func (s *Store) GetReviewByID(ctx context.Context, id string) (*Review, error) {
+	var review Review
+
+	query := `SELECT ` + reviewColumns + `
+		FROM reviews r
+		JOIN users u ON u.id = r.user_id
+		WHERE r.id = $1`
+
+	ctx, cancel := context.WithTimeout(ctx, utils.QueryTimeout)
+	defer cancel()
+
+	err := scanReview(s.db.QueryRowContext(ctx, query, id), &review)
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return nil, utils.ErrorNotFound
+		default:
+			return nil, err
+		}
+	}
+
+	return &review, nil
+}
+
+func (s *Store) GetProductReviews(ctx context.Context, productID string) ([]Review, error) {
+	query := `SELECT ` + reviewColumns + `
+		FROM reviews r
+		JOIN users u ON u.id = r.user_id
+		WHERE r.product_id = $1 AND r.status = 'approved'
+		ORDER BY r.verified_purchase DESC, r.created_at DESC`
+
+	ctx, cancel := context.WithTimeout(ctx, utils.QueryTimeout)
+	defer cancel()
+
+	rows, err := s.db.QueryContext(ctx, query, productID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var reviews []Review
+
+	for rows.Next() {
+		review := Review{}
+		if err := scanReview(rows, &review); err != nil {
+			return nil, err
+		}
+
+		reviews = append(reviews, review)
+	}
+
+	return reviews, rows.Err()
+}
+
+func (s *Store) UpdateReview(ctx context.Context, review *Review) error {
+	query := `
+		UPDATE reviews
+		SET rating = $1, title = $2, body = $3, status = $4, version = version + 1, updated_at = now()
+		WHERE id = $5 AND version = $6
+		RETURNING version, updated_at
+	`
+
+	ctx, cancel := context.WithTimeout(ctx, utils.QueryTimeout)
+	defer cancel()
+
+	return utils.WithTx(ctx, s.db, func(tx *sql.Tx) error {
+		err := tx.QueryRowContext(ctx, query,
+			review.Rating, review.Title, review.Body, review.Status, review.ID, review.Version,
+		).Scan(&review.Version, &review.UpdatedAt)
+		if err != nil {
+			switch err {
+			case sql.ErrNoRows:
+				return utils.ErrorNotFound
+			default:
+				return err
+			}
+		}
+
+		return refreshRating(ctx, tx, review.ProductID)
+	})
+}
+
+func (s *Store) DeleteReview(ctx context.Context, review *Review) error {
+	ctx, cancel := context.WithTimeout(ctx, utils.QueryTimeout)
+	defer cancel()
+
+	return utils.WithTx(ctx, s.db, func(tx *sql.Tx) error {
+		result, err := tx.ExecContext(ctx, `DELETE FROM reviews WHERE id = $1`, review.ID)
+		if err != nil {
+			return err
+		}
+
+		rows, err := result.RowsAffected()
+		if err != nil {
+			return err
+		}
+
+		if rows == 0 {
+			return utils.ErrorNotFound
+		}
+
+		return refreshRating(ctx, tx, review.ProductID)
+	})
+}
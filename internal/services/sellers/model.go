@@ -0,0 +1,42 @@
+package sellers
+
+import "context"
+
+type Seller struct {
+	ID             string `json:"id"`
+	UserID         string `json:"-"`
+	DisplayName    string `json:"display_name"`
+	Slug           string `json:"slug"`
+	Description    string `json:"description"`
+	Logo           string `json:"logo"`
+	ShippingPolicy string `json:"shipping_policy"`
+	ReturnPolicy   string `json:"return_policy"`
+	Version        string `json:"-"`
+	CreatedAt      string `json:"created_at"`
+	UpdatedAt      string `json:"-"`
+}
+
+type SellerStore interface {
+	CreateSeller(context.Context, *Seller) error
+	GetSellerBySlug(context.Context, string) (*Seller, error)
+	GetSellerByUserID(context.Context, string) (*Seller, error)
+	UpdateSeller(context.Context, *Seller) error
+}
+
+type SellerPayload struct {
+	DisplayName    string `json:"display_name" validate:"required,min=2,max=100"`
+	Slug           string `json:"slug" validate:"required,min=3,max=60,slug"`
+	Description    string `json:"description" validate:"omitempty,max=5000"`
+	Logo           string `json:"logo" validate:"omitempty,url,max=255"`
+	ShippingPolicy string `json:"shipping_policy" validate:"omitempty,max=5000"`
+	ReturnPolicy   string `json:"return_policy" validate:"omitempty,max=5000"`
+}
+
+type UpdateSellerPayload struct {
+	DisplayName    *string `json:"display_name" validate:"omitempty,min=2,max=100"`
+	Slug           *string `json:"slug" validate:"omitempty,min=3,max=60,slug"`
+	Description    *string `json:"description" validate:"omitempty,max=5000"`
+	Logo           *string `json:"logo" validate:"omitempty,url,max=255"`
+	ShippingPolicy *string `json:"shipping_policy" validate:"omitempty,max=5000"`
+	ReturnPolicy   *string `json:"return_policy" validate:"omitempty,max=5000"`
+}
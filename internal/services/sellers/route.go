@@ -0,0 +1,186 @@
+package sellers
+
+import (
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/umeh-promise/ecommerce/internal/services/products"
+	"github.com/umeh-promise/ecommerce/internal/services/user"
+	"github.com/umeh-promise/ecommerce/utils"
+)
+
+type Handler struct {
+	store        SellerStore
+	productStore products.ProductStore
+}
+
+func NewHandler(store SellerStore, productStore products.ProductStore) *Handler {
+	return &Handler{store: store, productStore: productStore}
+}
+
+func (h *Handler) RegisterRoute(auth *user.Handler) func(r chi.Router) {
+	return func(r chi.Router) {
+		r.Route("/sellers/{slug}", func(r chi.Router) {
+			r.Get("/", h.getSeller)
+			r.Get("/products", h.getSellerProducts)
+		})
+
+		r.Route("/seller/profile", func(r chi.Router) {
+			r.Use(auth.AuthTokenMiddleware)
+			r.Get("/", h.getMySeller)
+			r.Post("/", h.createSeller)
+			r.Put("/", h.updateSeller)
+		})
+	}
+}
+
+func (h *Handler) sellerBySlug(w http.ResponseWriter, r *http.Request) (*Seller, bool) {
+	seller, err := h.store.GetSellerBySlug(r.Context(), chi.URLParam(r, "slug"))
+	if err != nil {
+		switch err {
+		case utils.ErrorNotFound:
+			utils.NotFoundResponse(w, r, err)
+		default:
+			utils.InternalServerError(w, r, err)
+		}
+		return nil, false
+	}
+
+	return seller, true
+}
+
+func (h *Handler) getSeller(w http.ResponseWriter, r *http.Request) {
+	seller, ok := h.sellerBySlug(w, r)
+	if !ok {
+		return
+	}
+
+	if err := utils.JSONResponse(w, http.StatusOK, seller); err != nil {
+		utils.InternalServerError(w, r, err)
+		return
+	}
+}
+
+func (h *Handler) getSellerProducts(w http.ResponseWriter, r *http.Request) {
+	seller, ok := h.sellerBySlug(w, r)
+	if !ok {
+		return
+	}
+
+	products, err := h.productStore.GetPublishedProductsByUserID(r.Context(), seller.UserID)
+	if err != nil {
+		utils.InternalServerError(w, r, err)
+		return
+	}
+
+	if err := utils.JSONResponse(w, http.StatusOK, products); err != nil {
+		utils.InternalServerError(w, r, err)
+		return
+	}
+}
+
+func (h *Handler) getMySeller(w http.ResponseWriter, r *http.Request) {
+	seller, err := h.store.GetSellerByUserID(r.Context(), user.GetUserFromContext(r).ID)
+	if err != nil {
+		switch err {
+		case utils.ErrorNotFound:
+			utils.NotFoundResponse(w, r, err)
+		default:
+			utils.InternalServerError(w, r, err)
+		}
+		return
+	}
+
+	if err := utils.JSONResponse(w, http.StatusOK, seller); err != nil {
+		utils.InternalServerError(w, r, err)
+		return
+	}
+}
+
+func (h *Handler) createSeller(w http.ResponseWriter, r *http.Request) {
+	var payload SellerPayload
+
+	if err := utils.ParseJSON(w, r, &payload); err != nil {
+		utils.BadRequestError(w, r, err)
+		return
+	}
+
+	if err := utils.Validator.Struct(payload); err != nil {
+		utils.BadRequestError(w, r, err)
+		return
+	}
+
+	seller := &Seller{
+		UserID:         user.GetUserFromContext(r).ID,
+		DisplayName:    payload.DisplayName,
+		Slug:           payload.Slug,
+		Description:    payload.Description,
+		Logo:           payload.Logo,
+		ShippingPolicy: payload.ShippingPolicy,
+		ReturnPolicy:   payload.ReturnPolicy,
+	}
+
+	if err := h.store.CreateSeller(r.Context(), seller); err != nil {
+		switch err {
+		case utils.ErrorDuplicateSlug, utils.ErrorDuplicateSeller:
+			utils.BadRequestError(w, r, err)
+		default:
+			utils.InternalServerError(w, r, err)
+		}
+		return
+	}
+
+	if err := utils.JSONResponse(w, http.StatusCreated, seller); err != nil {
+		utils.InternalServerError(w, r, err)
+		return
+	}
+}
+
+func (h *Handler) updateSeller(w http.ResponseWriter, r *http.Request) {
+	var payload UpdateSellerPayload
+
+	if err := utils.ParseJSON(w, r, &payload); err != nil {
+		utils.BadRequestError(w, r, err)
+		return
+	}
+
+	if err := utils.Validator.Struct(payload); err != nil {
+		utils.BadRequestError(w, r, err)
+		return
+	}
+
+	seller, err := h.store.GetSellerByUserID(r.Context(), user.GetUserFromContext(r).ID)
+	if err != nil {
+		switch err {
+		case utils.ErrorNotFound:
+			utils.NotFoundResponse(w, r, err)
+		default:
+			utils.InternalServerError(w, r, err)
+		}
+		return
+	}
+
+	utils.AssignIfNotNil(&seller.DisplayName, payload.DisplayName)
+	utils.AssignIfNotNil(&seller.Slug, payload.Slug)
+	utils.AssignIfNotNil(&seller.Description, payload.Description)
+	utils.AssignIfNotNil(&seller.Logo, payload.Logo)
+	utils.AssignIfNotNil(&seller.ShippingPolicy, payload.ShippingPolicy)
+	utils.AssignIfNotNil(&seller.ReturnPolicy, payload.ReturnPolicy)
+
+	if err := h.store.UpdateSeller(r.Context(), seller); err != nil {
+		switch err {
+		case utils.ErrorDuplicateSlug:
+			utils.BadRequestError(w, r, err)
+		case utils.ErrorNotFound:
+			utils.NotFoundResponse(w, r, err)
+		default:
+			utils.InternalServerError(w, r, err)
+		}
+		return
+	}
+
+	if err := utils.JSONResponse(w, http.StatusOK, seller); err != nil {
+		utils.InternalServerError(w, r, err)
+		return
+	}
+}
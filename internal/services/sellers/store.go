@@ -0,0 +1,124 @@
+package sellers
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	uuid "github.com/satori/go.uuid"
+	"github.com/umeh-promise/ecommerce/utils"
+)
+
+const sellerColumns = `id, user_id, display_name, slug, description, logo, shipping_policy, return_policy,
+	version, created_at, updated_at`
+
+type Store struct {
+	db *sql.DB
+}
+
+func NewStore(db *sql.DB) *Store {
+	return &Store{db: db}
+}
+
+func uniqueViolation(err error) error {
+	switch err.Error() {
+	case `pq: duplicate key value violates unique constraint "sellers_slug_key"`:
+		return utils.ErrorDuplicateSlug
+	case `pq: duplicate key value violates unique constraint "sellers_user_id_key"`:
+		return utils.ErrorDuplicateSeller
+	default:
+		return err
+	}
+}
+
+func (s *Store) CreateSeller(ctx context.Context, seller *Seller) error {
+	query := `
+		INSERT INTO sellers
+			(id, user_id, display_name, slug, description, logo, shipping_policy, return_policy)
+		VALUES
+			($1, $2, $3, $4, $5, $6, $7, $8)
+		RETURNING version, created_at, updated_at
+	`
+
+	seller.ID = uuid.NewV4().String()
+
+	ctx, cancel := context.WithTimeout(ctx, utils.QueryTimeout)
+	defer cancel()
+
+	err := s.db.QueryRowContext(ctx, query,
+		seller.ID, seller.UserID, seller.DisplayName, seller.Slug,
+		seller.Description, seller.Logo, seller.ShippingPolicy, seller.ReturnPolicy,
+	).Scan(&seller.Version, &seller.CreatedAt, &seller.UpdatedAt)
+	if err != nil {
+		return uniqueViolation(err)
+	}
+
+	return nil
+}
+
+func (s *Store) GetSellerBySlug(ctx context.Context, slug string) (*Seller, error) {
+	return s.getSeller(ctx, `SELECT `+sellerColumns+` FROM sellers WHERE slug = $1`, slug)
+}
+
+func (s *Store) GetSellerByUserID(ctx context.Context, userID string) (*Seller, error) {
+	return s.getSeller(ctx, `SELECT `+sellerColumns+` FROM sellers WHERE user_id = $1`, userID)
+}
+
+func (s *Store) getSeller(ctx context.Context, query, arg string) (*Seller, error) {
+	var seller Seller
+
+	ctx, cancel := context.WithTimeout(ctx, utils.QueryTimeout)
+	defer cancel()
+
+	err := s.db.QueryRowContext(ctx, query, arg).Scan(
+		&seller.ID,
+		&seller.UserID,
+		&seller.DisplayName,
+		&seller.Slug,
+		&seller.Description,
+		&seller.Logo,
+		&seller.ShippingPolicy,
+		&seller.ReturnPolicy,
+		&seller.Version,
+		&seller.CreatedAt,
+		&seller.UpdatedAt,
+	)
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return nil, utils.ErrorNotFound
+		default:
+			return nil, err
+		}
+	}
+
+	return &seller, nil
+}
+
+func (s *Store) UpdateSeller(ctx context.Context, seller *Seller) error {
+	query := `
+		UPDATE sellers
+		SET display_name = $1, slug = $2, description = $3, logo = $4,
+			shipping_policy = $5, return_policy = $6, version = version + 1, updated_at = now()
+		WHERE id = $7 AND version = $8
+		RETURNING version
+	`
+
+	ctx, cancel := context.WithTimeout(ctx, utils.QueryTimeout)
+	defer cancel()
+
+	err := s.db.QueryRowContext(ctx, query,
+		seller.DisplayName, seller.Slug, seller.Description, seller.Logo,
+		seller.ShippingPolicy, seller.ReturnPolicy, seller.ID, seller.Version,
+	).Scan(&seller.Version)
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return utils.ErrorNotFound
+		default:
+			return uniqueViolation(err)
+		}
+	}
+
+	return nil
+}
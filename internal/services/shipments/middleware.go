@@ -0,0 +1,62 @@
+package shipments
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/umeh-promise/ecommerce/internal/services/user"
+	"github.com/umeh-promise/ecommerce/utils"
+)
+
+type shipmentKey string
+
+var shipmentCtx shipmentKey = "shipment"
+
+// ShipmentMiddleware loads the shipment in the URL for its seller, the
+// customer who placed the order, and admins. It must be mounted after
+// AuthTokenMiddleware.
+func (middleware *Handler) ShipmentMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		shipmentID := chi.URLParam(r, "shipmentID")
+		ctx := r.Context()
+
+		shipment, err := middleware.store.GetShipmentByID(ctx, shipmentID)
+		if err != nil {
+			switch err {
+			case utils.ErrorNotFound:
+				utils.NotFoundResponse(w, r, err)
+			default:
+				utils.InternalServerError(w, r, err)
+			}
+			return
+		}
+
+		viewer := user.GetUserFromContext(r)
+		if viewer.ID != shipment.SellerID && viewer.ID != shipment.CustomerID && viewer.Role != user.RoleAdmin {
+			utils.NotFoundResponse(w, r, utils.ErrorNotFound)
+			return
+		}
+
+		ctx = context.WithValue(ctx, shipmentCtx, shipment)
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// ShipmentSellerMiddleware lets through the shipping seller and admins. It
+// must be mounted after ShipmentMiddleware.
+func (middleware *Handler) ShipmentSellerMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		viewer := user.GetUserFromContext(r)
+		if GetShipmentFromContext(r).SellerID != viewer.ID && viewer.Role != user.RoleAdmin {
+			utils.ForbiddenServerError(w, r)
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+func GetShipmentFromContext(r *http.Request) *Shipment {
+	return r.Context().Value(shipmentCtx).(*Shipment)
+}
@@ -0,0 +1,95 @@
+package shipments
+
+import (
+	"context"
+	"time"
+)
+
+type ShipmentStatus string
+
+const (
+	StatusLabelCreated   ShipmentStatus = "label_created"
+	StatusInTransit      ShipmentStatus = "in_transit"
+	StatusOutForDelivery ShipmentStatus = "out_for_delivery"
+	StatusDelivered      ShipmentStatus = "delivered"
+	StatusException      ShipmentStatus = "exception"
+)
+
+type Shipment struct {
+	ID             string          `json:"id"`
+	OrderID        string          `json:"order_id"`
+	SellerOrderID  string          `json:"seller_order_id"`
+	SellerID       string          `json:"-"`
+	CustomerID     string          `json:"-"`
+	Carrier        string          `json:"carrier"`
+	TrackingNumber string          `json:"tracking_number"`
+	Status         ShipmentStatus  `json:"status"`
+	Items          []ShipmentItem  `json:"items"`
+	Events         []TrackingEvent `json:"events"`
+	DeliveredAt    *time.Time      `json:"delivered_at"`
+	CreatedAt      string          `json:"created_at"`
+	UpdatedAt      string          `json:"-"`
+}
+
+type ShipmentItem struct {
+	OrderItemID string `json:"order_item_id"`
+	ProductName string `json:"product_name"`
+	Quantity    int    `json:"quantity"`
+}
+
+// TrackingEvent is one step in a shipment's timeline, as reported by the
+// seller or the carrier.
+type TrackingEvent struct {
+	ID          string         `json:"id"`
+	ShipmentID  string         `json:"-"`
+	Status      ShipmentStatus `json:"status"`
+	Description string         `json:"description"`
+	Location    string         `json:"location"`
+	OccurredAt  time.Time      `json:"occurred_at"`
+}
+
+// ShipmentEvent is the payload of shipment events.
+type ShipmentEvent struct {
+	ShipmentID     string         `json:"shipment_id"`
+	OrderID        string         `json:"order_id"`
+	Status         ShipmentStatus `json:"status"`
+	Carrier        string         `json:"carrier"`
+	TrackingNumber string         `json:"tracking_number"`
+}
+
+func newShipmentEvent(shipment *Shipment) *ShipmentEvent {
+	return &ShipmentEvent{
+		ShipmentID:     shipment.ID,
+		OrderID:        shipment.OrderID,
+		Status:         shipment.Status,
+		Carrier:        shipment.Carrier,
+		TrackingNumber: shipment.TrackingNumber,
+	}
+}
+
+type ShipmentStore interface {
+	// CreateShipment records the shipment, rejecting items whose quantity
+	// would exceed what is left to ship.
+	CreateShipment(context.Context, *Shipment) error
+	GetShipmentByID(context.Context, string) (*Shipment, error)
+	GetShipmentsByOrderID(context.Context, string) ([]Shipment, error)
+	AddEvent(context.Context, *Shipment, *TrackingEvent) error
+}
+
+type ShipmentItemPayload struct {
+	OrderItemID string `json:"order_item_id" validate:"required,uuid"`
+	Quantity    int    `json:"quantity" validate:"required,gt=0"`
+}
+
+type ShipmentPayload struct {
+	Carrier        string                `json:"carrier" validate:"required,max=100"`
+	TrackingNumber string                `json:"tracking_number" validate:"required,max=100"`
+	Items          []ShipmentItemPayload `json:"items" validate:"required,min=1,dive"`
+}
+
+type TrackingEventPayload struct {
+	Status      ShipmentStatus `json:"status" validate:"required,oneof=in_transit out_for_delivery delivered exception"`
+	Description string         `json:"description" validate:"max=255"`
+	Location    string         `json:"location" validate:"max=255"`
+	OccurredAt  *time.Time     `json:"occurred_at"`
+}
@@ -0,0 +1,234 @@
+package shipments
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/umeh-promise/ecommerce/internal/services/orders"
+	"github.com/umeh-promise/ecommerce/internal/services/user"
+	"github.com/umeh-promise/ecommerce/utils"
+)
+
+type Handler struct {
+	store      ShipmentStore
+	orderStore orders.OrderStore
+}
+
+func NewHandler(store ShipmentStore, orderStore orders.OrderStore) *Handler {
+	return &Handler{store: store, orderStore: orderStore}
+}
+
+func (h *Handler) RegisterRoute(auth *user.Handler) func(r chi.Router) {
+	return func(r chi.Router) {
+		r.Route("/orders/{orderID}/shipments", func(r chi.Router) {
+			r.Use(auth.AuthTokenMiddleware)
+			r.Get("/", h.getOrderShipments)
+			r.Post("/", h.createShipment)
+		})
+
+		r.Route("/shipments/{shipmentID}", func(r chi.Router) {
+			r.Use(auth.AuthTokenMiddleware, h.ShipmentMiddleware)
+			r.Get("/", h.getShipment)
+			r.With(h.ShipmentSellerMiddleware).Post("/events", h.addEvent)
+		})
+	}
+}
+
+// order loads the order in the URL, reporting it as missing to anyone who
+// neither placed it, sells in it, nor is an admin.
+func (h *Handler) order(w http.ResponseWriter, r *http.Request) (*orders.Order, bool) {
+	order, err := h.orderStore.GetOrderByID(r.Context(), chi.URLParam(r, "orderID"))
+	if err != nil {
+		switch err {
+		case utils.ErrorNotFound:
+			utils.NotFoundResponse(w, r, err)
+		default:
+			utils.InternalServerError(w, r, err)
+		}
+		return nil, false
+	}
+
+	viewer := user.GetUserFromContext(r)
+	if order.UserID != viewer.ID && viewer.Role != user.RoleAdmin && sellerOrder(order, viewer.ID) == nil {
+		utils.NotFoundResponse(w, r, utils.ErrorNotFound)
+		return nil, false
+	}
+
+	return order, true
+}
+
+func sellerOrder(order *orders.Order, sellerID string) *orders.SellerOrder {
+	for i := range order.SellerOrders {
+		if order.SellerOrders[i].SellerID == sellerID {
+			return &order.SellerOrders[i]
+		}
+	}
+	return nil
+}
+
+func (h *Handler) getOrderShipments(w http.ResponseWriter, r *http.Request) {
+	order, ok := h.order(w, r)
+	if !ok {
+		return
+	}
+
+	shipments, err := h.store.GetShipmentsByOrderID(r.Context(), order.ID)
+	if err != nil {
+		utils.InternalServerError(w, r, err)
+		return
+	}
+
+	// Sellers only see their own parcels; the customer and admins see all.
+	viewer := user.GetUserFromContext(r)
+	if order.UserID != viewer.ID && viewer.Role != user.RoleAdmin {
+		var own []Shipment
+		for _, shipment := range shipments {
+			if shipment.SellerID == viewer.ID {
+				own = append(own, shipment)
+			}
+		}
+		shipments = own
+	}
+
+	if err := utils.JSONResponse(w, http.StatusOK, shipments); err != nil {
+		utils.InternalServerError(w, r, err)
+		return
+	}
+}
+
+func (h *Handler) createShipment(w http.ResponseWriter, r *http.Request) {
+	var payload ShipmentPayload
+
+	if err := utils.ParseJSON(w, r, &payload); err != nil {
+		utils.BadRequestError(w, r, err)
+		return
+	}
+
+	if err := utils.Validator.Struct(payload); err != nil {
+		utils.BadRequestError(w, r, err)
+		return
+	}
+
+	order, ok := h.order(w, r)
+	if !ok {
+		return
+	}
+
+	if order.Status != orders.StatusPaid {
+		utils.BadRequestError(w, r, fmt.Errorf("only paid orders can be shipped"))
+		return
+	}
+
+	shipment := &Shipment{
+		OrderID:        order.ID,
+		Carrier:        payload.Carrier,
+		TrackingNumber: payload.TrackingNumber,
+	}
+
+	// Sellers ship from their own sub-order. Admins ship on a seller's
+	// behalf, so the sub-order is taken from the items.
+	viewer := user.GetUserFromContext(r)
+	if own := sellerOrder(order, viewer.ID); own != nil {
+		shipment.SellerOrderID = own.ID
+	} else if viewer.Role == user.RoleAdmin {
+		for _, item := range order.Items {
+			if item.ID == payload.Items[0].OrderItemID {
+				shipment.SellerOrderID = item.SellerOrderID
+			}
+		}
+	} else {
+		utils.ForbiddenServerError(w, r)
+		return
+	}
+
+	seen := map[string]bool{}
+	for _, item := range payload.Items {
+		if seen[item.OrderItemID] {
+			utils.BadRequestError(w, r, fmt.Errorf("order item (%s) is listed more than once", item.OrderItemID))
+			return
+		}
+		seen[item.OrderItemID] = true
+
+		shipment.Items = append(shipment.Items, ShipmentItem{
+			OrderItemID: item.OrderItemID,
+			Quantity:    item.Quantity,
+		})
+	}
+
+	if shipment.SellerOrderID == "" {
+		utils.BadRequestError(w, r, fmt.Errorf("order items must belong to this order"))
+		return
+	}
+
+	if err := h.store.CreateShipment(r.Context(), shipment); err != nil {
+		switch err {
+		case utils.ErrorNotFound:
+			utils.BadRequestError(w, r, fmt.Errorf("every item must belong to the same seller in this order"))
+		case utils.ErrorOverShipment:
+			utils.BadRequestError(w, r, err)
+		default:
+			utils.InternalServerError(w, r, err)
+		}
+		return
+	}
+
+	if err := utils.JSONResponse(w, http.StatusCreated, shipment); err != nil {
+		utils.InternalServerError(w, r, err)
+		return
+	}
+}
+
+func (h *Handler) getShipment(w http.ResponseWriter, r *http.Request) {
+	shipment := GetShipmentFromContext(r)
+
+	if err := utils.JSONResponse(w, http.StatusOK, shipment); err != nil {
+		utils.InternalServerError(w, r, err)
+		return
+	}
+}
+
+func (h *Handler) addEvent(w http.ResponseWriter, r *http.Request) {
+	var payload TrackingEventPayload
+
+	if err := utils.ParseJSON(w, r, &payload); err != nil {
+		utils.BadRequestError(w, r, err)
+		return
+	}
+
+	if err := utils.Validator.Struct(payload); err != nil {
+		utils.BadRequestError(w, r, err)
+		return
+	}
+
+	shipment := GetShipmentFromContext(r)
+
+	if shipment.Status == StatusDelivered {
+		utils.BadRequestError(w, r, fmt.Errorf("shipment has already been delivered"))
+		return
+	}
+
+	event := &TrackingEvent{
+		Status:      payload.Status,
+		Description: payload.Description,
+		Location:    payload.Location,
+	}
+	if payload.OccurredAt != nil {
+		if payload.OccurredAt.After(time.Now()) {
+			utils.BadRequestError(w, r, fmt.Errorf("tracking events cannot be in the future"))
+			return
+		}
+		event.OccurredAt = *payload.OccurredAt
+	}
+
+	if err := h.store.AddEvent(r.Context(), shipment, event); err != nil {
+		utils.InternalServerError(w, r, err)
+		return
+	}
+
+	if err := utils.JSONResponse(w, http.StatusCreated, shipment); err != nil {
+		utils.InternalServerError(w, r, err)
+		return
+	}
+}
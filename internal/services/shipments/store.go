@@ -0,0 +1,321 @@
+package shipments
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	uuid "github.com/satori/go.uuid"
+	"github.com/umeh-promise/ecommerce/internal/events"
+	"github.com/umeh-promise/ecommerce/utils"
+)
+
+const shipmentColumns = `sh.id, sh.order_id, sh.seller_order_id, so.seller_user_id, COALESCE(o.user_id::text, ''), sh.carrier,
+	sh.tracking_number, sh.status, sh.delivered_at, sh.created_at, sh.updated_at`
+
+const shipmentSource = `shipments sh
+	JOIN seller_orders so ON so.id = sh.seller_order_id
+	JOIN orders o ON o.id = sh.order_id`
+
+type scanner interface {
+	Scan(dest ...any) error
+}
+
+func scanShipment(row scanner, shipment *Shipment) error {
+	var deliveredAt sql.NullTime
+
+	err := row.Scan(
+		&shipment.ID,
+		&shipment.OrderID,
+		&shipment.SellerOrderID,
+		&shipment.SellerID,
+		&shipment.CustomerID,
+		&shipment.Carrier,
+		&shipment.TrackingNumber,
+		&shipment.Status,
+		&deliveredAt,
+		&shipment.CreatedAt,
+		&shipment.UpdatedAt,
+	)
+	if err != nil {
+		return err
+	}
+
+	shipment.DeliveredAt = nil
+	if deliveredAt.Valid {
+		shipment.DeliveredAt = &deliveredAt.Time
+	}
+
+	return nil
+}
+
+type Store struct {
+	db *sql.DB
+}
+
+func NewStore(db *sql.DB) *Store {
+	return &Store{db: db}
+}
+
+func (s *Store) CreateShipment(ctx context.Context, shipment *Shipment) error {
+	shipment.ID = uuid.NewV4().String()
+	shipment.Status = StatusLabelCreated
+
+	ctx, cancel := context.WithTimeout(ctx, utils.QueryTimeout)
+	defer cancel()
+
+	return utils.WithTx(ctx, s.db, func(tx *sql.Tx) error {
+		err := tx.QueryRowContext(ctx, `
+			INSERT INTO shipments (id, order_id, seller_order_id, carrier, tracking_number, status)
+			VALUES ($1, $2, $3, $4, $5, $6)
+			RETURNING created_at, updated_at
+		`, shipment.ID, shipment.OrderID, shipment.SellerOrderID, shipment.Carrier, shipment.TrackingNumber, shipment.Status,
+		).Scan(&shipment.CreatedAt, &shipment.UpdatedAt)
+		if err != nil {
+			return err
+		}
+
+		for i := range shipment.Items {
+			item := &shipment.Items[i]
+
+			// Lock the order item so concurrent shipments can't both claim
+			// the last units.
+			var ordered, shipped int
+			err := tx.QueryRowContext(ctx, `
+				SELECT product_name, quantity FROM order_items
+				WHERE id = $1 AND seller_order_id = $2
+				FOR UPDATE
+			`, item.OrderItemID, shipment.SellerOrderID).Scan(&item.ProductName, &ordered)
+			if err != nil {
+				switch {
+				case errors.Is(err, sql.ErrNoRows):
+					return utils.ErrorNotFound
+				default:
+					return err
+				}
+			}
+
+			err = tx.QueryRowContext(ctx,
+				`SELECT COALESCE(sum(quantity), 0) FROM shipment_items WHERE order_item_id = $1`, item.OrderItemID,
+			).Scan(&shipped)
+			if err != nil {
+				return err
+			}
+
+			remaining := ordered - shipped
+			if item.Quantity > remaining {
+				return utils.ErrorOverShipment
+			}
+
+			_, err = tx.ExecContext(ctx, `
+				INSERT INTO shipment_items (shipment_id, order_item_id, quantity)
+				VALUES ($1, $2, $3)
+			`, shipment.ID, item.OrderItemID, item.Quantity)
+			if err != nil {
+				return err
+			}
+		}
+
+		event := TrackingEvent{Status: shipment.Status, Description: "Shipping label created"}
+		if err := insertEvent(ctx, tx, shipment.ID, &event); err != nil {
+			return err
+		}
+		shipment.Events = []TrackingEvent{event}
+
+		return events.Publish(ctx, tx, events.ShipmentCreated, shipment.ID, newShipmentEvent(shipment))
+	})
+}
+
+func insertEvent(ctx context.Context, tx *sql.Tx, shipmentID string, event *TrackingEvent) error {
+	event.ID = uuid.NewV4().String()
+	event.ShipmentID = shipmentID
+
+	var occurredAt any
+	if !event.OccurredAt.IsZero() {
+		occurredAt = event.OccurredAt
+	}
+
+	return tx.QueryRowContext(ctx, `
+		INSERT INTO shipment_events (id, shipment_id, status, description, location, occurred_at)
+		VALUES ($1, $2, $3, $4, $5, COALESCE($6, now()))
+		RETURNING occurred_at
+	`, event.ID, event.ShipmentID, event.Status, event.Description, event.Location, occurredAt).Scan(&event.OccurredAt)
+}
+
+func (s *Store) GetShipmentByID(ctx context.Context, id string) (*Shipment, error) {
+	var shipment Shipment
+
+	query := `SELECT ` + shipmentColumns + ` FROM ` + shipmentSource + ` WHERE sh.id = $1`
+
+	ctx, cancel := context.WithTimeout(ctx, utils.QueryTimeout)
+	defer cancel()
+
+	if err := scanShipment(s.db.QueryRowContext(ctx, query, id), &shipment); err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return nil, utils.ErrorNotFound
+		default:
+			return nil, err
+		}
+	}
+
+	if err := s.loadDetails(ctx, []*Shipment{&shipment}); err != nil {
+		return nil, err
+	}
+
+	return &shipment, nil
+}
+
+func (s *Store) GetShipmentsByOrderID(ctx context.Context, orderID string) ([]Shipment, error) {
+	query := `SELECT ` + shipmentColumns + ` FROM ` + shipmentSource + `
+		WHERE sh.order_id = $1
+		ORDER BY sh.created_at, sh.id`
+
+	ctx, cancel := context.WithTimeout(ctx, utils.QueryTimeout)
+	defer cancel()
+
+	rows, err := s.db.QueryContext(ctx, query, orderID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var shipments []Shipment
+
+	for rows.Next() {
+		shipment := Shipment{}
+		if err := scanShipment(rows, &shipment); err != nil {
+			return nil, err
+		}
+
+		shipments = append(shipments, shipment)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	refs := make([]*Shipment, len(shipments))
+	for i := range shipments {
+		refs[i] = &shipments[i]
+	}
+
+	if err := s.loadDetails(ctx, refs); err != nil {
+		return nil, err
+	}
+
+	return shipments, nil
+}
+
+// loadDetails fills in the items and event timeline of each shipment.
+func (s *Store) loadDetails(ctx context.Context, shipments []*Shipment) error {
+	for _, shipment := range shipments {
+		items, err := s.getItems(ctx, shipment.ID)
+		if err != nil {
+			return err
+		}
+		shipment.Items = items
+
+		events, err := s.getEvents(ctx, shipment.ID)
+		if err != nil {
+			return err
+		}
+		shipment.Events = events
+	}
+
+	return nil
+}
+
+func (s *Store) getItems(ctx context.Context, shipmentID string) ([]ShipmentItem, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT si.order_item_id, oi.product_name, si.quantity
+		FROM shipment_items si
+		JOIN order_items oi ON oi.id = si.order_item_id
+		WHERE si.shipment_id = $1
+		ORDER BY oi.product_name
+	`, shipmentID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var items []ShipmentItem
+
+	for rows.Next() {
+		item := ShipmentItem{}
+		if err := rows.Scan(&item.OrderItemID, &item.ProductName, &item.Quantity); err != nil {
+			return nil, err
+		}
+
+		items = append(items, item)
+	}
+
+	return items, rows.Err()
+}
+
+func (s *Store) getEvents(ctx context.Context, shipmentID string) ([]TrackingEvent, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT id, shipment_id, status, description, location, occurred_at
+		FROM shipment_events
+		WHERE shipment_id = $1
+		ORDER BY occurred_at, created_at
+	`, shipmentID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var events []TrackingEvent
+
+	for rows.Next() {
+		event := TrackingEvent{}
+		err := rows.Scan(
+			&event.ID,
+			&event.ShipmentID,
+			&event.Status,
+			&event.Description,
+			&event.Location,
+			&event.OccurredAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		events = append(events, event)
+	}
+
+	return events, rows.Err()
+}
+
+// AddEvent appends to the timeline and moves the shipment to the event's
+// status.
+func (s *Store) AddEvent(ctx context.Context, shipment *Shipment, event *TrackingEvent) error {
+	ctx, cancel := context.WithTimeout(ctx, utils.QueryTimeout)
+	defer cancel()
+
+	return utils.WithTx(ctx, s.db, func(tx *sql.Tx) error {
+		if err := insertEvent(ctx, tx, shipment.ID, event); err != nil {
+			return err
+		}
+
+		var deliveredAt sql.NullTime
+		err := tx.QueryRowContext(ctx, `
+			UPDATE shipments
+			SET status = $1,
+				delivered_at = CASE WHEN $1 = 'delivered' THEN $2 ELSE delivered_at END,
+				updated_at = now()
+			WHERE id = $3
+			RETURNING delivered_at
+		`, event.Status, event.OccurredAt, shipment.ID).Scan(&deliveredAt)
+		if err != nil {
+			return err
+		}
+
+		shipment.Status = event.Status
+		if deliveredAt.Valid {
+			shipment.DeliveredAt = &deliveredAt.Time
+		}
+		shipment.Events = append(shipment.Events, *event)
+
+		return events.Publish(ctx, tx, events.ShipmentUpdated, shipment.ID, newShipmentEvent(shipment))
+	})
+}
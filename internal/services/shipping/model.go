@@ -0,0 +1,233 @@
+package shipping
+
+import (
+	"context"
+	"slices"
+	"strings"
+
+	"github.com/umeh-promise/ecommerce/internal/services/products"
+	"github.com/umeh-promise/ecommerce/utils"
+)
+
+// volumetricDivisor converts a parcel's size into the weight carriers bill
+// it at: length x width x height in mm divided by this gives grams.
+const volumetricDivisor = 5000
+
+type Destination struct {
+	Country    string `json:"country"`
+	Region     string `json:"region"`
+	PostalCode string `json:"postal_code"`
+}
+
+type Item struct {
+	ProductID   string
+	Quantity    int
+	WeightGrams int
+	LengthMm    int
+	WidthMm     int
+	HeightMm    int
+}
+
+func NewItem(product *products.Product, quantity int) Item {
+	return Item{
+		ProductID:   product.ID,
+		Quantity:    quantity,
+		WeightGrams: product.WeightGrams,
+		LengthMm:    product.LengthMm,
+		WidthMm:     product.WidthMm,
+		HeightMm:    product.HeightMm,
+	}
+}
+
+// BillableWeight is the larger of the item's actual and volumetric weight.
+func (i Item) BillableWeight() int {
+	volumetric := i.LengthMm * i.WidthMm * i.HeightMm / volumetricDivisor
+	return max(i.WeightGrams, volumetric) * i.Quantity
+}
+
+// Parcel is everything a provider needs to price a delivery.
+type Parcel struct {
+	Items       []Item
+	Subtotal    int
+	Destination Destination
+}
+
+func (p *Parcel) BillableWeight() int {
+	total := 0
+	for _, item := range p.Items {
+		total += item.BillableWeight()
+	}
+	return total
+}
+
+// Option is one way of shipping a parcel. ID is stable across quotes so a
+// client can send it back at checkout.
+type Option struct {
+	ID       string `json:"id"`
+	Provider string `json:"provider"`
+	Name     string `json:"name"`
+	Amount   int    `json:"amount"`
+	MinDays  *int   `json:"min_days"`
+	MaxDays  *int   `json:"max_days"`
+}
+
+// RateProvider prices parcels. The built-in provider reads table rates from
+// the database; carrier integrations implement the same interface.
+type RateProvider interface {
+	Name() string
+	Rates(context.Context, *Parcel) ([]Option, error)
+}
+
+// Quote collects the options of every provider, cheapest first.
+func Quote(ctx context.Context, providers []RateProvider, parcel *Parcel) ([]Option, error) {
+	var options []Option
+
+	for _, provider := range providers {
+		rates, err := provider.Rates(ctx, parcel)
+		if err != nil {
+			return nil, err
+		}
+		options = append(options, rates...)
+	}
+
+	slices.SortStableFunc(options, func(a, b Option) int {
+		return a.Amount - b.Amount
+	})
+
+	return options, nil
+}
+
+// Select returns the option with the given ID, or the cheapest one when id
+// is empty.
+func Select(options []Option, id string) (*Option, error) {
+	if len(options) == 0 {
+		return nil, utils.ErrorNoShippingOptions
+	}
+
+	if id == "" {
+		return &options[0], nil
+	}
+
+	for i := range options {
+		if options[i].ID == id {
+			return &options[i], nil
+		}
+	}
+
+	return nil, utils.ErrorInvalidShippingOption
+}
+
+type Zone struct {
+	ID        string   `json:"id"`
+	Name      string   `json:"name"`
+	Countries []string `json:"countries"`
+	Regions   []string `json:"regions"`
+	Rates     []Rate   `json:"rates"`
+	CreatedAt string   `json:"created_at"`
+}
+
+// specificity ranks how closely the zone matches the destination, or
+// returns 0 if it does not cover it at all.
+func (z *Zone) specificity(destination Destination) int {
+	if len(z.Countries) == 0 {
+		return 1
+	}
+
+	if !slices.Contains(z.Countries, destination.Country) {
+		return 0
+	}
+
+	if len(z.Regions) == 0 {
+		return 2
+	}
+
+	matches := slices.ContainsFunc(z.Regions, func(region string) bool {
+		return strings.EqualFold(region, destination.Region)
+	})
+	if !matches {
+		return 0
+	}
+
+	return 3
+}
+
+type Rate struct {
+	ID             string `json:"id"`
+	ZoneID         string `json:"-"`
+	Name           string `json:"name"`
+	MinWeightGrams int    `json:"min_weight_grams"`
+	MaxWeightGrams *int   `json:"max_weight_grams"`
+	MinSubtotal    int    `json:"min_subtotal"`
+	MaxSubtotal    *int   `json:"max_subtotal"`
+	Price          int    `json:"price"`
+	FreeOver       *int   `json:"free_over"`
+	MinDays        *int   `json:"min_days"`
+	MaxDays        *int   `json:"max_days"`
+}
+
+// Applies reports whether the parcel falls inside the rate's brackets.
+// Upper bounds are inclusive.
+func (r *Rate) Applies(weight, subtotal int) bool {
+	if weight < r.MinWeightGrams || (r.MaxWeightGrams != nil && weight > *r.MaxWeightGrams) {
+		return false
+	}
+
+	if subtotal < r.MinSubtotal || (r.MaxSubtotal != nil && subtotal > *r.MaxSubtotal) {
+		return false
+	}
+
+	return true
+}
+
+// Charge is the rate's price for an order of the given subtotal, taking the
+// free-shipping threshold into account.
+func (r *Rate) Charge(subtotal int) int {
+	if r.FreeOver != nil && subtotal >= *r.FreeOver {
+		return 0
+	}
+	return r.Price
+}
+
+type ZoneStore interface {
+	CreateZone(context.Context, *Zone) error
+	GetZones(context.Context) ([]Zone, error)
+	DeleteZone(context.Context, string) error
+}
+
+type RatePayload struct {
+	Name           string `json:"name" validate:"required,max=100"`
+	MinWeightGrams int    `json:"min_weight_grams" validate:"gte=0"`
+	MaxWeightGrams *int   `json:"max_weight_grams" validate:"omitempty,gtefield=MinWeightGrams"`
+	MinSubtotal    int    `json:"min_subtotal" validate:"gte=0"`
+	MaxSubtotal    *int   `json:"max_subtotal" validate:"omitempty,gtefield=MinSubtotal"`
+	Price          int    `json:"price" validate:"gte=0"`
+	FreeOver       *int   `json:"free_over" validate:"omitempty,gte=0"`
+	MinDays        *int   `json:"min_days" validate:"omitempty,gte=0"`
+	MaxDays        *int   `json:"max_days" validate:"omitempty,gte=0"`
+}
+
+type ZonePayload struct {
+	Name      string        `json:"name" validate:"required,max=100"`
+	Countries []string      `json:"countries" validate:"dive,iso3166_1_alpha2"`
+	Regions   []string      `json:"regions" validate:"dive,min=1,max=100"`
+	Rates     []RatePayload `json:"rates" validate:"required,min=1,dive"`
+}
+
+type ItemPayload struct {
+	ProductID string `json:"product_id" validate:"required,uuid"`
+	Quantity  int    `json:"quantity" validate:"required,gt=0,lte=1000"`
+}
+
+type DestinationPayload struct {
+	Country    string `json:"country" validate:"required,iso3166_1_alpha2"`
+	Region     string `json:"region" validate:"max=100"`
+	PostalCode string `json:"postal_code" validate:"max=20"`
+}
+
+// QuotePayload takes either a saved address (signed-in users) or a bare
+// destination.
+type QuotePayload struct {
+	Items       []ItemPayload       `json:"items" validate:"required,min=1,dive"`
+	AddressID   string              `json:"address_id" validate:"required_without=Destination,omitempty,uuid"`
+	Destination *DestinationPayload `json:"destination" validate:"required_without=AddressID,omitempty"`
+}
@@ -0,0 +1,230 @@
+package shipping
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/umeh-promise/ecommerce/internal/services/addresses"
+	"github.com/umeh-promise/ecommerce/internal/services/products"
+	"github.com/umeh-promise/ecommerce/internal/services/user"
+	"github.com/umeh-promise/ecommerce/utils"
+)
+
+type Handler struct {
+	store        ZoneStore
+	providers    []RateProvider
+	productStore products.ProductStore
+	addressStore addresses.AddressStore
+}
+
+func NewHandler(store ZoneStore, providers []RateProvider, productStore products.ProductStore, addressStore addresses.AddressStore) *Handler {
+	return &Handler{
+		store:        store,
+		providers:    providers,
+		productStore: productStore,
+		addressStore: addressStore,
+	}
+}
+
+func (h *Handler) RegisterRoute(auth *user.Handler) func(r chi.Router) {
+	return func(r chi.Router) {
+		r.Route("/shipping", func(r chi.Router) {
+			r.With(auth.OptionalAuthMiddleware).Post("/quote", h.quote)
+
+			r.Route("/zones", func(r chi.Router) {
+				r.Use(auth.AuthTokenMiddleware, auth.AdminMiddleware)
+				r.Get("/", h.getZones)
+				r.Post("/", h.createZone)
+				r.Delete("/{id}", h.deleteZone)
+			})
+		})
+	}
+}
+
+func (h *Handler) quote(w http.ResponseWriter, r *http.Request) {
+	var payload QuotePayload
+
+	if err := utils.ParseJSON(w, r, &payload); err != nil {
+		utils.BadRequestError(w, r, err)
+		return
+	}
+
+	if err := utils.Validator.Struct(payload); err != nil {
+		utils.BadRequestError(w, r, err)
+		return
+	}
+
+	ctx := r.Context()
+
+	destination, err := h.destination(r, &payload)
+	if err != nil {
+		switch err {
+		case utils.ErrorNotFound:
+			utils.BadRequestError(w, r, fmt.Errorf("address does not exist"))
+		default:
+			utils.InternalServerError(w, r, err)
+		}
+		return
+	}
+
+	parcel, err := h.parcel(ctx, payload.Items)
+	if err != nil {
+		switch err {
+		case utils.ErrorNotFound:
+			utils.BadRequestError(w, r, fmt.Errorf("one or more products do not exist"))
+		default:
+			utils.InternalServerError(w, r, err)
+		}
+		return
+	}
+	parcel.Destination = *destination
+
+	options, err := Quote(ctx, h.providers, parcel)
+	if err != nil {
+		utils.InternalServerError(w, r, err)
+		return
+	}
+
+	if err := utils.JSONResponse(w, http.StatusOK, options); err != nil {
+		utils.InternalServerError(w, r, err)
+		return
+	}
+}
+
+// destination resolves the quote's address. Saved addresses can only be
+// used by their owner; anyone else gets ErrorNotFound.
+func (h *Handler) destination(r *http.Request, payload *QuotePayload) (*Destination, error) {
+	if payload.Destination != nil {
+		return &Destination{
+			Country:    payload.Destination.Country,
+			Region:     payload.Destination.Region,
+			PostalCode: payload.Destination.PostalCode,
+		}, nil
+	}
+
+	caller, ok := user.UserFromContext(r)
+	if !ok {
+		return nil, utils.ErrorNotFound
+	}
+
+	address, err := h.addressStore.GetAddressByID(r.Context(), payload.AddressID)
+	if err != nil {
+		return nil, err
+	}
+
+	if address.UserID != caller.ID {
+		return nil, utils.ErrorNotFound
+	}
+
+	return &Destination{
+		Country:    address.Country,
+		Region:     address.Region,
+		PostalCode: address.PostalCode,
+	}, nil
+}
+
+func (h *Handler) parcel(ctx context.Context, items []ItemPayload) (*Parcel, error) {
+	parcel := &Parcel{}
+
+	for _, item := range items {
+		product, err := h.productStore.GetPostByID(ctx, item.ProductID)
+		if err != nil {
+			return nil, err
+		}
+
+		if product.Status != products.StatusPublished {
+			return nil, utils.ErrorNotFound
+		}
+
+		parcel.Items = append(parcel.Items, NewItem(product, item.Quantity))
+		parcel.Subtotal += product.EffectivePrice * item.Quantity
+	}
+
+	return parcel, nil
+}
+
+func (h *Handler) getZones(w http.ResponseWriter, r *http.Request) {
+	zones, err := h.store.GetZones(r.Context())
+	if err != nil {
+		utils.InternalServerError(w, r, err)
+		return
+	}
+
+	if err := utils.JSONResponse(w, http.StatusOK, zones); err != nil {
+		utils.InternalServerError(w, r, err)
+		return
+	}
+}
+
+func (h *Handler) createZone(w http.ResponseWriter, r *http.Request) {
+	var payload ZonePayload
+
+	if err := utils.ParseJSON(w, r, &payload); err != nil {
+		utils.BadRequestError(w, r, err)
+		return
+	}
+
+	if err := utils.Validator.Struct(payload); err != nil {
+		utils.BadRequestError(w, r, err)
+		return
+	}
+
+	if len(payload.Regions) > 0 && len(payload.Countries) == 0 {
+		utils.BadRequestError(w, r, fmt.Errorf("regions require at least one country"))
+		return
+	}
+
+	zone := &Zone{
+		Name:      payload.Name,
+		Countries: payload.Countries,
+		Regions:   payload.Regions,
+	}
+
+	for _, rate := range payload.Rates {
+		zone.Rates = append(zone.Rates, Rate{
+			Name:           rate.Name,
+			MinWeightGrams: rate.MinWeightGrams,
+			MaxWeightGrams: rate.MaxWeightGrams,
+			MinSubtotal:    rate.MinSubtotal,
+			MaxSubtotal:    rate.MaxSubtotal,
+			Price:          rate.Price,
+			FreeOver:       rate.FreeOver,
+			MinDays:        rate.MinDays,
+			MaxDays:        rate.MaxDays,
+		})
+	}
+
+	if err := h.store.CreateZone(r.Context(), zone); err != nil {
+		switch err {
+		case utils.ErrorDuplicateZone:
+			utils.BadRequestError(w, r, err)
+		default:
+			utils.InternalServerError(w, r, err)
+		}
+		return
+	}
+
+	if err := utils.JSONResponse(w, http.StatusCreated, zone); err != nil {
+		utils.InternalServerError(w, r, err)
+		return
+	}
+}
+
+func (h *Handler) deleteZone(w http.ResponseWriter, r *http.Request) {
+	if err := h.store.DeleteZone(r.Context(), chi.URLParam(r, "id")); err != nil {
+		switch err {
+		case utils.ErrorNotFound:
+			utils.NotFoundResponse(w, r, err)
+		default:
+			utils.InternalServerError(w, r, err)
+		}
+		return
+	}
+
+	if err := utils.JSONResponse(w, http.StatusNoContent, nil); err != nil {
+		utils.InternalServerError(w, r, err)
+		return
+	}
+}
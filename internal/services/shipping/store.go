@@ -0,0 +1,187 @@
+package shipping
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/lib/pq"
+	uuid "github.com/satori/go.uuid"
+	"github.com/umeh-promise/ecommerce/utils"
+)
+
+type Store struct {
+	db *sql.DB
+}
+
+func NewStore(db *sql.DB) *Store {
+	return &Store{db: db}
+}
+
+func (s *Store) CreateZone(ctx context.Context, zone *Zone) error {
+	zone.ID = uuid.NewV4().String()
+
+	// pq stores a nil slice as NULL, but the columns default to '{}' and
+	// an empty list is meaningful: no regions covers the whole country, no
+	// countries the rest of the world.
+	if zone.Countries == nil {
+		zone.Countries = []string{}
+	}
+	if zone.Regions == nil {
+		zone.Regions = []string{}
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, utils.QueryTimeout)
+	defer cancel()
+
+	return utils.WithTx(ctx, s.db, func(tx *sql.Tx) error {
+		err := tx.QueryRowContext(ctx, `
+			INSERT INTO shipping_zones (id, name, countries, regions)
+			VALUES ($1, $2, $3, $4)
+			RETURNING created_at
+		`, zone.ID, zone.Name, pq.Array(zone.Countries), pq.Array(zone.Regions)).Scan(&zone.CreatedAt)
+		if err != nil {
+			switch {
+			case err.Error() == `pq: duplicate key value violates unique constraint "shipping_zones_name_key"`:
+				return utils.ErrorDuplicateZone
+			default:
+				return err
+			}
+		}
+
+		for i := range zone.Rates {
+			rate := &zone.Rates[i]
+			rate.ID = uuid.NewV4().String()
+			rate.ZoneID = zone.ID
+
+			_, err := tx.ExecContext(ctx, `
+				INSERT INTO shipping_rates
+					(id, zone_id, name, min_weight_grams, max_weight_grams, min_subtotal, max_subtotal,
+					price, free_over, min_days, max_days)
+				VALUES
+					($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+			`, rate.ID, rate.ZoneID, rate.Name, rate.MinWeightGrams, rate.MaxWeightGrams,
+				rate.MinSubtotal, rate.MaxSubtotal, rate.Price, rate.FreeOver, rate.MinDays, rate.MaxDays)
+			if err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
+
+// GetZones returns every zone with its rates, cheapest rate first.
+func (s *Store) GetZones(ctx context.Context) ([]Zone, error) {
+	ctx, cancel := context.WithTimeout(ctx, utils.QueryTimeout)
+	defer cancel()
+
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT id, name, countries, regions, created_at FROM shipping_zones ORDER BY name
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var zones []Zone
+	index := map[string]int{}
+
+	for rows.Next() {
+		var (
+			zone      Zone
+			countries pq.StringArray
+			regions   pq.StringArray
+		)
+
+		if err := rows.Scan(&zone.ID, &zone.Name, &countries, &regions, &zone.CreatedAt); err != nil {
+			return nil, err
+		}
+		zone.Countries = countries
+		zone.Regions = regions
+
+		index[zone.ID] = len(zones)
+		zones = append(zones, zone)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	rateRows, err := s.db.QueryContext(ctx, `
+		SELECT id, zone_id, name, min_weight_grams, max_weight_grams, min_subtotal, max_subtotal,
+			price, free_over, min_days, max_days
+		FROM shipping_rates
+		ORDER BY price, name
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rateRows.Close()
+
+	for rateRows.Next() {
+		var (
+			rate                   Rate
+			maxWeight, maxSubtotal sql.NullInt64
+			freeOver               sql.NullInt64
+			minDays, maxDays       sql.NullInt64
+		)
+
+		err := rateRows.Scan(
+			&rate.ID,
+			&rate.ZoneID,
+			&rate.Name,
+			&rate.MinWeightGrams,
+			&maxWeight,
+			&rate.MinSubtotal,
+			&maxSubtotal,
+			&rate.Price,
+			&freeOver,
+			&minDays,
+			&maxDays,
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		rate.MaxWeightGrams = nullInt(maxWeight)
+		rate.MaxSubtotal = nullInt(maxSubtotal)
+		rate.FreeOver = nullInt(freeOver)
+		rate.MinDays = nullInt(minDays)
+		rate.MaxDays = nullInt(maxDays)
+
+		if i, ok := index[rate.ZoneID]; ok {
+			zones[i].Rates = append(zones[i].Rates, rate)
+		}
+	}
+
+	return zones, rateRows.Err()
+}
+
+func (s *Store) DeleteZone(ctx context.Context, id string) error {
+	ctx, cancel := context.WithTimeout(ctx, utils.QueryTimeout)
+	defer cancel()
+
+	result, err := s.db.ExecContext(ctx, `DELETE FROM shipping_zones WHERE id = $1`, id)
+	if err != nil {
+		return err
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rows == 0 {
+		return utils.ErrorNotFound
+	}
+
+	return nil
+}
+
+func nullInt(value sql.NullInt64) *int {
+	if !value.Valid {
+		return nil
+	}
+
+	n := int(value.Int64)
+	return &n
+}
@@ -0,0 +1,136 @@
+package shipping
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"io"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// recordingDriver is a database/sql driver that accepts every statement and
+// keeps the arguments, so store queries can be checked without Postgres.
+type recordingDriver struct {
+	mu         sync.Mutex
+	statements []recordedStatement
+}
+
+type recordedStatement struct {
+	query string
+	args  []driver.Value
+}
+
+func (d *recordingDriver) Open(string) (driver.Conn, error) {
+	return &recordingConn{driver: d}, nil
+}
+
+func (d *recordingDriver) record(query string, args []driver.NamedValue) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	values := make([]driver.Value, len(args))
+	for i, arg := range args {
+		values[i] = arg.Value
+	}
+	d.statements = append(d.statements, recordedStatement{query: query, args: values})
+}
+
+type recordingConn struct {
+	driver *recordingDriver
+}
+
+func (c *recordingConn) Prepare(string) (driver.Stmt, error) { return nil, driver.ErrSkip }
+func (c *recordingConn) Close() error                        { return nil }
+func (c *recordingConn) Begin() (driver.Tx, error)           { return c, nil }
+func (c *recordingConn) Commit() error                       { return nil }
+func (c *recordingConn) Rollback() error                     { return nil }
+
+func (c *recordingConn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	c.driver.record(query, args)
+	return driver.RowsAffected(1), nil
+}
+
+func (c *recordingConn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	c.driver.record(query, args)
+	return &createdAtRows{}, nil
+}
+
+// createdAtRows answers the RETURNING created_at of an insert.
+type createdAtRows struct {
+	done bool
+}
+
+func (r *createdAtRows) Columns() []string { return []string{"created_at"} }
+func (r *createdAtRows) Close() error      { return nil }
+
+func (r *createdAtRows) Next(dest []driver.Value) error {
+	if r.done {
+		return io.EOF
+	}
+	r.done = true
+	dest[0] = "2024-01-01T00:00:00Z"
+	return nil
+}
+
+func newRecordingStore(t *testing.T) (*Store, *recordingDriver) {
+	t.Helper()
+
+	recorder := &recordingDriver{}
+	name := "recording-" + t.Name()
+	sql.Register(name, recorder)
+
+	db, err := sql.Open(name, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	return NewStore(db), recorder
+}
+
+func TestCreateZoneWithoutRegionsOrCountries(t *testing.T) {
+	tests := []struct {
+		name          string
+		zone          *Zone
+		wantCountries string
+		wantRegions   string
+	}{
+		{"country-wide", &Zone{Name: "United States", Countries: []string{"US"}}, "{\"US\"}", "{}"},
+		{"rest of world", &Zone{Name: "Rest of world"}, "{}", "{}"},
+		{"regions", &Zone{Name: "West coast", Countries: []string{"US"}, Regions: []string{"CA"}}, "{\"US\"}", "{\"CA\"}"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store, recorder := newRecordingStore(t)
+
+			if err := store.CreateZone(context.Background(), tt.zone); err != nil {
+				t.Fatal(err)
+			}
+
+			var insert *recordedStatement
+			for i := range recorder.statements {
+				if strings.Contains(recorder.statements[i].query, "INSERT INTO shipping_zones") {
+					insert = &recorder.statements[i]
+				}
+			}
+			if insert == nil {
+				t.Fatal("zone was not inserted")
+			}
+
+			// Both columns are NOT NULL, so an empty list must go in as '{}'.
+			if got := insert.args[2]; got != tt.wantCountries {
+				t.Errorf("countries = %#v, want %q", got, tt.wantCountries)
+			}
+			if got := insert.args[3]; got != tt.wantRegions {
+				t.Errorf("regions = %#v, want %q", got, tt.wantRegions)
+			}
+
+			if tt.zone.Countries == nil || tt.zone.Regions == nil {
+				t.Error("zone is returned with null lists")
+			}
+		})
+	}
+}
@@ -0,0 +1,59 @@
+package shipping
+
+import "context"
+
+// TableRateProvider prices parcels from the zones and rate brackets kept in
+// the database. Only the most specific zone covering the destination is
+// used, so a region-level zone overrides its country's rates.
+type TableRateProvider struct {
+	store ZoneStore
+}
+
+func NewTableRateProvider(store ZoneStore) *TableRateProvider {
+	return &TableRateProvider{store: store}
+}
+
+func (p *TableRateProvider) Name() string {
+	return "table"
+}
+
+func (p *TableRateProvider) Rates(ctx context.Context, parcel *Parcel) ([]Option, error) {
+	zones, err := p.store.GetZones(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		zone *Zone
+		best int
+	)
+	for i := range zones {
+		if score := zones[i].specificity(parcel.Destination); score > best {
+			zone, best = &zones[i], score
+		}
+	}
+
+	if zone == nil {
+		return nil, nil
+	}
+
+	weight := parcel.BillableWeight()
+	var options []Option
+
+	for _, rate := range zone.Rates {
+		if !rate.Applies(weight, parcel.Subtotal) {
+			continue
+		}
+
+		options = append(options, Option{
+			ID:       p.Name() + ":" + rate.ID,
+			Provider: p.Name(),
+			Name:     rate.Name,
+			Amount:   rate.Charge(parcel.Subtotal),
+			MinDays:  rate.MinDays,
+			MaxDays:  rate.MaxDays,
+		})
+	}
+
+	return options, nil
+}
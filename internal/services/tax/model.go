@@ -0,0 +1,127 @@
+package tax
+
+import (
+	"context"
+	"strings"
+)
+
+// ClassStandard is the tax class of products that don't name one; products
+// in ClassExempt are never taxed.
+const (
+	ClassStandard = "standard"
+	ClassExempt   = "exempt"
+)
+
+type Rate struct {
+	ID        string `json:"id"`
+	Country   string `json:"country"`
+	Region    string `json:"region"`
+	TaxClass  string `json:"tax_class"`
+	Name      string `json:"name"`
+	RateBps   int    `json:"rate_bps"`
+	Inclusive bool   `json:"inclusive"`
+	CreatedAt string `json:"created_at"`
+}
+
+type Line struct {
+	ProductID string
+	TaxClass  string
+	// Amount is what the customer pays for the line after discounts, in
+	// minor units.
+	Amount int
+}
+
+type Request struct {
+	Country string
+	Region  string
+	Lines   []Line
+}
+
+type LineTax struct {
+	RateBps   int  `json:"tax_rate_bps"`
+	Amount    int  `json:"tax_amount"`
+	Inclusive bool `json:"tax_inclusive"`
+}
+
+type Result struct {
+	// Lines matches Request.Lines index for index.
+	Lines []LineTax
+	// Total is all tax on the order, Exclusive only the part that is added
+	// on top of the prices.
+	Total     int
+	Exclusive int
+}
+
+// Calculator works out the tax owed on an order.
+type Calculator interface {
+	Calculate(context.Context, *Request) (*Result, error)
+}
+
+type RateStore interface {
+	CreateRate(context.Context, *Rate) error
+	GetRates(context.Context) ([]Rate, error)
+	GetRatesByCountry(context.Context, string) ([]Rate, error)
+	DeleteRate(context.Context, string) error
+}
+
+// roundDiv divides, rounding halves away from zero, so the same inputs give
+// the same cent on every platform.
+func roundDiv(n, d int) int {
+	if n < 0 {
+		return -roundDiv(-n, d)
+	}
+	return (2*n + d) / (2 * d)
+}
+
+// ComputeLine works out the tax on one line. Exclusive rates add the tax to the
+// amount; inclusive rates extract the tax already contained in it.
+func ComputeLine(amount, rateBps int, inclusive bool) int {
+	if inclusive {
+		return amount - roundDiv(amount*10000, 10000+rateBps)
+	}
+	return roundDiv(amount*rateBps, 10000)
+}
+
+// match picks the rate for a line: a region rate beats a country-wide one,
+// and the product's class beats the standard class.
+func match(rates []Rate, region, class string) *Rate {
+	var best *Rate
+	bestScore := 0
+
+	for i := range rates {
+		rate := &rates[i]
+
+		score := 0
+		switch {
+		case rate.Region == "":
+			score = 1
+		case strings.EqualFold(rate.Region, region):
+			score = 2
+		default:
+			continue
+		}
+
+		switch rate.TaxClass {
+		case class:
+			score += 2
+		case ClassStandard:
+		default:
+			continue
+		}
+
+		if score > bestScore {
+			best, bestScore = rate, score
+		}
+	}
+
+	return best
+}
+
+type RatePayload struct {
+	Country   string `json:"country" validate:"required,iso3166_1_alpha2"`
+	Region    string `json:"region" validate:"max=100"`
+	TaxClass  string `json:"tax_class" validate:"omitempty,slug,max=50"`
+	Name      string `json:"name" validate:"required,max=100"`
+	RateBps   int    `json:"rate_bps" validate:"gte=0,lte=10000"`
+	Inclusive bool   `json:"inclusive"`
+}
@@ -0,0 +1,153 @@
+package tax
+
+import (
+	"context"
+	"testing"
+)
+
+func TestRoundDiv(t *testing.T) {
+	tests := []struct {
+		name string
+		n, d int
+		want int
+	}{
+		{"exact", 10, 2, 5},
+		{"below half rounds down", 4, 3, 1},
+		{"above half rounds up", 5, 3, 2},
+		{"half rounds up", 5, 2, 3},
+		{"another half rounds up", 7, 2, 4},
+		{"negative half rounds away from zero", -5, 2, -3},
+		{"negative below half", -4, 3, -1},
+		{"zero", 0, 7, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := roundDiv(tt.n, tt.d); got != tt.want {
+				t.Errorf("roundDiv(%d, %d) = %d, want %d", tt.n, tt.d, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestComputeLine(t *testing.T) {
+	tests := []struct {
+		name      string
+		amount    int
+		rateBps   int
+		inclusive bool
+		want      int
+	}{
+		{"exclusive", 1000, 2000, false, 200},
+		{"exclusive half cent rounds up", 125, 1000, false, 13},
+		{"exclusive half of smallest unit", 5, 1000, false, 1},
+		{"exclusive below half", 14, 1000, false, 1},
+		{"inclusive extracts contained tax", 1000, 2000, true, 167},
+		{"inclusive half cent", 105, 10000, true, 52},
+		{"exclusive zero rate", 1000, 0, false, 0},
+		{"inclusive zero rate", 1000, 0, true, 0},
+		{"exclusive full rate", 1000, 10000, false, 1000},
+		{"inclusive full rate", 1000, 10000, true, 500},
+		{"zero amount", 0, 2000, false, 0},
+		{"exclusive negative", -1000, 2000, false, -200},
+		{"exclusive negative half cent", -125, 1000, false, -13},
+		{"inclusive negative", -1000, 2000, true, -167},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ComputeLine(tt.amount, tt.rateBps, tt.inclusive); got != tt.want {
+				t.Errorf("ComputeLine(%d, %d, %t) = %d, want %d", tt.amount, tt.rateBps, tt.inclusive, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMatch(t *testing.T) {
+	countryStandard := Rate{ID: "country-standard", Country: "US", TaxClass: ClassStandard}
+	regionStandard := Rate{ID: "region-standard", Country: "US", Region: "CA", TaxClass: ClassStandard}
+	countryBooks := Rate{ID: "country-books", Country: "US", TaxClass: "books"}
+	regionBooks := Rate{ID: "region-books", Country: "US", Region: "CA", TaxClass: "books"}
+	otherRegion := Rate{ID: "other-region", Country: "US", Region: "NY", TaxClass: ClassStandard}
+	otherClass := Rate{ID: "other-class", Country: "US", TaxClass: "food"}
+
+	tests := []struct {
+		name   string
+		rates  []Rate
+		region string
+		class  string
+		want   string
+	}{
+		{"country rate", []Rate{countryStandard}, "CA", ClassStandard, "country-standard"},
+		{"region beats country", []Rate{countryStandard, regionStandard}, "CA", ClassStandard, "region-standard"},
+		{"region beats country in any order", []Rate{regionStandard, countryStandard}, "CA", ClassStandard, "region-standard"},
+		{"region matched case-insensitively", []Rate{countryStandard, regionStandard}, "ca", ClassStandard, "region-standard"},
+		{"other region ignored", []Rate{otherRegion}, "CA", ClassStandard, ""},
+		{"product class beats standard", []Rate{countryStandard, countryBooks}, "", "books", "country-books"},
+		{"standard used when class has no rate", []Rate{countryStandard, otherClass}, "", "books", "country-standard"},
+		{"other class ignored", []Rate{otherClass}, "", "books", ""},
+		{"region and class beat everything", []Rate{countryStandard, regionStandard, countryBooks, regionBooks}, "CA", "books", "region-books"},
+		{"no rates", nil, "CA", ClassStandard, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := ""
+			if rate := match(tt.rates, tt.region, tt.class); rate != nil {
+				got = rate.ID
+			}
+			if got != tt.want {
+				t.Errorf("match() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+type fakeRateStore struct {
+	RateStore
+	rates []Rate
+}
+
+func (s *fakeRateStore) GetRatesByCountry(ctx context.Context, country string) ([]Rate, error) {
+	return s.rates, nil
+}
+
+func TestRulesCalculator(t *testing.T) {
+	store := &fakeRateStore{rates: []Rate{
+		{Country: "GB", TaxClass: ClassStandard, RateBps: 2000, Inclusive: true},
+		{Country: "GB", TaxClass: "books", RateBps: 500},
+		// Exempt products are never taxed, even if a rate names the class.
+		{Country: "GB", TaxClass: ClassExempt, RateBps: 2000},
+	}}
+
+	result, err := NewRulesCalculator(store).Calculate(context.Background(), &Request{
+		Country: "GB",
+		Lines: []Line{
+			{TaxClass: "", Amount: 1000},
+			{TaxClass: "books", Amount: 1000},
+			{TaxClass: ClassExempt, Amount: 1000},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []LineTax{
+		{RateBps: 2000, Amount: 167, Inclusive: true},
+		{RateBps: 500, Amount: 50},
+		{},
+	}
+
+	for i := range want {
+		if result.Lines[i] != want[i] {
+			t.Errorf("line %d = %+v, want %+v", i, result.Lines[i], want[i])
+		}
+	}
+
+	if result.Total != 217 {
+		t.Errorf("Total = %d, want 217", result.Total)
+	}
+	if result.Exclusive != 50 {
+		t.Errorf("Exclusive = %d, want 50", result.Exclusive)
+	}
+}
@@ -0,0 +1,99 @@
+package tax
+
+import (
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/umeh-promise/ecommerce/internal/services/user"
+	"github.com/umeh-promise/ecommerce/utils"
+)
+
+type Handler struct {
+	store RateStore
+}
+
+func NewHandler(store RateStore) *Handler {
+	return &Handler{store: store}
+}
+
+func (h *Handler) RegisterRoute(auth *user.Handler) func(r chi.Router) {
+	return func(r chi.Router) {
+		r.Route("/tax/rates", func(r chi.Router) {
+			r.Use(auth.AuthTokenMiddleware, auth.AdminMiddleware)
+			r.Get("/", h.getRates)
+			r.Post("/", h.createRate)
+			r.Delete("/{id}", h.deleteRate)
+		})
+	}
+}
+
+func (h *Handler) getRates(w http.ResponseWriter, r *http.Request) {
+	rates, err := h.store.GetRates(r.Context())
+	if err != nil {
+		utils.InternalServerError(w, r, err)
+		return
+	}
+
+	if err := utils.JSONResponse(w, http.StatusOK, rates); err != nil {
+		utils.InternalServerError(w, r, err)
+		return
+	}
+}
+
+func (h *Handler) createRate(w http.ResponseWriter, r *http.Request) {
+	var payload RatePayload
+
+	if err := utils.ParseJSON(w, r, &payload); err != nil {
+		utils.BadRequestError(w, r, err)
+		return
+	}
+
+	if err := utils.Validator.Struct(payload); err != nil {
+		utils.BadRequestError(w, r, err)
+		return
+	}
+
+	rate := &Rate{
+		Country:   payload.Country,
+		Region:    payload.Region,
+		TaxClass:  payload.TaxClass,
+		Name:      payload.Name,
+		RateBps:   payload.RateBps,
+		Inclusive: payload.Inclusive,
+	}
+	if rate.TaxClass == "" {
+		rate.TaxClass = ClassStandard
+	}
+
+	if err := h.store.CreateRate(r.Context(), rate); err != nil {
+		switch err {
+		case utils.ErrorDuplicateTaxRate:
+			utils.BadRequestError(w, r, err)
+		default:
+			utils.InternalServerError(w, r, err)
+		}
+		return
+	}
+
+	if err := utils.JSONResponse(w, http.StatusCreated, rate); err != nil {
+		utils.InternalServerError(w, r, err)
+		return
+	}
+}
+
+func (h *Handler) deleteRate(w http.ResponseWriter, r *http.Request) {
+	if err := h.store.DeleteRate(r.Context(), chi.URLParam(r, "id")); err != nil {
+		switch err {
+		case utils.ErrorNotFound:
+			utils.NotFoundResponse(w, r, err)
+		default:
+			utils.InternalServerError(w, r, err)
+		}
+		return
+	}
+
+	if err := utils.JSONResponse(w, http.StatusNoContent, nil); err != nil {
+		utils.InternalServerError(w, r, err)
+		return
+	}
+}
@@ -0,0 +1,48 @@
+package tax
+
+import "context"
+
+// RulesCalculator applies the rates configured per country, region and tax
+// class. Tax is rounded per line, never on the order total, so line amounts
+// always add up to the order's tax.
+type RulesCalculator struct {
+	store RateStore
+}
+
+func NewRulesCalculator(store RateStore) *RulesCalculator {
+	return &RulesCalculator{store: store}
+}
+
+func (c *RulesCalculator) Calculate(ctx context.Context, request *Request) (*Result, error) {
+	rates, err := c.store.GetRatesByCountry(ctx, request.Country)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &Result{Lines: make([]LineTax, len(request.Lines))}
+
+	for i, line := range request.Lines {
+		class := line.TaxClass
+		if class == "" {
+			class = ClassStandard
+		}
+		if class == ClassExempt {
+			continue
+		}
+
+		rate := match(rates, request.Region, class)
+		if rate == nil {
+			continue
+		}
+
+		amount := ComputeLine(line.Amount, rate.RateBps, rate.Inclusive)
+
+		result.Lines[i] = LineTax{RateBps: rate.RateBps, Amount: amount, Inclusive: rate.Inclusive}
+		result.Total += amount
+		if !rate.Inclusive {
+			result.Exclusive += amount
+		}
+	}
+
+	return result, nil
+}
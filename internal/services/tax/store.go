@@ -0,0 +1,109 @@
+package tax
+
+import (
+	"context"
+	"database/sql"
+
+	uuid "github.com/satori/go.uuid"
+	"github.com/umeh-promise/ecommerce/utils"
+)
+
+const rateColumns = `id, country, region, tax_class, name, rate_bps, inclusive, created_at`
+
+type Store struct {
+	db *sql.DB
+}
+
+func NewStore(db *sql.DB) *Store {
+	return &Store{db: db}
+}
+
+func (s *Store) CreateRate(ctx context.Context, rate *Rate) error {
+	query := `
+		INSERT INTO tax_rates (id, country, region, tax_class, name, rate_bps, inclusive)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		RETURNING created_at
+	`
+
+	rate.ID = uuid.NewV4().String()
+
+	ctx, cancel := context.WithTimeout(ctx, utils.QueryTimeout)
+	defer cancel()
+
+	err := s.db.QueryRowContext(ctx, query,
+		rate.ID, rate.Country, rate.Region, rate.TaxClass, rate.Name, rate.RateBps, rate.Inclusive,
+	).Scan(&rate.CreatedAt)
+	if err != nil {
+		switch {
+		case err.Error() == `pq: duplicate key value violates unique constraint "tax_rates_jurisdiction_key"`:
+			return utils.ErrorDuplicateTaxRate
+		default:
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (s *Store) GetRates(ctx context.Context) ([]Rate, error) {
+	return s.queryRates(ctx, `SELECT `+rateColumns+` FROM tax_rates ORDER BY country, region, tax_class`)
+}
+
+func (s *Store) GetRatesByCountry(ctx context.Context, country string) ([]Rate, error) {
+	return s.queryRates(ctx, `SELECT `+rateColumns+` FROM tax_rates WHERE country = $1 ORDER BY region, tax_class`, country)
+}
+
+func (s *Store) queryRates(ctx context.Context, query string, args ...any) ([]Rate, error) {
+	ctx, cancel := context.WithTimeout(ctx, utils.QueryTimeout)
+	defer cancel()
+
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var rates []Rate
+
+	for rows.Next() {
+		rate := Rate{}
+		err := rows.Scan(
+			&rate.ID,
+			&rate.Country,
+			&rate.Region,
+			&rate.TaxClass,
+			&rate.Name,
+			&rate.RateBps,
+			&rate.Inclusive,
+			&rate.CreatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		rates = append(rates, rate)
+	}
+
+	return rates, rows.Err()
+}
+
+func (s *Store) DeleteRate(ctx context.Context, id string) error {
+	ctx, cancel := context.WithTimeout(ctx, utils.QueryTimeout)
+	defer cancel()
+
+	result, err := s.db.ExecContext(ctx, `DELETE FROM tax_rates WHERE id = $1`, id)
+	if err != nil {
+		return err
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rows == 0 {
+		return utils.ErrorNotFound
+	}
+
+	return nil
+}
@@ -15,6 +15,16 @@ type userKey string
 var userCtx userKey = "user"
 
 func (middleware *Handler) AuthTokenMiddleware(next http.Handler) http.Handler {
+	return middleware.authenticate(next, false)
+}
+
+// PasswordResetAuthMiddleware is AuthTokenMiddleware for the routes a user
+// who has been told to reset their password can still use.
+func (middleware *Handler) PasswordResetAuthMiddleware(next http.Handler) http.Handler {
+	return middleware.authenticate(next, true)
+}
+
+func (middleware *Handler) authenticate(next http.Handler, allowPasswordReset bool) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		authHeader := r.Header.Get("Authorization")
 		if authHeader == "" {
@@ -46,11 +56,55 @@ func (middleware *Handler) AuthTokenMiddleware(next http.Handler) http.Handler {
 			return
 		}
 
+		// Checked on every request so that suspending an account takes
+		// effect before its tokens expire.
+		if user.SuspendedAt != nil {
+			utils.ForbiddenResponse(w, r, utils.ErrorAccountSuspended)
+			return
+		}
+
+		if user.PasswordResetRequired && !allowPasswordReset {
+			utils.ForbiddenResponse(w, r, utils.ErrorPasswordResetRequired)
+			return
+		}
+
 		ctx = context.WithValue(ctx, userCtx, user)
 		next.ServeHTTP(w, r.WithContext(ctx))
 	})
 }
 
+// OptionalAuthMiddleware identifies the caller when a token is sent but lets
+// anonymous requests through. A token that is sent but invalid is rejected.
+func (middleware *Handler) OptionalAuthMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") == "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		middleware.AuthTokenMiddleware(next).ServeHTTP(w, r)
+	})
+}
+
+// AdminMiddleware must be mounted after AuthTokenMiddleware.
+func (middleware *Handler) AdminMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		user := GetUserFromContext(r)
+		if user.Role != RoleAdmin {
+			utils.ForbiddenServerError(w, r)
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
 func GetUserFromContext(r *http.Request) *User {
 	return r.Context().Value(userCtx).(*User)
 }
+
+// UserFromContext is GetUserFromContext for routes where auth is optional.
+func UserFromContext(r *http.Request) (*User, bool) {
+	user, ok := r.Context().Value(userCtx).(*User)
+	return user, ok
+}
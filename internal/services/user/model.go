@@ -1,6 +1,16 @@
 package user
 
-import "context"
+import (
+	"context"
+	"time"
+
+	"github.com/umeh-promise/ecommerce/utils"
+)
+
+const (
+	RoleCustomer = "customer"
+	RoleAdmin    = "admin"
+)
 
 type User struct {
 	ID             string `json:"id"`
@@ -12,9 +22,19 @@ type User struct {
 	DOB            string `json:"dob"`
 	Gender         string `json:"gender"`
 	ProfilePicture string `json:"profile_picture"`
-	Version        string `json:"-"`
-	CreatedAt      string `json:"-"`
-	UpdatedAt      string `json:"-"`
+	Role           string `json:"role"`
+	// SuspendedAt is set while an admin has suspended the account.
+	SuspendedAt *time.Time `json:"suspended_at"`
+	// PasswordResetRequired locks the account out of everything but
+	// changing its password.
+	PasswordResetRequired bool   `json:"password_reset_required"`
+	Version               string `json:"-"`
+	CreatedAt             string `json:"-"`
+	UpdatedAt             string `json:"-"`
+}
+
+func (u *User) ETag() string {
+	return utils.ETag(u.Version)
 }
 
 type UserStore interface {
@@ -24,6 +44,25 @@ type UserStore interface {
 	UpdateUser(context.Context, *User) error
 	ChangePassword(context.Context, *User) error
 	DeleteUser(context.Context, string) error
+	// ClaimGuestOrders moves guest orders placed with the email and guest
+	// token onto the user's account, returning how many were claimed.
+	ClaimGuestOrders(ctx context.Context, userID, email, guestToken string) (int64, error)
+	SearchUsers(context.Context, *UserFilter) ([]User, error)
+	// SetSuspended suspends or reinstates the user.
+	SetSuspended(ctx context.Context, user *User, suspended bool) error
+	// RequirePasswordReset makes the user change their password before they
+	// can do anything else. ChangePassword clears it.
+	RequirePasswordReset(context.Context, *User) error
+}
+
+// UserFilter narrows SearchUsers. Query matches the start of the email or
+// either name.
+type UserFilter struct {
+	Query     string `validate:"max=100"`
+	Role      string `validate:"omitempty,oneof=customer admin"`
+	Suspended *bool
+	Limit     int `validate:"gte=1,lte=200"`
+	Offset    int `validate:"gte=0"`
 }
 
 type RegisterUserPayload struct {
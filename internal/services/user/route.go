@@ -3,32 +3,42 @@ package user
 import (
 	"fmt"
 	"net/http"
+	"strings"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/go-playground/validator/v10"
+	uuid "github.com/satori/go.uuid"
+	"github.com/umeh-promise/ecommerce/internal/audit"
+	"github.com/umeh-promise/ecommerce/internal/services/guest"
+	"github.com/umeh-promise/ecommerce/internal/storage"
 	"github.com/umeh-promise/ecommerce/utils"
 )
 
 type Handler struct {
 	store UserStore
+	blobs storage.BlobStore
+	audit audit.AuditStore
 }
 
-func NewHandler(store UserStore) *Handler {
-	return &Handler{store: store}
+func NewHandler(store UserStore, blobs storage.BlobStore, auditStore audit.AuditStore) *Handler {
+	return &Handler{store: store, blobs: blobs, audit: auditStore}
 }
 
 func (h *Handler) RegisterRoute() func(r chi.Router) {
 	return func(r chi.Router) {
 
 		r.Route("/auth", func(r chi.Router) {
-			r.Post("/register", h.registerUser)
+			r.With(guest.Middleware).Post("/register", h.registerUser)
 			r.Post("/login", h.loginUser)
 
 			r.Route("/user", func(r chi.Router) {
-				r.Use(h.AuthTokenMiddleware)
-				r.Get("/", h.getUser)
-				r.Put("/", h.updateUser)
-				r.Put("/change-password", h.changePassword)
+				r.With(h.PasswordResetAuthMiddleware).Put("/change-password", h.changePassword)
+				r.Group(func(r chi.Router) {
+					r.Use(h.AuthTokenMiddleware)
+					r.Get("/", h.getUser)
+					r.Put("/", h.updateUser)
+					r.Post("/profile-picture", h.uploadProfilePicture)
+				})
 			})
 		})
 	}
@@ -75,6 +85,16 @@ func (h *Handler) registerUser(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	audit.Record(h.audit, r, user.ID, "user.register", "user", user.ID, nil, user)
+
+	// Orders placed as a guest from this browser join the new account. The
+	// account still works if this fails, so it is only logged.
+	if token := guest.TokenFromContext(r); token != "" {
+		if _, err := h.store.ClaimGuestOrders(ctx, user.ID, user.Email, token); err != nil {
+			utils.Logger.Warnw("failed to claim guest orders", "user_id", user.ID, "error", err.Error())
+		}
+	}
+
 	if err := utils.JSONResponse(w, http.StatusCreated, &UserResponse{
 		FirstName:   user.FirstName,
 		LastName:    user.LastName,
@@ -106,20 +126,33 @@ func (h *Handler) loginUser(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// Failed attempts have no actor: nobody has proven who they are yet.
 	if err := utils.ComparePasswords(user.Password, payload.Password); err != nil {
+		audit.Record(h.audit, r, "", "user.login_failed", "user", user.ID, nil, nil)
 		utils.UnAuthorizedRequestError(w, r, fmt.Errorf("invalid email or password"))
 		return
 	}
 
+	if user.SuspendedAt != nil {
+		audit.Record(h.audit, r, "", "user.login_failed", "user", user.ID, nil, nil)
+		utils.ForbiddenResponse(w, r, utils.ErrorAccountSuspended)
+		return
+	}
+
 	token, err := utils.GenerateToken(user.ID)
 	if err != nil {
 		utils.InternalServerError(w, r, err)
 		return
 	}
 
+	audit.Record(h.audit, r, user.ID, "user.login", "user", user.ID, nil, nil)
+
+	// A user who must reset their password still gets a token, but it only
+	// works for changing the password.
 	type userWithToken struct {
-		User  UserResponse `json:"user"`
-		Token string       `json:"token"`
+		User                  UserResponse `json:"user"`
+		Token                 string       `json:"token"`
+		PasswordResetRequired bool         `json:"password_reset_required"`
 	}
 
 	userResponse := &userWithToken{
@@ -129,7 +162,8 @@ func (h *Handler) loginUser(w http.ResponseWriter, r *http.Request) {
 			Email:       user.Email,
 			PhoneNumber: user.PhoneNumber,
 		},
-		Token: token,
+		Token:                 token,
+		PasswordResetRequired: user.PasswordResetRequired,
 	}
 
 	if err := utils.JSONResponse(w, http.StatusOK, userResponse); err != nil {
@@ -142,6 +176,10 @@ func (h *Handler) loginUser(w http.ResponseWriter, r *http.Request) {
 func (h *Handler) getUser(w http.ResponseWriter, r *http.Request) {
 	user := GetUserFromContext(r)
 
+	if utils.NotModified(w, r, user.ETag()) {
+		return
+	}
+
 	if err := utils.JSONResponse(w, http.StatusOK, user); err != nil {
 		utils.InternalServerError(w, r, err)
 		return
@@ -151,6 +189,13 @@ func (h *Handler) getUser(w http.ResponseWriter, r *http.Request) {
 func (h *Handler) updateUser(w http.ResponseWriter, r *http.Request) {
 	var payload UpdateUserPayload
 
+	user := GetUserFromContext(r)
+
+	if err := utils.CheckIfMatch(r, user.ETag()); err != nil {
+		utils.PreconditionFailedResponse(w, r, err)
+		return
+	}
+
 	if err := utils.ParseJSON(w, r, &payload); err != nil {
 		utils.BadRequestError(w, r, err)
 		return
@@ -162,7 +207,7 @@ func (h *Handler) updateUser(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	user := GetUserFromContext(r)
+	before := *user
 
 	utils.AssignIfNotNil(&user.FirstName, payload.FirstName)
 	utils.AssignIfNotNil(&user.LastName, payload.LastName)
@@ -171,6 +216,22 @@ func (h *Handler) updateUser(w http.ResponseWriter, r *http.Request) {
 	utils.AssignIfNotNil(&user.Gender, payload.Gender)
 	utils.AssignIfNotNil(&user.ProfilePicture, payload.ProfilePicture)
 
+	if err := h.store.UpdateUser(r.Context(), user); err != nil {
+		switch err {
+		case utils.ErrorEditConflict:
+			utils.ConflictResponse(w, r, err)
+		case utils.ErrorDuplicatePhoneNumber:
+			utils.BadRequestError(w, r, err)
+		default:
+			utils.InternalServerError(w, r, err)
+		}
+		return
+	}
+
+	audit.Record(h.audit, r, user.ID, "user.update", "user", user.ID, &before, user)
+
+	w.Header().Set("ETag", user.ETag())
+
 	if err := utils.JSONResponse(w, http.StatusOK, user); err != nil {
 		utils.InternalServerError(w, r, err)
 		return
@@ -210,8 +271,54 @@ func (h *Handler) changePassword(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	audit.Record(h.audit, r, user.ID, "user.change_password", "user", user.ID, nil, nil)
+
 	if err := utils.JSONResponse(w, http.StatusOK, nil); err != nil {
 		utils.InternalServerError(w, r, err)
 		return
 	}
 }
+
+func (h *Handler) uploadProfilePicture(w http.ResponseWriter, r *http.Request) {
+	user := GetUserFromContext(r)
+
+	upload, err := storage.ReadImage(w, r, "image", utils.MaxUploadBytes)
+	if err != nil {
+		utils.BadRequestError(w, r, err)
+		return
+	}
+
+	key := fmt.Sprintf("users/%s/%s%s", user.ID, uuid.NewV4().String(), upload.Extension)
+	if err := h.blobs.Put(r.Context(), key, upload.Reader(), upload.ContentType); err != nil {
+		utils.InternalServerError(w, r, err)
+		return
+	}
+
+	previous := user.ProfilePicture
+	user.ProfilePicture = h.blobs.URL(key)
+
+	if err := h.store.UpdateUser(r.Context(), user); err != nil {
+		switch err {
+		case utils.ErrorEditConflict:
+			utils.ConflictResponse(w, r, err)
+		default:
+			utils.InternalServerError(w, r, err)
+		}
+		return
+	}
+
+	// Only pictures uploaded for this user are removed; the field may also
+	// hold a URL the user set by hand.
+	if old, ok := storage.KeyFromURL(h.blobs, previous); ok && strings.HasPrefix(old, "users/"+user.ID+"/") {
+		if err := h.blobs.Delete(r.Context(), old); err != nil {
+			utils.Logger.Warnw("failed to delete blob", "key", old, "error", err.Error())
+		}
+	}
+
+	w.Header().Set("ETag", user.ETag())
+
+	if err := utils.JSONResponse(w, http.StatusOK, user); err != nil {
+		utils.InternalServerError(w, r, err)
+		return
+	}
+}
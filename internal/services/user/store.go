@@ -3,8 +3,10 @@ package user
 import (
 	"context"
 	"database/sql"
+	"errors"
 
 	uuid "github.com/satori/go.uuid"
+	"github.com/umeh-promise/ecommerce/internal/events"
 	"github.com/umeh-promise/ecommerce/utils"
 )
 
@@ -27,35 +29,38 @@ func (s *Store) CreateUser(ctx context.Context, user *User) error {
 	ctx, cancel := context.WithTimeout(ctx, utils.QueryTimeout)
 	defer cancel()
 
-	err := s.db.QueryRowContext(ctx, query,
-		user.ID, user.FirstName, user.LastName,
-		user.Email, user.Password,
-		user.PhoneNumber, user.DOB,
-		user.Gender, user.ProfilePicture).Scan(
-		&user.ID,
-		&user.Version,
-		&user.CreatedAt,
-		&user.UpdatedAt,
-	)
-	if err != nil {
-		switch {
-		case err.Error() == `pq: duplicate key value violates unique constraint "users_email_key"`:
-			return utils.ErrorDuplicateEmail
-		case err.Error() == `pq: duplicate key value violates unique constraint "users_phone_number_key"`:
-			return utils.ErrorDuplicatePhoneNumber
-		default:
-			return err
+	return utils.WithTx(ctx, s.db, func(tx *sql.Tx) error {
+		err := tx.QueryRowContext(ctx, query,
+			user.ID, user.FirstName, user.LastName,
+			user.Email, user.Password,
+			user.PhoneNumber, user.DOB,
+			user.Gender, user.ProfilePicture).Scan(
+			&user.ID,
+			&user.Version,
+			&user.CreatedAt,
+			&user.UpdatedAt,
+		)
+		if err != nil {
+			switch {
+			case err.Error() == `pq: duplicate key value violates unique constraint "users_email_key"`:
+				return utils.ErrorDuplicateEmail
+			case err.Error() == `pq: duplicate key value violates unique constraint "users_phone_number_key"`:
+				return utils.ErrorDuplicatePhoneNumber
+			default:
+				return err
+			}
 		}
-	}
 
-	return nil
+		return events.Publish(ctx, tx, events.UserRegistered, user.ID, user)
+	})
 }
 
 func (s *Store) GetUserByID(ctx context.Context, userID string) (*User, error) {
 	var user User
 
 	query := `
-		SELECT id, first_name, last_name, email, phone_number, dob, gender, profile_picture, password, version FROM users
+		SELECT id, first_name, last_name, email, phone_number, dob, gender, profile_picture, password, role,
+			suspended_at, password_reset_required, version FROM users
 		WHERE id = $1
 	`
 	ctx, cancel := context.WithTimeout(ctx, utils.QueryTimeout)
@@ -65,7 +70,8 @@ func (s *Store) GetUserByID(ctx context.Context, userID string) (*User, error) {
 		&user.ID, &user.FirstName,
 		&user.LastName, &user.Email, &user.PhoneNumber,
 		&user.DOB, &user.Gender,
-		&user.ProfilePicture, &user.Password, &user.Version,
+		&user.ProfilePicture, &user.Password, &user.Role,
+		&user.SuspendedAt, &user.PasswordResetRequired, &user.Version,
 	)
 	if err != nil {
 		switch err {
@@ -85,7 +91,8 @@ func (s *Store) GetUserByEmail(ctx context.Context, email string) (*User, error)
 	var user User
 
 	query := `
-		SELECT id, first_name, last_name, email, password, phone_number, version FROM users
+		SELECT id, first_name, last_name, email, password, phone_number, role,
+			suspended_at, password_reset_required, version FROM users
 		WHERE email = $1
 	`
 	ctx, cancel := context.WithTimeout(ctx, utils.QueryTimeout)
@@ -95,7 +102,7 @@ func (s *Store) GetUserByEmail(ctx context.Context, email string) (*User, error)
 		&user.ID, &user.FirstName,
 		&user.LastName, &user.Email,
 		&user.Password, &user.PhoneNumber,
-		&user.Version,
+		&user.Role, &user.SuspendedAt, &user.PasswordResetRequired, &user.Version,
 	)
 	if err != nil {
 		return &User{}, err
@@ -108,18 +115,21 @@ func (s *Store) GetUserByEmail(ctx context.Context, email string) (*User, error)
 func (s *Store) UpdateUser(ctx context.Context, user *User) error {
 	query := `
 		UPDATE users 
-		SET first_name = $1, last_name = $2, dob = $3, gender = $4, profile_picture = $5, version = version + 1
-		WHERE id = $6 AND version = $7
+		SET first_name = $1, last_name = $2, phone_number = $3, dob = $4, gender = $5, profile_picture = $6,
+			version = version + 1, updated_at = now()
+		WHERE id = $7 AND version = $8
 		RETURNING version
 	`
 
 	ctx, cancel := context.WithTimeout(ctx, utils.QueryTimeout)
 	defer cancel()
-	err := s.db.QueryRowContext(ctx, query, user.FirstName, user.LastName, user.DOB, user.Gender, user.ID, user.ProfilePicture, user.Version).Scan(&user.Version)
+	err := s.db.QueryRowContext(ctx, query, user.FirstName, user.LastName, user.PhoneNumber, user.DOB, user.Gender, user.ProfilePicture, user.ID, user.Version).Scan(&user.Version)
 	if err != nil {
-		switch err {
-		case sql.ErrNoRows:
-			return utils.ErrorNotFound
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return utils.ErrorEditConflict
+		case err.Error() == `pq: duplicate key value violates unique constraint "users_phone_number_key"`:
+			return utils.ErrorDuplicatePhoneNumber
 		default:
 			return err
 		}
@@ -156,7 +166,7 @@ func (s *Store) DeleteUser(ctx context.Context, userID string) error {
 func (s *Store) ChangePassword(ctx context.Context, user *User) error {
 	query := `
 	UPDATE users 
-	SET password = $1
+	SET password = $1, password_reset_required = false
 	WHERE id = $2
 	RETURNING version
 	`
@@ -164,11 +174,103 @@ func (s *Store) ChangePassword(ctx context.Context, user *User) error {
 	ctx, cancel := context.WithTimeout(ctx, utils.QueryTimeout)
 	defer cancel()
 
-	err := s.db.QueryRowContext(ctx, query, user.Password, user.ID).Scan(&user.Version)
+	return utils.WithTx(ctx, s.db, func(tx *sql.Tx) error {
+		err := tx.QueryRowContext(ctx, query, user.Password, user.ID).Scan(&user.Version)
+		if err != nil {
+			switch err {
+			case sql.ErrNoRows:
+				return utils.ErrorNotFound
+			default:
+				return err
+			}
+		}
+
+		user.PasswordResetRequired = false
+
+		return events.Publish(ctx, tx, events.UserPasswordChanged, user.ID, user)
+	})
+}
+
+// ClaimGuestOrders needs the guest token as well as the email because
+// addresses aren't verified: matching on email alone would let anyone read
+// a stranger's orders by registering with their address.
+func (s *Store) ClaimGuestOrders(ctx context.Context, userID, email, guestToken string) (int64, error) {
+	query := `
+		UPDATE orders
+		SET user_id = $1, version = version + 1, updated_at = now()
+		WHERE user_id IS NULL AND guest_email = lower($2) AND guest_token = $3
+	`
+
+	ctx, cancel := context.WithTimeout(ctx, utils.QueryTimeout)
+	defer cancel()
+
+	result, err := s.db.ExecContext(ctx, query, userID, email, guestToken)
 	if err != nil {
-		switch err {
-		case sql.ErrNoRows:
-			return utils.ErrorNotFound
+		return 0, err
+	}
+
+	return result.RowsAffected()
+}
+
+func (s *Store) SearchUsers(ctx context.Context, filter *UserFilter) ([]User, error) {
+	query := `
+		SELECT id, first_name, last_name, email, phone_number, COALESCE(dob, ''), COALESCE(gender, ''),
+			COALESCE(profile_picture, ''), role, suspended_at, password_reset_required, version, created_at
+		FROM users
+		WHERE ($1 = '' OR email ILIKE $1 || '%' OR first_name ILIKE $1 || '%' OR last_name ILIKE $1 || '%')
+			AND ($2 = '' OR role = $2)
+			AND ($3::boolean IS NULL OR (suspended_at IS NOT NULL) = $3)
+		ORDER BY created_at DESC, id
+		LIMIT $4 OFFSET $5
+	`
+
+	search := utils.EscapeLike(filter.Query)
+
+	ctx, cancel := context.WithTimeout(ctx, utils.QueryTimeout)
+	defer cancel()
+
+	rows, err := s.db.QueryContext(ctx, query, search, filter.Role, filter.Suspended, filter.Limit, filter.Offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var users []User
+
+	for rows.Next() {
+		var user User
+		err := rows.Scan(
+			&user.ID, &user.FirstName, &user.LastName, &user.Email, &user.PhoneNumber,
+			&user.DOB, &user.Gender, &user.ProfilePicture, &user.Role,
+			&user.SuspendedAt, &user.PasswordResetRequired, &user.Version, &user.CreatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		users = append(users, user)
+	}
+
+	return users, rows.Err()
+}
+
+func (s *Store) SetSuspended(ctx context.Context, user *User, suspended bool) error {
+	query := `
+		UPDATE users
+		SET suspended_at = CASE WHEN $1 THEN COALESCE(suspended_at, now()) END,
+			version = version + 1, updated_at = now()
+		WHERE id = $2 AND version = $3
+		RETURNING suspended_at, version
+	`
+
+	ctx, cancel := context.WithTimeout(ctx, utils.QueryTimeout)
+	defer cancel()
+
+	err := s.db.QueryRowContext(ctx, query, suspended, user.ID, user.Version).Scan(&user.SuspendedAt, &user.Version)
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return utils.ErrorEditConflict
 		default:
 			return err
 		}
@@ -176,3 +278,29 @@ func (s *Store) ChangePassword(ctx context.Context, user *User) error {
 
 	return nil
 }
+
+func (s *Store) RequirePasswordReset(ctx context.Context, user *User) error {
+	query := `
+		UPDATE users
+		SET password_reset_required = true, version = version + 1, updated_at = now()
+		WHERE id = $1 AND version = $2
+		RETURNING version
+	`
+
+	ctx, cancel := context.WithTimeout(ctx, utils.QueryTimeout)
+	defer cancel()
+
+	err := s.db.QueryRowContext(ctx, query, user.ID, user.Version).Scan(&user.Version)
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return utils.ErrorEditConflict
+		default:
+			return err
+		}
+	}
+
+	user.PasswordResetRequired = true
+
+	return nil
+}
@@ -0,0 +1,45 @@
+package webhooks
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/umeh-promise/ecommerce/internal/services/user"
+	"github.com/umeh-promise/ecommerce/utils"
+)
+
+type webhookKey string
+
+var subscriptionCtx webhookKey = "subscription"
+
+// SubscriptionMiddleware loads the caller's own subscription. It must be
+// mounted after AuthTokenMiddleware.
+func (middleware *Handler) SubscriptionMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+
+		subscription, err := middleware.store.GetSubscriptionByID(ctx, chi.URLParam(r, "webhookID"))
+		if err != nil {
+			switch err {
+			case utils.ErrorNotFound:
+				utils.NotFoundResponse(w, r, err)
+			default:
+				utils.InternalServerError(w, r, err)
+			}
+			return
+		}
+
+		if subscription.UserID != user.GetUserFromContext(r).ID {
+			utils.NotFoundResponse(w, r, utils.ErrorNotFound)
+			return
+		}
+
+		ctx = context.WithValue(ctx, subscriptionCtx, subscription)
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+func GetSubscriptionFromContext(r *http.Request) *Subscription {
+	return r.Context().Value(subscriptionCtx).(*Subscription)
+}
@@ -0,0 +1,117 @@
+package webhooks
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/umeh-promise/ecommerce/internal/events"
+	"github.com/umeh-promise/ecommerce/utils"
+)
+
+type DeliveryStatus string
+
+const (
+	StatusPending   DeliveryStatus = "pending"
+	StatusSending   DeliveryStatus = "sending"
+	StatusSucceeded DeliveryStatus = "succeeded"
+	StatusFailed    DeliveryStatus = "failed"
+)
+
+type Subscription struct {
+	ID     string `json:"id"`
+	UserID string `json:"-"`
+	URL    string `json:"url"`
+	// Secret is only returned when the subscription is created.
+	Secret     string   `json:"secret,omitempty"`
+	EventTypes []string `json:"event_types"`
+	Active     bool     `json:"active"`
+	Version    string   `json:"-"`
+	CreatedAt  string   `json:"created_at"`
+	UpdatedAt  string   `json:"-"`
+}
+
+type Delivery struct {
+	ID             string          `json:"id"`
+	SubscriptionID string          `json:"subscription_id"`
+	EventID        int64           `json:"event_id"`
+	EventType      string          `json:"event_type"`
+	Body           json.RawMessage `json:"body"`
+	Status         DeliveryStatus  `json:"status"`
+	Attempts       int             `json:"attempts"`
+	NextAttemptAt  string          `json:"next_attempt_at"`
+	ResponseCode   *int            `json:"response_code"`
+	LastError      string          `json:"last_error"`
+	DeliveredAt    *string         `json:"delivered_at"`
+	CreatedAt      string          `json:"created_at"`
+	// URL and Secret come from the subscription when a delivery is claimed
+	// for sending.
+	URL    string `json:"-"`
+	Secret string `json:"-"`
+}
+
+// Envelope is the JSON body posted to subscribers.
+type Envelope struct {
+	ID        int64           `json:"id"`
+	Type      string          `json:"type"`
+	CreatedAt string          `json:"created_at"`
+	Data      json.RawMessage `json:"data"`
+}
+
+// Attempt is the outcome of one attempt to send a delivery.
+type Attempt struct {
+	ResponseCode *int
+	Error        string
+	Succeeded    bool
+}
+
+// record applies the outcome of a send to the delivery and returns how
+// long to wait before the next try.
+func (d *Delivery) record(attempt Attempt) time.Duration {
+	d.Attempts++
+	d.ResponseCode = attempt.ResponseCode
+	d.LastError = attempt.Error
+
+	switch {
+	case attempt.Succeeded:
+		d.Status = StatusSucceeded
+	case d.Attempts >= maxAttempts:
+		d.Status = StatusFailed
+	default:
+		d.Status = StatusPending
+	}
+
+	return utils.Backoff(d.Attempts)
+}
+
+type WebhookStore interface {
+	CreateSubscription(context.Context, *Subscription) error
+	GetSubscriptionsByUserID(context.Context, string) ([]Subscription, error)
+	GetSubscriptionByID(context.Context, string) (*Subscription, error)
+	UpdateSubscription(context.Context, *Subscription) error
+	DeleteSubscription(context.Context, string) error
+	// GetAudienceSubscriptions returns active subscriptions for eventType
+	// owned by one of userIDs, by an admin, or by anyone when public is set.
+	GetAudienceSubscriptions(ctx context.Context, eventType string, userIDs []string, public bool) ([]Subscription, error)
+	// Enqueue creates a pending delivery. An event already queued for the
+	// subscription is left alone, so redelivered events don't double up.
+	Enqueue(ctx context.Context, subscriptionID string, event events.Event, body []byte) error
+	GetDeliveries(ctx context.Context, subscriptionID string, limit int) ([]Delivery, error)
+	GetDeliveryByID(context.Context, string) (*Delivery, error)
+	// Redeliver queues a delivery to be sent again straight away.
+	Redeliver(context.Context, *Delivery) error
+	// ClaimDue leases up to limit due deliveries to the caller for sending.
+	ClaimDue(ctx context.Context, limit int) ([]Delivery, error)
+	RecordAttempt(context.Context, *Delivery, Attempt) error
+}
+
+type SubscriptionPayload struct {
+	URL        string   `json:"url" validate:"required,url,max=2000"`
+	EventTypes []string `json:"event_types" validate:"required,min=1,dive,oneof=* user.registered product.created product.updated product.deleted order.placed order.paid order.cancelled"`
+}
+
+type UpdateSubscriptionPayload struct {
+	URL        *string  `json:"url" validate:"omitempty,url,max=2000"`
+	EventTypes []string `json:"event_types" validate:"omitempty,min=1,dive,oneof=* user.registered product.created product.updated product.deleted order.placed order.paid order.cancelled"`
+	Active     *bool    `json:"active"`
+}
@@ -0,0 +1,241 @@
+package webhooks
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/umeh-promise/ecommerce/internal/services/user"
+	"github.com/umeh-promise/ecommerce/utils"
+)
+
+const deliveryLogLimit = 50
+
+type Handler struct {
+	store WebhookStore
+}
+
+func NewHandler(store WebhookStore) *Handler {
+	return &Handler{store: store}
+}
+
+func (h *Handler) RegisterRoute(auth *user.Handler) func(r chi.Router) {
+	return func(r chi.Router) {
+		r.Route("/webhooks", func(r chi.Router) {
+			r.Use(auth.AuthTokenMiddleware)
+			r.Get("/", h.getSubscriptions)
+			r.Post("/", h.createSubscription)
+
+			r.Route("/{webhookID}", func(r chi.Router) {
+				r.Use(h.SubscriptionMiddleware)
+				r.Get("/", h.getSubscription)
+				r.Put("/", h.updateSubscription)
+				r.Delete("/", h.deleteSubscription)
+				r.Get("/deliveries", h.getDeliveries)
+				r.Post("/deliveries/{deliveryID}/redeliver", h.redeliver)
+			})
+		})
+	}
+}
+
+// validateURL accepts absolute http(s) URLs. Where they may point is checked
+// when connecting, since DNS can change after the subscription is saved.
+func validateURL(raw string) error {
+	parsed, err := url.Parse(raw)
+	if err != nil || (parsed.Scheme != "https" && parsed.Scheme != "http") || parsed.Host == "" {
+		return fmt.Errorf("webhook url must be an absolute http or https url")
+	}
+
+	if parsed.User != nil {
+		return fmt.Errorf("webhook url must not contain credentials")
+	}
+
+	return nil
+}
+
+func (h *Handler) createSubscription(w http.ResponseWriter, r *http.Request) {
+	var payload SubscriptionPayload
+
+	if err := utils.ParseJSON(w, r, &payload); err != nil {
+		utils.BadRequestError(w, r, err)
+		return
+	}
+
+	if err := utils.Validator.Struct(payload); err != nil {
+		utils.BadRequestError(w, r, err)
+		return
+	}
+
+	if err := validateURL(payload.URL); err != nil {
+		utils.BadRequestError(w, r, err)
+		return
+	}
+
+	secret, err := NewSecret()
+	if err != nil {
+		utils.InternalServerError(w, r, err)
+		return
+	}
+
+	subscription := &Subscription{
+		UserID:     user.GetUserFromContext(r).ID,
+		URL:        payload.URL,
+		Secret:     secret,
+		EventTypes: payload.EventTypes,
+		Active:     true,
+	}
+
+	if err := h.store.CreateSubscription(r.Context(), subscription); err != nil {
+		utils.InternalServerError(w, r, err)
+		return
+	}
+
+	if err := utils.JSONResponse(w, http.StatusCreated, subscription); err != nil {
+		utils.InternalServerError(w, r, err)
+		return
+	}
+}
+
+func (h *Handler) getSubscriptions(w http.ResponseWriter, r *http.Request) {
+	subscriptions, err := h.store.GetSubscriptionsByUserID(r.Context(), user.GetUserFromContext(r).ID)
+	if err != nil {
+		utils.InternalServerError(w, r, err)
+		return
+	}
+
+	for i := range subscriptions {
+		subscriptions[i].Secret = ""
+	}
+
+	if err := utils.JSONResponse(w, http.StatusOK, subscriptions); err != nil {
+		utils.InternalServerError(w, r, err)
+		return
+	}
+}
+
+func (h *Handler) getSubscription(w http.ResponseWriter, r *http.Request) {
+	subscription := GetSubscriptionFromContext(r)
+	subscription.Secret = ""
+
+	if err := utils.JSONResponse(w, http.StatusOK, subscription); err != nil {
+		utils.InternalServerError(w, r, err)
+		return
+	}
+}
+
+func (h *Handler) updateSubscription(w http.ResponseWriter, r *http.Request) {
+	var payload UpdateSubscriptionPayload
+
+	if err := utils.ParseJSON(w, r, &payload); err != nil {
+		utils.BadRequestError(w, r, err)
+		return
+	}
+
+	if err := utils.Validator.Struct(payload); err != nil {
+		utils.BadRequestError(w, r, err)
+		return
+	}
+
+	subscription := GetSubscriptionFromContext(r)
+
+	if payload.URL != nil {
+		if err := validateURL(*payload.URL); err != nil {
+			utils.BadRequestError(w, r, err)
+			return
+		}
+		subscription.URL = *payload.URL
+	}
+	if payload.EventTypes != nil {
+		subscription.EventTypes = payload.EventTypes
+	}
+	utils.AssignIfNotNil(&subscription.Active, payload.Active)
+
+	if err := h.store.UpdateSubscription(r.Context(), subscription); err != nil {
+		switch err {
+		case utils.ErrorEditConflict:
+			utils.ConflictResponse(w, r, err)
+		default:
+			utils.InternalServerError(w, r, err)
+		}
+		return
+	}
+
+	subscription.Secret = ""
+
+	if err := utils.JSONResponse(w, http.StatusOK, subscription); err != nil {
+		utils.InternalServerError(w, r, err)
+		return
+	}
+}
+
+func (h *Handler) deleteSubscription(w http.ResponseWriter, r *http.Request) {
+	subscription := GetSubscriptionFromContext(r)
+
+	if err := h.store.DeleteSubscription(r.Context(), subscription.ID); err != nil {
+		switch err {
+		case utils.ErrorNotFound:
+			utils.NotFoundResponse(w, r, err)
+		default:
+			utils.InternalServerError(w, r, err)
+		}
+		return
+	}
+
+	if err := utils.JSONResponse(w, http.StatusNoContent, nil); err != nil {
+		utils.InternalServerError(w, r, err)
+		return
+	}
+}
+
+// getDeliveries is the subscription's delivery log, newest first.
+func (h *Handler) getDeliveries(w http.ResponseWriter, r *http.Request) {
+	subscription := GetSubscriptionFromContext(r)
+
+	deliveries, err := h.store.GetDeliveries(r.Context(), subscription.ID, deliveryLogLimit)
+	if err != nil {
+		utils.InternalServerError(w, r, err)
+		return
+	}
+
+	if err := utils.JSONResponse(w, http.StatusOK, deliveries); err != nil {
+		utils.InternalServerError(w, r, err)
+		return
+	}
+}
+
+func (h *Handler) redeliver(w http.ResponseWriter, r *http.Request) {
+	subscription := GetSubscriptionFromContext(r)
+	ctx := r.Context()
+
+	delivery, err := h.store.GetDeliveryByID(ctx, chi.URLParam(r, "deliveryID"))
+	if err != nil {
+		switch err {
+		case utils.ErrorNotFound:
+			utils.NotFoundResponse(w, r, err)
+		default:
+			utils.InternalServerError(w, r, err)
+		}
+		return
+	}
+
+	if delivery.SubscriptionID != subscription.ID {
+		utils.NotFoundResponse(w, r, utils.ErrorNotFound)
+		return
+	}
+
+	if err := h.store.Redeliver(ctx, delivery); err != nil {
+		switch err {
+		case utils.ErrorNotFound:
+			utils.NotFoundResponse(w, r, err)
+		default:
+			utils.InternalServerError(w, r, err)
+		}
+		return
+	}
+
+	if err := utils.JSONResponse(w, http.StatusAccepted, delivery); err != nil {
+		utils.InternalServerError(w, r, err)
+		return
+	}
+}
@@ -0,0 +1,80 @@
+package webhooks
+
+import (
+	"crypto/hmac"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+// tolerance is how old a timestamp a receiver in these tests accepts.
+const tolerance = 5 * time.Minute
+
+// verify checks a request the way receivers are told to.
+func verify(secret, timestamp, signature string, body []byte, now time.Time) bool {
+	ts, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return false
+	}
+
+	if age := now.Sub(time.Unix(ts, 0)); age > tolerance || age < -tolerance {
+		return false
+	}
+
+	return hmac.Equal([]byte(Sign(secret, ts, body)), []byte(signature))
+}
+
+func TestSign(t *testing.T) {
+	body := []byte(`{"id":1,"type":"order.placed"}`)
+	now := time.Unix(1700000000, 0)
+	signature := Sign("whsec_test", now.Unix(), body)
+
+	if !strings.HasPrefix(signature, "v1=") || len(signature) != len("v1=")+64 {
+		t.Fatalf("unexpected signature format %q", signature)
+	}
+
+	if Sign("whsec_test", now.Unix(), body) != signature {
+		t.Fatal("signature is not deterministic")
+	}
+
+	tests := []struct {
+		name      string
+		secret    string
+		timestamp int64
+		body      []byte
+		now       time.Time
+		want      bool
+	}{
+		{"valid", "whsec_test", now.Unix(), body, now, true},
+		{"valid within tolerance", "whsec_test", now.Unix(), body, now.Add(tolerance - time.Second), true},
+		{"wrong secret", "whsec_other", now.Unix(), body, now, false},
+		{"tampered body", "whsec_test", now.Unix(), []byte(`{"id":2,"type":"order.placed"}`), now, false},
+		{"tampered timestamp", "whsec_test", now.Unix() + 1, body, now, false},
+		{"replayed too late", "whsec_test", now.Unix(), body, now.Add(tolerance + time.Second), false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := verify(tt.secret, strconv.FormatInt(tt.timestamp, 10), signature, tt.body, tt.now)
+			if got != tt.want {
+				t.Errorf("verify() = %t, want %t", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNewSecret(t *testing.T) {
+	a, err := NewSecret()
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := NewSecret()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !strings.HasPrefix(a, "whsec_") || a == b {
+		t.Errorf("unexpected secrets %q and %q", a, b)
+	}
+}
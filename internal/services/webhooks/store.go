@@ -0,0 +1,381 @@
+package webhooks
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/lib/pq"
+	uuid "github.com/satori/go.uuid"
+	"github.com/umeh-promise/ecommerce/internal/events"
+	"github.com/umeh-promise/ecommerce/utils"
+)
+
+// leaseDuration is how long a claimed delivery is reserved for its sender.
+// A sender that dies mid-request loses the lease and the delivery is tried
+// again.
+const leaseDuration = 5 * 60
+
+const subscriptionColumns = `id, user_id, url, secret, event_types, active, version, created_at, updated_at`
+
+const deliveryColumns = `d.id, d.subscription_id, d.event_id, d.event_type, d.body, d.status, d.attempts,
+	d.next_attempt_at, d.response_code, d.last_error, d.delivered_at, d.created_at`
+
+type scanner interface {
+	Scan(dest ...any) error
+}
+
+func scanSubscription(row scanner, subscription *Subscription) error {
+	var eventTypes pq.StringArray
+
+	err := row.Scan(
+		&subscription.ID,
+		&subscription.UserID,
+		&subscription.URL,
+		&subscription.Secret,
+		&eventTypes,
+		&subscription.Active,
+		&subscription.Version,
+		&subscription.CreatedAt,
+		&subscription.UpdatedAt,
+	)
+	if err != nil {
+		return err
+	}
+
+	subscription.EventTypes = eventTypes
+
+	return nil
+}
+
+func scanDelivery(row scanner, delivery *Delivery, extra ...any) error {
+	var (
+		responseCode sql.NullInt64
+		deliveredAt  sql.NullString
+	)
+
+	dest := []any{
+		&delivery.ID,
+		&delivery.SubscriptionID,
+		&delivery.EventID,
+		&delivery.EventType,
+		&delivery.Body,
+		&delivery.Status,
+		&delivery.Attempts,
+		&delivery.NextAttemptAt,
+		&responseCode,
+		&delivery.LastError,
+		&deliveredAt,
+		&delivery.CreatedAt,
+	}
+
+	if err := row.Scan(append(dest, extra...)...); err != nil {
+		return err
+	}
+
+	delivery.ResponseCode = nil
+	if responseCode.Valid {
+		code := int(responseCode.Int64)
+		delivery.ResponseCode = &code
+	}
+
+	delivery.DeliveredAt = nil
+	if deliveredAt.Valid {
+		delivery.DeliveredAt = &deliveredAt.String
+	}
+
+	return nil
+}
+
+type Store struct {
+	db *sql.DB
+}
+
+func NewStore(db *sql.DB) *Store {
+	return &Store{db: db}
+}
+
+func (s *Store) CreateSubscription(ctx context.Context, subscription *Subscription) error {
+	query := `
+		INSERT INTO webhook_subscriptions (id, user_id, url, secret, event_types, active)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING version, created_at, updated_at
+	`
+
+	subscription.ID = uuid.NewV4().String()
+
+	ctx, cancel := context.WithTimeout(ctx, utils.QueryTimeout)
+	defer cancel()
+
+	return s.db.QueryRowContext(ctx, query,
+		subscription.ID, subscription.UserID, subscription.URL, subscription.Secret,
+		pq.Array(subscription.EventTypes), subscription.Active,
+	).Scan(&subscription.Version, &subscription.CreatedAt, &subscription.UpdatedAt)
+}
+
+func (s *Store) GetSubscriptionsByUserID(ctx context.Context, userID string) ([]Subscription, error) {
+	query := `SELECT ` + subscriptionColumns + ` FROM webhook_subscriptions WHERE user_id = $1 ORDER BY created_at`
+
+	return s.querySubscriptions(ctx, query, userID)
+}
+
+func (s *Store) GetAudienceSubscriptions(ctx context.Context, eventType string, userIDs []string, public bool) ([]Subscription, error) {
+	query := `
+		SELECT ` + subscriptionColumns + `
+		FROM webhook_subscriptions
+		WHERE active
+			AND ($1 = ANY(event_types) OR '*' = ANY(event_types))
+			AND ($3 OR user_id = ANY($2) OR user_id IN (SELECT id FROM users WHERE role = 'admin'))
+	`
+
+	return s.querySubscriptions(ctx, query, eventType, pq.Array(userIDs), public)
+}
+
+func (s *Store) querySubscriptions(ctx context.Context, query string, args ...any) ([]Subscription, error) {
+	ctx, cancel := context.WithTimeout(ctx, utils.QueryTimeout)
+	defer cancel()
+
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var subscriptions []Subscription
+
+	for rows.Next() {
+		subscription := Subscription{}
+		if err := scanSubscription(rows, &subscription); err != nil {
+			return nil, err
+		}
+
+		subscriptions = append(subscriptions, subscription)
+	}
+
+	return subscriptions, rows.Err()
+}
+
+func (s *Store) GetSubscriptionByID(ctx context.Context, id string) (*Subscription, error) {
+	var subscription Subscription
+
+	query := `SELECT ` + subscriptionColumns + ` FROM webhook_subscriptions WHERE id = $1`
+
+	ctx, cancel := context.WithTimeout(ctx, utils.QueryTimeout)
+	defer cancel()
+
+	err := scanSubscription(s.db.QueryRowContext(ctx, query, id), &subscription)
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return nil, utils.ErrorNotFound
+		default:
+			return nil, err
+		}
+	}
+
+	return &subscription, nil
+}
+
+func (s *Store) UpdateSubscription(ctx context.Context, subscription *Subscription) error {
+	query := `
+		UPDATE webhook_subscriptions
+		SET url = $1, event_types = $2, active = $3, version = version + 1, updated_at = now()
+		WHERE id = $4 AND version = $5
+		RETURNING version, updated_at
+	`
+
+	ctx, cancel := context.WithTimeout(ctx, utils.QueryTimeout)
+	defer cancel()
+
+	err := s.db.QueryRowContext(ctx, query,
+		subscription.URL, pq.Array(subscription.EventTypes), subscription.Active,
+		subscription.ID, subscription.Version,
+	).Scan(&subscription.Version, &subscription.UpdatedAt)
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return utils.ErrorEditConflict
+		default:
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (s *Store) DeleteSubscription(ctx context.Context, id string) error {
+	ctx, cancel := context.WithTimeout(ctx, utils.QueryTimeout)
+	defer cancel()
+
+	result, err := s.db.ExecContext(ctx, `DELETE FROM webhook_subscriptions WHERE id = $1`, id)
+	if err != nil {
+		return err
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rows == 0 {
+		return utils.ErrorNotFound
+	}
+
+	return nil
+}
+
+func (s *Store) Enqueue(ctx context.Context, subscriptionID string, event events.Event, body []byte) error {
+	query := `
+		INSERT INTO webhook_deliveries (id, subscription_id, event_id, event_type, body)
+		VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT ON CONSTRAINT webhook_deliveries_subscription_event_key DO NOTHING
+	`
+
+	ctx, cancel := context.WithTimeout(ctx, utils.QueryTimeout)
+	defer cancel()
+
+	_, err := s.db.ExecContext(ctx, query, uuid.NewV4().String(), subscriptionID, event.ID, event.Type, body)
+	return err
+}
+
+func (s *Store) GetDeliveries(ctx context.Context, subscriptionID string, limit int) ([]Delivery, error) {
+	query := `
+		SELECT ` + deliveryColumns + `
+		FROM webhook_deliveries d
+		WHERE d.subscription_id = $1
+		ORDER BY d.created_at DESC
+		LIMIT $2
+	`
+
+	ctx, cancel := context.WithTimeout(ctx, utils.QueryTimeout)
+	defer cancel()
+
+	rows, err := s.db.QueryContext(ctx, query, subscriptionID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var deliveries []Delivery
+
+	for rows.Next() {
+		delivery := Delivery{}
+		if err := scanDelivery(rows, &delivery); err != nil {
+			return nil, err
+		}
+
+		deliveries = append(deliveries, delivery)
+	}
+
+	return deliveries, rows.Err()
+}
+
+func (s *Store) GetDeliveryByID(ctx context.Context, id string) (*Delivery, error) {
+	var delivery Delivery
+
+	query := `SELECT ` + deliveryColumns + ` FROM webhook_deliveries d WHERE d.id = $1`
+
+	ctx, cancel := context.WithTimeout(ctx, utils.QueryTimeout)
+	defer cancel()
+
+	err := scanDelivery(s.db.QueryRowContext(ctx, query, id), &delivery)
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return nil, utils.ErrorNotFound
+		default:
+			return nil, err
+		}
+	}
+
+	return &delivery, nil
+}
+
+func (s *Store) Redeliver(ctx context.Context, delivery *Delivery) error {
+	query := `
+		UPDATE webhook_deliveries
+		SET status = 'pending', attempts = 0, next_attempt_at = now(), updated_at = now()
+		WHERE id = $1
+		RETURNING status, attempts, next_attempt_at
+	`
+
+	ctx, cancel := context.WithTimeout(ctx, utils.QueryTimeout)
+	defer cancel()
+
+	err := s.db.QueryRowContext(ctx, query, delivery.ID).
+		Scan(&delivery.Status, &delivery.Attempts, &delivery.NextAttemptAt)
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return utils.ErrorNotFound
+		default:
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (s *Store) ClaimDue(ctx context.Context, limit int) ([]Delivery, error) {
+	query := `
+		UPDATE webhook_deliveries d
+		SET status = 'sending', next_attempt_at = now() + $2 * interval '1 second', updated_at = now()
+		FROM webhook_subscriptions s
+		WHERE s.id = d.subscription_id AND d.id IN (
+			SELECT dd.id
+			FROM webhook_deliveries dd
+			JOIN webhook_subscriptions ss ON ss.id = dd.subscription_id
+			WHERE dd.status IN ('pending', 'sending') AND dd.next_attempt_at <= now() AND ss.active
+			ORDER BY dd.next_attempt_at
+			LIMIT $1
+			FOR UPDATE OF dd SKIP LOCKED
+		)
+		RETURNING ` + deliveryColumns + `, s.url, s.secret
+	`
+
+	ctx, cancel := context.WithTimeout(ctx, utils.QueryTimeout)
+	defer cancel()
+
+	rows, err := s.db.QueryContext(ctx, query, limit, leaseDuration)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var deliveries []Delivery
+
+	for rows.Next() {
+		delivery := Delivery{}
+		if err := scanDelivery(rows, &delivery, &delivery.URL, &delivery.Secret); err != nil {
+			return nil, err
+		}
+
+		deliveries = append(deliveries, delivery)
+	}
+
+	return deliveries, rows.Err()
+}
+
+// RecordAttempt stores the outcome of a send. Failed deliveries are retried
+// with exponential backoff until maxAttempts is reached.
+func (s *Store) RecordAttempt(ctx context.Context, delivery *Delivery, attempt Attempt) error {
+	retryIn := delivery.record(attempt)
+
+	query := `
+		UPDATE webhook_deliveries
+		SET status = $1, attempts = $2, response_code = $3, last_error = $4,
+			next_attempt_at = now() + $5 * interval '1 second',
+			delivered_at = CASE WHEN $1 = 'succeeded' THEN now() ELSE delivered_at END,
+			updated_at = now()
+		WHERE id = $6
+	`
+
+	ctx, cancel := context.WithTimeout(ctx, utils.QueryTimeout)
+	defer cancel()
+
+	_, err := s.db.ExecContext(ctx, query,
+		delivery.Status, delivery.Attempts, delivery.ResponseCode, delivery.LastError,
+		int(retryIn.Seconds()), delivery.ID,
+	)
+	return err
+}
@@ -0,0 +1,217 @@
+package webhooks
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"syscall"
+	"time"
+
+	"github.com/umeh-promise/ecommerce/internal/events"
+	"github.com/umeh-promise/ecommerce/internal/services/products"
+	"github.com/umeh-promise/ecommerce/utils"
+)
+
+const (
+	pollInterval   = time.Second
+	batchSize      = 20
+	maxAttempts    = 8
+	requestTimeout = 10 * time.Second
+	// maxErrorBytes is how much of a failing response body is kept in the
+	// delivery log.
+	maxErrorBytes = 1024
+)
+
+// Fanout returns the event subscriber that queues a delivery for every
+// subscription allowed to see the event: the customer and sellers on an
+// order, the owner of a product, everyone for public catalogue changes, and
+// admins for everything.
+func Fanout(store WebhookStore, productStore products.ProductStore) events.Handler {
+	return func(ctx context.Context, event events.Event) error {
+		userIDs, public, err := audience(ctx, productStore, event)
+		if err != nil {
+			return err
+		}
+
+		subscriptions, err := store.GetAudienceSubscriptions(ctx, event.Type, userIDs, public)
+		if err != nil || len(subscriptions) == 0 {
+			return err
+		}
+
+		body, err := json.Marshal(&Envelope{
+			ID:        event.ID,
+			Type:      event.Type,
+			CreatedAt: event.CreatedAt,
+			Data:      event.Payload,
+		})
+		if err != nil {
+			return err
+		}
+
+		for _, subscription := range subscriptions {
+			if err := store.Enqueue(ctx, subscription.ID, event, body); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	}
+}
+
+func audience(ctx context.Context, productStore products.ProductStore, event events.Event) ([]string, bool, error) {
+	switch event.Type {
+	case events.OrderPlaced, events.OrderPaid, events.OrderCancelled:
+		var order struct {
+			UserID    string   `json:"user_id"`
+			SellerIDs []string `json:"seller_ids"`
+		}
+		if err := json.Unmarshal(event.Payload, &order); err != nil {
+			return nil, false, err
+		}
+
+		userIDs := order.SellerIDs
+		if order.UserID != "" {
+			userIDs = append(userIDs, order.UserID)
+		}
+		return userIDs, false, nil
+
+	case events.ProductCreated, events.ProductUpdated:
+		product, err := productStore.GetPostByID(ctx, event.AggregateID)
+		if err != nil {
+			return nil, false, err
+		}
+		return []string{product.UserID}, product.Status == products.StatusPublished, nil
+
+	case events.ProductDeleted:
+		// Anyone may have cached a product that is now gone.
+		return nil, true, nil
+
+	default:
+		return nil, false, nil
+	}
+}
+
+// Worker sends queued deliveries. Several replicas can run one each;
+// deliveries are leased so each is sent by one worker at a time.
+type Worker struct {
+	store  WebhookStore
+	client *http.Client
+}
+
+// NewWorker builds a worker whose client refuses to connect to private and
+// loopback addresses unless allowPrivate is set, so subscribers can't point
+// webhooks at internal services.
+func NewWorker(store WebhookStore, allowPrivate bool) *Worker {
+	dialer := &net.Dialer{Timeout: requestTimeout}
+	if !allowPrivate {
+		dialer.Control = refusePrivate
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = nil
+	transport.DialContext = dialer.DialContext
+
+	return &Worker{
+		store: store,
+		client: &http.Client{
+			Timeout:   requestTimeout,
+			Transport: transport,
+			// A redirect would be followed without re-signing, and could
+			// lead anywhere.
+			CheckRedirect: func(*http.Request, []*http.Request) error {
+				return http.ErrUseLastResponse
+			},
+		},
+	}
+}
+
+func refusePrivate(network, address string, conn syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+
+	ip := net.ParseIP(host)
+	if ip == nil || ip.IsLoopback() || ip.IsPrivate() || ip.IsUnspecified() ||
+		ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() || ip.IsMulticast() {
+		return fmt.Errorf("webhook address %s is not publicly routable", host)
+	}
+
+	return nil
+}
+
+// Run sends due deliveries until ctx is cancelled.
+func (wk *Worker) Run(ctx context.Context) {
+	ticker := time.NewTicker(pollInterval)
+	defer ticker.Stop()
+
+	for {
+		for {
+			deliveries, err := wk.store.ClaimDue(ctx, batchSize)
+			if err != nil {
+				if ctx.Err() == nil {
+					utils.Logger.Warnw("failed to claim webhook deliveries", "error", err.Error())
+				}
+				break
+			}
+
+			for i := range deliveries {
+				wk.send(ctx, &deliveries[i])
+			}
+
+			if len(deliveries) < batchSize {
+				break
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (wk *Worker) send(ctx context.Context, delivery *Delivery) {
+	attempt := wk.post(ctx, delivery)
+
+	// Record the outcome even while shutting down, so the lease isn't left
+	// to expire.
+	if err := wk.store.RecordAttempt(context.WithoutCancel(ctx), delivery, attempt); err != nil {
+		utils.Logger.Warnw("failed to record webhook attempt", "delivery_id", delivery.ID, "error", err.Error())
+	}
+}
+
+func (wk *Worker) post(ctx context.Context, delivery *Delivery) Attempt {
+	request, err := http.NewRequestWithContext(ctx, http.MethodPost, delivery.URL, bytes.NewReader(delivery.Body))
+	if err != nil {
+		return Attempt{Error: err.Error()}
+	}
+
+	timestamp := time.Now().Unix()
+	request.Header.Set("Content-Type", "application/json")
+	request.Header.Set("User-Agent", "ecommerce-webhooks/1")
+	request.Header.Set(HeaderID, delivery.ID)
+	request.Header.Set(HeaderEvent, delivery.EventType)
+	request.Header.Set(HeaderTimestamp, fmt.Sprint(timestamp))
+	request.Header.Set(HeaderSignature, Sign(delivery.Secret, timestamp, delivery.Body))
+
+	response, err := wk.client.Do(request)
+	if err != nil {
+		return Attempt{Error: err.Error()}
+	}
+	defer response.Body.Close()
+
+	code := response.StatusCode
+	if code >= 200 && code < 300 {
+		io.Copy(io.Discard, io.LimitReader(response.Body, maxErrorBytes))
+		return Attempt{ResponseCode: &code, Succeeded: true}
+	}
+
+	body, _ := io.ReadAll(io.LimitReader(response.Body, maxErrorBytes))
+	return Attempt{ResponseCode: &code, Error: fmt.Sprintf("unexpected status %d: %s", code, body)}
+}
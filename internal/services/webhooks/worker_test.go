@@ -0,0 +1,187 @@
+package webhooks
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/umeh-promise/ecommerce/utils"
+)
+
+// recordingStore keeps the attempts the worker records.
+type recordingStore struct {
+	WebhookStore
+	attempts []Attempt
+}
+
+func (s *recordingStore) RecordAttempt(ctx context.Context, delivery *Delivery, attempt Attempt) error {
+	s.attempts = append(s.attempts, attempt)
+	return nil
+}
+
+func newDelivery(url string) *Delivery {
+	return &Delivery{
+		ID:        "delivery-1",
+		EventType: "order.placed",
+		Body:      []byte(`{"id":1}`),
+		URL:       url,
+		Secret:    "whsec_test",
+	}
+}
+
+func TestWorkerSendsSignedRequest(t *testing.T) {
+	var request *http.Request
+	var body []byte
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		request = r
+		body, _ = io.ReadAll(r.Body)
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	store := &recordingStore{}
+	delivery := newDelivery(server.URL)
+	NewWorker(store, true).send(context.Background(), delivery)
+
+	if len(store.attempts) != 1 || !store.attempts[0].Succeeded {
+		t.Fatalf("attempts = %+v, want one success", store.attempts)
+	}
+
+	if request.Header.Get(HeaderID) != delivery.ID || request.Header.Get(HeaderEvent) != delivery.EventType {
+		t.Errorf("unexpected headers %v", request.Header)
+	}
+
+	timestamp := request.Header.Get(HeaderTimestamp)
+	if !verify(delivery.Secret, timestamp, request.Header.Get(HeaderSignature), body, time.Now()) {
+		t.Error("receiver could not verify the signature")
+	}
+
+	if verify("whsec_other", timestamp, request.Header.Get(HeaderSignature), body, time.Now()) {
+		t.Error("signature verified with the wrong secret")
+	}
+}
+
+func TestWorkerRecordsFailedResponse(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "boom", http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	store := &recordingStore{}
+	delivery := newDelivery(server.URL)
+	NewWorker(store, true).send(context.Background(), delivery)
+
+	if len(store.attempts) != 1 {
+		t.Fatalf("recorded %d attempts, want 1", len(store.attempts))
+	}
+
+	attempt := store.attempts[0]
+	if attempt.Succeeded || attempt.ResponseCode == nil || *attempt.ResponseCode != http.StatusInternalServerError {
+		t.Fatalf("attempt = %+v, want a failed 500", attempt)
+	}
+	if !strings.Contains(attempt.Error, "boom") {
+		t.Errorf("error %q does not include the response body", attempt.Error)
+	}
+
+	retryIn := delivery.record(attempt)
+	if delivery.Status != StatusPending || delivery.Attempts != 1 || retryIn != utils.Backoff(1) {
+		t.Errorf("after one failure: status %s, attempts %d, retry in %s", delivery.Status, delivery.Attempts, retryIn)
+	}
+
+	retryIn = delivery.record(attempt)
+	if retryIn <= utils.Backoff(1) {
+		t.Errorf("backoff did not grow: %s", retryIn)
+	}
+
+	delivery.Attempts = maxAttempts - 1
+	delivery.record(attempt)
+	if delivery.Status != StatusFailed {
+		t.Errorf("status after %d attempts = %s, want %s", maxAttempts, delivery.Status, StatusFailed)
+	}
+}
+
+func TestWorkerDoesNotFollowRedirects(t *testing.T) {
+	var followed atomic.Bool
+
+	target := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		followed.Store(true)
+	}))
+	defer target.Close()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, target.URL, http.StatusTemporaryRedirect)
+	}))
+	defer server.Close()
+
+	store := &recordingStore{}
+	NewWorker(store, true).send(context.Background(), newDelivery(server.URL))
+
+	if followed.Load() {
+		t.Error("redirect was followed")
+	}
+
+	attempt := store.attempts[0]
+	if attempt.Succeeded || attempt.ResponseCode == nil || *attempt.ResponseCode != http.StatusTemporaryRedirect {
+		t.Errorf("attempt = %+v, want a failed 307", attempt)
+	}
+}
+
+func TestWorkerRefusesPrivateAddresses(t *testing.T) {
+	var hit atomic.Bool
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hit.Store(true)
+	}))
+	defer server.Close()
+
+	store := &recordingStore{}
+	NewWorker(store, false).send(context.Background(), newDelivery(server.URL))
+
+	if hit.Load() {
+		t.Error("request reached a loopback receiver")
+	}
+
+	attempt := store.attempts[0]
+	if attempt.Succeeded || attempt.ResponseCode != nil || !strings.Contains(attempt.Error, "not publicly routable") {
+		t.Errorf("attempt = %+v, want a refused connection", attempt)
+	}
+}
+
+func TestRefusePrivate(t *testing.T) {
+	tests := []struct {
+		address string
+		allowed bool
+	}{
+		{"127.0.0.1:443", false},
+		{"[::1]:443", false},
+		{"10.0.0.5:443", false},
+		{"172.16.0.1:443", false},
+		{"192.168.1.1:443", false},
+		{"169.254.169.254:80", false},
+		{"0.0.0.0:80", false},
+		{"[fe80::1]:443", false},
+		{"[fc00::1]:443", false},
+		{"224.0.0.1:443", false},
+		{"93.184.216.34:443", true},
+		{"[2606:4700::1111]:443", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.address, func(t *testing.T) {
+			err := refusePrivate("tcp", tt.address, nil)
+			if (err == nil) != tt.allowed {
+				t.Errorf("refusePrivate(%s) = %v, want allowed %t", tt.address, err, tt.allowed)
+			}
+		})
+	}
+
+	if err := refusePrivate("tcp", "no-port", nil); err == nil {
+		t.Error("malformed address was allowed")
+	}
+}
@@ -0,0 +1,46 @@
+package wishlists
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/umeh-promise/ecommerce/internal/services/user"
+	"github.com/umeh-promise/ecommerce/utils"
+)
+
+type wishlistKey string
+
+var wishlistCtx wishlistKey = "wishlist"
+
+// WishlistMiddleware loads the caller's own wishlist. It must be mounted
+// after AuthTokenMiddleware.
+func (middleware *Handler) WishlistMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		wishlistID := chi.URLParam(r, "id")
+		ctx := r.Context()
+
+		wishlist, err := middleware.store.GetWishlistByID(ctx, wishlistID)
+		if err != nil {
+			switch err {
+			case utils.ErrorNotFound:
+				utils.NotFoundResponse(w, r, err)
+			default:
+				utils.InternalServerError(w, r, err)
+			}
+			return
+		}
+
+		if wishlist.UserID != user.GetUserFromContext(r).ID {
+			utils.NotFoundResponse(w, r, utils.ErrorNotFound)
+			return
+		}
+
+		ctx = context.WithValue(ctx, wishlistCtx, wishlist)
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+func GetWishlistFromContext(r *http.Request) *Wishlist {
+	return r.Context().Value(wishlistCtx).(*Wishlist)
+}
@@ -0,0 +1,49 @@
+package wishlists
+
+import "context"
+
+type Wishlist struct {
+	ID         string         `json:"id"`
+	UserID     string         `json:"-"`
+	Name       string         `json:"name"`
+	IsPublic   bool           `json:"is_public"`
+	ShareToken *string        `json:"share_token,omitempty"`
+	Items      []WishlistItem `json:"items,omitempty"`
+	Version    string         `json:"-"`
+	CreatedAt  string         `json:"created_at"`
+	UpdatedAt  string         `json:"-"`
+}
+
+type WishlistItem struct {
+	ProductID      string `json:"product_id"`
+	Name           string `json:"name"`
+	Image          string `json:"image"`
+	EffectivePrice int    `json:"effective_price"`
+	Available      bool   `json:"available"`
+	AddedAt        string `json:"added_at"`
+}
+
+type WishlistStore interface {
+	CreateWishlist(context.Context, *Wishlist) error
+	GetWishlistsByUserID(context.Context, string) ([]Wishlist, error)
+	GetWishlistByID(context.Context, string) (*Wishlist, error)
+	GetWishlistByShareToken(context.Context, string) (*Wishlist, error)
+	GetWishlistItems(context.Context, string) ([]WishlistItem, error)
+	UpdateWishlist(context.Context, *Wishlist) error
+	DeleteWishlist(context.Context, string) error
+	AddItem(context.Context, string, string) error
+	RemoveItem(context.Context, string, string) error
+}
+
+type WishlistPayload struct {
+	Name string `json:"name" validate:"required,min=1,max=100"`
+}
+
+type UpdateWishlistPayload struct {
+	Name     *string `json:"name" validate:"omitempty,min=1,max=100"`
+	IsPublic *bool   `json:"is_public"`
+}
+
+type AddItemPayload struct {
+	ProductID string `json:"product_id" validate:"required,uuid"`
+}
@@ -0,0 +1,245 @@
+package wishlists
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/umeh-promise/ecommerce/internal/services/products"
+	"github.com/umeh-promise/ecommerce/internal/services/user"
+	"github.com/umeh-promise/ecommerce/utils"
+)
+
+type Handler struct {
+	store        WishlistStore
+	productStore products.ProductStore
+}
+
+func NewHandler(store WishlistStore, productStore products.ProductStore) *Handler {
+	return &Handler{store: store, productStore: productStore}
+}
+
+func (h *Handler) RegisterRoute(auth *user.Handler) func(r chi.Router) {
+	return func(r chi.Router) {
+		r.Route("/wishlists", func(r chi.Router) {
+			r.Get("/shared/{token}", h.getSharedWishlist)
+
+			r.Group(func(r chi.Router) {
+				r.Use(auth.AuthTokenMiddleware)
+				r.Get("/", h.getWishlists)
+				r.Post("/", h.createWishlist)
+				r.Route("/{id}", func(r chi.Router) {
+					r.Use(h.WishlistMiddleware)
+					r.Get("/", h.getWishlist)
+					r.Put("/", h.updateWishlist)
+					r.Delete("/", h.deleteWishlist)
+					r.Post("/items", h.addItem)
+					r.Delete("/items/{productID}", h.removeItem)
+				})
+			})
+		})
+	}
+}
+
+func (h *Handler) getWishlists(w http.ResponseWriter, r *http.Request) {
+	user := user.GetUserFromContext(r)
+
+	wishlists, err := h.store.GetWishlistsByUserID(r.Context(), user.ID)
+	if err != nil {
+		utils.InternalServerError(w, r, err)
+		return
+	}
+
+	if err := utils.JSONResponse(w, http.StatusOK, wishlists); err != nil {
+		utils.InternalServerError(w, r, err)
+		return
+	}
+}
+
+func (h *Handler) createWishlist(w http.ResponseWriter, r *http.Request) {
+	var payload WishlistPayload
+
+	if err := utils.ParseJSON(w, r, &payload); err != nil {
+		utils.BadRequestError(w, r, err)
+		return
+	}
+
+	if err := utils.Validator.Struct(payload); err != nil {
+		utils.BadRequestError(w, r, err)
+		return
+	}
+
+	wishlist := &Wishlist{
+		UserID: user.GetUserFromContext(r).ID,
+		Name:   payload.Name,
+	}
+
+	if err := h.store.CreateWishlist(r.Context(), wishlist); err != nil {
+		switch err {
+		case utils.ErrorDuplicateWishlist:
+			utils.BadRequestError(w, r, err)
+		default:
+			utils.InternalServerError(w, r, err)
+		}
+		return
+	}
+
+	if err := utils.JSONResponse(w, http.StatusCreated, wishlist); err != nil {
+		utils.InternalServerError(w, r, err)
+		return
+	}
+}
+
+func (h *Handler) getWishlist(w http.ResponseWriter, r *http.Request) {
+	h.writeWishlist(w, r, GetWishlistFromContext(r))
+}
+
+func (h *Handler) getSharedWishlist(w http.ResponseWriter, r *http.Request) {
+	wishlist, err := h.store.GetWishlistByShareToken(r.Context(), chi.URLParam(r, "token"))
+	if err != nil {
+		switch err {
+		case utils.ErrorNotFound:
+			utils.NotFoundResponse(w, r, err)
+		default:
+			utils.InternalServerError(w, r, err)
+		}
+		return
+	}
+
+	// The token is the credential; don't echo it back to viewers.
+	wishlist.ShareToken = nil
+
+	h.writeWishlist(w, r, wishlist)
+}
+
+func (h *Handler) writeWishlist(w http.ResponseWriter, r *http.Request, wishlist *Wishlist) {
+	items, err := h.store.GetWishlistItems(r.Context(), wishlist.ID)
+	if err != nil {
+		utils.InternalServerError(w, r, err)
+		return
+	}
+	wishlist.Items = items
+
+	if err := utils.JSONResponse(w, http.StatusOK, wishlist); err != nil {
+		utils.InternalServerError(w, r, err)
+		return
+	}
+}
+
+func (h *Handler) updateWishlist(w http.ResponseWriter, r *http.Request) {
+	var payload UpdateWishlistPayload
+
+	if err := utils.ParseJSON(w, r, &payload); err != nil {
+		utils.BadRequestError(w, r, err)
+		return
+	}
+
+	if err := utils.Validator.Struct(payload); err != nil {
+		utils.BadRequestError(w, r, err)
+		return
+	}
+
+	wishlist := GetWishlistFromContext(r)
+
+	utils.AssignIfNotNil(&wishlist.Name, payload.Name)
+	utils.AssignIfNotNil(&wishlist.IsPublic, payload.IsPublic)
+
+	switch {
+	case wishlist.IsPublic && wishlist.ShareToken == nil:
+		token, err := NewShareToken()
+		if err != nil {
+			utils.InternalServerError(w, r, err)
+			return
+		}
+		wishlist.ShareToken = &token
+	case !wishlist.IsPublic:
+		// Revoke the link so re-sharing later yields a fresh one.
+		wishlist.ShareToken = nil
+	}
+
+	if err := h.store.UpdateWishlist(r.Context(), wishlist); err != nil {
+		switch err {
+		case utils.ErrorDuplicateWishlist:
+			utils.BadRequestError(w, r, err)
+		case utils.ErrorNotFound:
+			utils.NotFoundResponse(w, r, err)
+		default:
+			utils.InternalServerError(w, r, err)
+		}
+		return
+	}
+
+	if err := utils.JSONResponse(w, http.StatusOK, wishlist); err != nil {
+		utils.InternalServerError(w, r, err)
+		return
+	}
+}
+
+func (h *Handler) deleteWishlist(w http.ResponseWriter, r *http.Request) {
+	wishlist := GetWishlistFromContext(r)
+
+	if err := h.store.DeleteWishlist(r.Context(), wishlist.ID); err != nil {
+		utils.InternalServerError(w, r, err)
+		return
+	}
+
+	if err := utils.JSONResponse(w, http.StatusNoContent, nil); err != nil {
+		utils.InternalServerError(w, r, err)
+		return
+	}
+}
+
+func (h *Handler) addItem(w http.ResponseWriter, r *http.Request) {
+	var payload AddItemPayload
+
+	if err := utils.ParseJSON(w, r, &payload); err != nil {
+		utils.BadRequestError(w, r, err)
+		return
+	}
+
+	if err := utils.Validator.Struct(payload); err != nil {
+		utils.BadRequestError(w, r, err)
+		return
+	}
+
+	wishlist := GetWishlistFromContext(r)
+
+	product, err := h.productStore.GetPostByID(r.Context(), payload.ProductID)
+	if err != nil {
+		switch err {
+		case utils.ErrorNotFound:
+			utils.BadRequestError(w, r, fmt.Errorf("product (%s) does not exist", payload.ProductID))
+		default:
+			utils.InternalServerError(w, r, err)
+		}
+		return
+	}
+
+	if product.Status != products.StatusPublished {
+		utils.BadRequestError(w, r, fmt.Errorf("product (%s) is not available", payload.ProductID))
+		return
+	}
+
+	if err := h.store.AddItem(r.Context(), wishlist.ID, product.ID); err != nil {
+		utils.InternalServerError(w, r, err)
+		return
+	}
+
+	h.writeWishlist(w, r, wishlist)
+}
+
+func (h *Handler) removeItem(w http.ResponseWriter, r *http.Request) {
+	wishlist := GetWishlistFromContext(r)
+
+	if err := h.store.RemoveItem(r.Context(), wishlist.ID, chi.URLParam(r, "productID")); err != nil {
+		switch err {
+		case utils.ErrorNotFound:
+			utils.NotFoundResponse(w, r, err)
+		default:
+			utils.InternalServerError(w, r, err)
+		}
+		return
+	}
+
+	h.writeWishlist(w, r, wishlist)
+}
@@ -0,0 +1,255 @@
+package wishlists
+
+import (
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"encoding/base64"
+	"errors"
+
+	uuid "github.com/satori/go.uuid"
+	"github.com/umeh-promise/ecommerce/internal/services/products"
+	"github.com/umeh-promise/ecommerce/utils"
+)
+
+const wishlistColumns = `id, user_id, name, is_public, share_token, version, created_at, updated_at`
+
+type scanner interface {
+	Scan(dest ...any) error
+}
+
+func scanWishlist(row scanner, wishlist *Wishlist) error {
+	var shareToken sql.NullString
+
+	err := row.Scan(
+		&wishlist.ID,
+		&wishlist.UserID,
+		&wishlist.Name,
+		&wishlist.IsPublic,
+		&shareToken,
+		&wishlist.Version,
+		&wishlist.CreatedAt,
+		&wishlist.UpdatedAt,
+	)
+	if err != nil {
+		return err
+	}
+
+	wishlist.ShareToken = nil
+	if shareToken.Valid {
+		wishlist.ShareToken = &shareToken.String
+	}
+
+	return nil
+}
+
+// NewShareToken returns an unguessable URL-safe token for public links.
+func NewShareToken() (string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+
+	return base64.RawURLEncoding.EncodeToString(buf), nil
+}
+
+type Store struct {
+	db *sql.DB
+}
+
+func NewStore(db *sql.DB) *Store {
+	return &Store{db: db}
+}
+
+func (s *Store) CreateWishlist(ctx context.Context, wishlist *Wishlist) error {
+	query := `
+		INSERT INTO wishlists (id, user_id, name)
+		VALUES ($1, $2, $3)
+		RETURNING version, created_at, updated_at
+	`
+
+	wishlist.ID = uuid.NewV4().String()
+
+	ctx, cancel := context.WithTimeout(ctx, utils.QueryTimeout)
+	defer cancel()
+
+	err := s.db.QueryRowContext(ctx, query, wishlist.ID, wishlist.UserID, wishlist.Name).Scan(
+		&wishlist.Version,
+		&wishlist.CreatedAt,
+		&wishlist.UpdatedAt,
+	)
+	if err != nil {
+		switch {
+		case err.Error() == `pq: duplicate key value violates unique constraint "wishlists_user_name_key"`:
+			return utils.ErrorDuplicateWishlist
+		default:
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (s *Store) GetWishlistsByUserID(ctx context.Context, userID string) ([]Wishlist, error) {
+	query := `SELECT ` + wishlistColumns + ` FROM wishlists WHERE user_id = $1 ORDER BY created_at`
+
+	ctx, cancel := context.WithTimeout(ctx, utils.QueryTimeout)
+	defer cancel()
+
+	rows, err := s.db.QueryContext(ctx, query, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var wishlists []Wishlist
+
+	for rows.Next() {
+		wishlist := Wishlist{}
+		if err := scanWishlist(rows, &wishlist); err != nil {
+			return nil, err
+		}
+
+		wishlists = append(wishlists, wishlist)
+	}
+
+	return wishlists, rows.Err()
+}
+
+func (s *Store) GetWishlistByID(ctx context.Context, id string) (*Wishlist, error) {
+	return s.getWishlist(ctx, `SELECT `+wishlistColumns+` FROM wishlists WHERE id = $1`, id)
+}
+
+func (s *Store) GetWishlistByShareToken(ctx context.Context, token string) (*Wishlist, error) {
+	return s.getWishlist(ctx, `SELECT `+wishlistColumns+` FROM wishlists WHERE share_token = $1 AND is_public`, token)
+}
+
+func (s *Store) getWishlist(ctx context.Context, query string, arg string) (*Wishlist, error) {
+	var wishlist Wishlist
+
+	ctx, cancel := context.WithTimeout(ctx, utils.QueryTimeout)
+	defer cancel()
+
+	err := scanWishlist(s.db.QueryRowContext(ctx, query, arg), &wishlist)
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return nil, utils.ErrorNotFound
+		default:
+			return nil, err
+		}
+	}
+
+	return &wishlist, nil
+}
+
+func (s *Store) GetWishlistItems(ctx context.Context, wishlistID string) ([]WishlistItem, error) {
+	query := `
+		SELECT p.id, p.name, p.image, ` + products.EffectivePriceSQL + `, p.status = 'published', wi.added_at
+		FROM wishlist_items wi
+		JOIN products p ON p.id = wi.product_id
+		WHERE wi.wishlist_id = $1
+		ORDER BY wi.added_at DESC
+	`
+
+	ctx, cancel := context.WithTimeout(ctx, utils.QueryTimeout)
+	defer cancel()
+
+	rows, err := s.db.QueryContext(ctx, query, wishlistID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var items []WishlistItem
+
+	for rows.Next() {
+		item := WishlistItem{}
+		err := rows.Scan(
+			&item.ProductID,
+			&item.Name,
+			&item.Image,
+			&item.EffectivePrice,
+			&item.Available,
+			&item.AddedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		items = append(items, item)
+	}
+
+	return items, rows.Err()
+}
+
+func (s *Store) UpdateWishlist(ctx context.Context, wishlist *Wishlist) error {
+	query := `
+		UPDATE wishlists
+		SET name = $1, is_public = $2, share_token = $3, version = version + 1, updated_at = now()
+		WHERE id = $4 AND version = $5
+		RETURNING version
+	`
+
+	ctx, cancel := context.WithTimeout(ctx, utils.QueryTimeout)
+	defer cancel()
+
+	err := s.db.QueryRowContext(ctx, query,
+		wishlist.Name, wishlist.IsPublic, wishlist.ShareToken, wishlist.ID, wishlist.Version,
+	).Scan(&wishlist.Version)
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return utils.ErrorNotFound
+		case err.Error() == `pq: duplicate key value violates unique constraint "wishlists_user_name_key"`:
+			return utils.ErrorDuplicateWishlist
+		default:
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (s *Store) DeleteWishlist(ctx context.Context, id string) error {
+	ctx, cancel := context.WithTimeout(ctx, utils.QueryTimeout)
+	defer cancel()
+
+	_, err := s.db.ExecContext(ctx, `DELETE FROM wishlists WHERE id = $1`, id)
+	return err
+}
+
+func (s *Store) AddItem(ctx context.Context, wishlistID, productID string) error {
+	query := `
+		INSERT INTO wishlist_items (wishlist_id, product_id)
+		VALUES ($1, $2)
+		ON CONFLICT DO NOTHING
+	`
+
+	ctx, cancel := context.WithTimeout(ctx, utils.QueryTimeout)
+	defer cancel()
+
+	_, err := s.db.ExecContext(ctx, query, wishlistID, productID)
+	return err
+}
+
+func (s *Store) RemoveItem(ctx context.Context, wishlistID, productID string) error {
+	ctx, cancel := context.WithTimeout(ctx, utils.QueryTimeout)
+	defer cancel()
+
+	result, err := s.db.ExecContext(ctx, `DELETE FROM wishlist_items WHERE wishlist_id = $1 AND product_id = $2`, wishlistID, productID)
+	if err != nil {
+		return err
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rows == 0 {
+		return utils.ErrorNotFound
+	}
+
+	return nil
+}
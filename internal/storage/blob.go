@@ -0,0 +1,74 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"strings"
+)
+
+var ErrorInvalidKey = errors.New("invalid blob key")
+
+// BlobStore persists uploaded files under slash-separated keys such as
+// "products/<id>/<uuid>.png".
+type BlobStore interface {
+	Put(ctx context.Context, key string, body io.Reader, contentType string) error
+	Get(ctx context.Context, key string) (io.ReadCloser, string, error)
+	Delete(ctx context.Context, key string) error
+	URL(key string) string
+}
+
+type Config struct {
+	Backend       string
+	PublicBaseURL string
+	LocalDir      string
+	S3Endpoint    string
+	S3Bucket      string
+	S3Region      string
+	S3AccessKey   string
+	S3SecretKey   string
+}
+
+func New(config Config) (BlobStore, error) {
+	switch config.Backend {
+	case "", "local":
+		return NewLocalStore(config.LocalDir, config.PublicBaseURL)
+	case "s3":
+		return NewS3Store(config.S3Endpoint, config.S3Bucket, config.S3Region, config.S3AccessKey, config.S3SecretKey, config.PublicBaseURL), nil
+	default:
+		return nil, fmt.Errorf("unknown storage backend %q", config.Backend)
+	}
+}
+
+// publicURL is the stable address under which files are served by the API,
+// regardless of which backend holds them.
+func publicURL(baseURL, key string) string {
+	return strings.TrimRight(baseURL, "/") + "/v1/files/" + key
+}
+
+// KeyFromURL returns the key of a file served at url by blobs. It reports
+// false for URLs that don't point at one of blobs' files, such as images
+// hosted elsewhere.
+func KeyFromURL(blobs BlobStore, url string) (string, bool) {
+	key, ok := strings.CutPrefix(url, blobs.URL(""))
+	if !ok {
+		return "", false
+	}
+
+	if _, err := cleanKey(key); err != nil {
+		return "", false
+	}
+
+	return key, true
+}
+
+func cleanKey(key string) (string, error) {
+	cleaned := path.Clean("/" + key)[1:]
+	if cleaned == "" || cleaned != key || strings.HasPrefix(cleaned, "..") {
+		return "", ErrorInvalidKey
+	}
+
+	return cleaned, nil
+}
@@ -0,0 +1,68 @@
+package storage
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	_ "image/gif"
+	"image/jpeg"
+	"image/png"
+
+	"golang.org/x/image/draw"
+	_ "golang.org/x/image/webp"
+)
+
+const ThumbnailSize = 320
+
+// MaxImagePixels caps the size of images we decode. A small file can
+// declare huge dimensions, and decoding allocates memory for all of them.
+const MaxImagePixels = 40_000_000
+
+var ErrorImageTooLarge = errors.New("image dimensions are too large")
+
+// Thumbnail scales the image down so its longest side is at most maxSide.
+// PNGs stay PNG to keep transparency; everything else becomes JPEG.
+func Thumbnail(data []byte, maxSide int) (*Upload, error) {
+	config, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+
+	if config.Width > MaxImagePixels/max(config.Height, 1) {
+		return nil, ErrorImageTooLarge
+	}
+
+	src, format, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+
+	bounds := src.Bounds()
+	width, height := bounds.Dx(), bounds.Dy()
+	if width > maxSide || height > maxSide {
+		if width >= height {
+			height = max(height*maxSide/width, 1)
+			width = maxSide
+		} else {
+			width = max(width*maxSide/height, 1)
+			height = maxSide
+		}
+	}
+
+	dst := image.NewRGBA(image.Rect(0, 0, width, height))
+	draw.CatmullRom.Scale(dst, dst.Bounds(), src, bounds, draw.Over, nil)
+
+	var buf bytes.Buffer
+	if format == "png" {
+		if err := png.Encode(&buf, dst); err != nil {
+			return nil, err
+		}
+		return &Upload{Data: buf.Bytes(), ContentType: "image/png", Extension: ".png"}, nil
+	}
+
+	if err := jpeg.Encode(&buf, dst, &jpeg.Options{Quality: 85}); err != nil {
+		return nil, err
+	}
+
+	return &Upload{Data: buf.Bytes(), ContentType: "image/jpeg", Extension: ".jpg"}, nil
+}
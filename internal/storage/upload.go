@@ -0,0 +1,66 @@
+package storage
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"slices"
+
+	"github.com/gabriel-vasile/mimetype"
+)
+
+var (
+	ErrorFileTooLarge      = errors.New("file is too large")
+	ErrorUnsupportedFormat = errors.New("unsupported file format")
+)
+
+var imageTypes = []string{"image/jpeg", "image/png", "image/gif", "image/webp"}
+
+type Upload struct {
+	Data        []byte
+	ContentType string
+	Extension   string
+}
+
+// ReadImage reads a single multipart file field, enforcing maxBytes and
+// sniffing the content rather than trusting the client's Content-Type.
+func ReadImage(w http.ResponseWriter, r *http.Request, field string, maxBytes int64) (*Upload, error) {
+	// Leave some headroom for the multipart envelope itself.
+	r.Body = http.MaxBytesReader(w, r.Body, maxBytes+1024*64)
+
+	file, _, err := r.FormFile(field)
+	if err != nil {
+		var maxErr *http.MaxBytesError
+		if errors.As(err, &maxErr) {
+			return nil, ErrorFileTooLarge
+		}
+		return nil, fmt.Errorf("missing %q file field: %w", field, err)
+	}
+	defer file.Close()
+
+	data, err := io.ReadAll(io.LimitReader(file, maxBytes+1))
+	if err != nil {
+		return nil, err
+	}
+
+	if int64(len(data)) > maxBytes {
+		return nil, ErrorFileTooLarge
+	}
+
+	mime := mimetype.Detect(data)
+	if !slices.ContainsFunc(imageTypes, mime.Is) {
+		return nil, fmt.Errorf("%w: %s", ErrorUnsupportedFormat, mime.String())
+	}
+
+	return &Upload{
+		Data:        data,
+		ContentType: mime.String(),
+		Extension:   mime.Extension(),
+	}, nil
+}
+
+func (u *Upload) Reader() io.Reader {
+	return bytes.NewReader(u.Data)
+}
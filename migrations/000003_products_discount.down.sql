@@ -0,0 +1,10 @@
+ALTER TABLE products
+    DROP CONSTRAINT IF EXISTS products_discount_window_check,
+    DROP CONSTRAINT IF EXISTS products_discount_value_check,
+    DROP CONSTRAINT IF EXISTS products_discount_type_check,
+    DROP COLUMN IF EXISTS discount_ends_at,
+    DROP COLUMN IF EXISTS discount_starts_at,
+    DROP COLUMN IF EXISTS discount_value,
+    DROP COLUMN IF EXISTS discount_type;
+
+ALTER TABLE products ADD COLUMN discount integer not null default 0;
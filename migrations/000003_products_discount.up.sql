@@ -0,0 +1,16 @@
+ALTER TABLE products DROP COLUMN IF EXISTS discount;
+
+ALTER TABLE products
+    ADD COLUMN discount_type varchar(20),
+    ADD COLUMN discount_value integer not null default 0,
+    ADD COLUMN discount_starts_at timestamp(0) with time zone,
+    ADD COLUMN discount_ends_at timestamp(0) with time zone,
+    ADD CONSTRAINT products_discount_type_check CHECK (discount_type IN ('percentage', 'fixed')),
+    ADD CONSTRAINT products_discount_value_check CHECK (
+        discount_value >= 0
+        AND (discount_type <> 'percentage' OR discount_value <= 100)
+        AND (discount_type <> 'fixed' OR discount_value <= price)
+    ),
+    ADD CONSTRAINT products_discount_window_check CHECK (
+        discount_starts_at IS NULL OR discount_ends_at IS NULL OR discount_ends_at > discount_starts_at
+    );
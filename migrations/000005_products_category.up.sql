@@ -0,0 +1,3 @@
+ALTER TABLE products ADD COLUMN category varchar(100) not null default '';
+
+CREATE INDEX IF NOT EXISTS products_category_idx ON products (category);
@@ -0,0 +1,32 @@
+CREATE TABLE IF NOT EXISTS orders (
+    id uuid primary key,
+    user_id uuid not null,
+    status varchar(20) not null default 'pending',
+    subtotal integer not null,
+    discount_total integer not null default 0,
+    shipping_total integer not null default 0,
+    total integer not null,
+    coupon_code varchar(50),
+    version integer not null default 0,
+    created_at timestamp(0) with time zone not null default now(),
+    updated_at timestamp(0) with time zone not null default now(),
+
+    FOREIGN KEY ("user_id") REFERENCES "users" ("id") ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS orders_user_id_idx ON orders (user_id);
+
+CREATE TABLE IF NOT EXISTS order_items (
+    id uuid primary key,
+    order_id uuid not null,
+    product_id uuid not null,
+    product_name varchar(255) not null,
+    unit_price integer not null,
+    quantity integer not null CHECK (quantity > 0),
+    line_total integer not null,
+
+    FOREIGN KEY ("order_id") REFERENCES "orders" ("id") ON DELETE CASCADE,
+    FOREIGN KEY ("product_id") REFERENCES "products" ("id")
+);
+
+CREATE INDEX IF NOT EXISTS order_items_order_id_idx ON order_items (order_id);
@@ -0,0 +1,37 @@
+CREATE TABLE IF NOT EXISTS promotions (
+    id uuid primary key,
+    code varchar(50) unique not null,
+    type varchar(20) not null CHECK (type IN ('percentage', 'fixed', 'free_shipping')),
+    value integer not null default 0 CHECK (value >= 0),
+    min_order_value integer not null default 0,
+    usage_limit integer,
+    per_user_limit integer,
+    times_used integer not null default 0,
+    product_ids uuid[] not null default '{}',
+    categories text[] not null default '{}',
+    starts_at timestamp(0) with time zone,
+    expires_at timestamp(0) with time zone,
+    active boolean not null default true,
+    version integer not null default 0,
+    created_at timestamp(0) with time zone not null default now(),
+    updated_at timestamp(0) with time zone not null default now(),
+
+    CHECK (type <> 'percentage' OR value <= 100),
+    CHECK (usage_limit IS NULL OR times_used <= usage_limit)
+);
+
+CREATE TABLE IF NOT EXISTS promotion_redemptions (
+    id uuid primary key,
+    promotion_id uuid not null,
+    user_id uuid not null,
+    order_id uuid not null,
+    discount_amount integer not null,
+    created_at timestamp(0) with time zone not null default now(),
+
+    FOREIGN KEY ("promotion_id") REFERENCES "promotions" ("id") ON DELETE CASCADE,
+    FOREIGN KEY ("user_id") REFERENCES "users" ("id") ON DELETE CASCADE,
+    FOREIGN KEY ("order_id") REFERENCES "orders" ("id") ON DELETE CASCADE,
+    UNIQUE (promotion_id, order_id)
+);
+
+CREATE INDEX IF NOT EXISTS promotion_redemptions_user_idx ON promotion_redemptions (promotion_id, user_id);
@@ -0,0 +1,14 @@
+CREATE TABLE IF NOT EXISTS product_images (
+    id uuid primary key,
+    product_id uuid not null,
+    url varchar(255) not null,
+    thumbnail_url varchar(255) not null,
+    blob_key varchar(255) not null,
+    thumbnail_key varchar(255) not null,
+    alt_text varchar(255) not null default '',
+    position integer not null,
+    created_at timestamp(0) with time zone not null default now(),
+
+    FOREIGN KEY ("product_id") REFERENCES "products" ("id") ON DELETE CASCADE,
+    CONSTRAINT product_images_position_key UNIQUE (product_id, position) DEFERRABLE INITIALLY DEFERRED
+);
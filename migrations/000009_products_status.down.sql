@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS products_status_idx;
+
+ALTER TABLE products
+    DROP CONSTRAINT IF EXISTS products_status_check,
+    DROP COLUMN IF EXISTS status;
@@ -0,0 +1,8 @@
+ALTER TABLE products
+    ADD COLUMN status varchar(20) not null default 'draft',
+    ADD CONSTRAINT products_status_check CHECK (status IN ('draft', 'published', 'archived'));
+
+-- Everything created before statuses existed was already public.
+UPDATE products SET status = 'published';
+
+CREATE INDEX IF NOT EXISTS products_status_idx ON products (status);
@@ -0,0 +1,23 @@
+CREATE TABLE IF NOT EXISTS reviews (
+    id uuid primary key,
+    product_id uuid not null,
+    user_id uuid not null,
+    rating smallint not null CHECK (rating BETWEEN 1 AND 5),
+    title varchar(255) not null default '',
+    body text not null default '',
+    status varchar(20) not null default 'approved' CHECK (status IN ('pending', 'approved', 'rejected')),
+    verified_purchase boolean not null default false,
+    version integer not null default 0,
+    created_at timestamp(0) with time zone not null default now(),
+    updated_at timestamp(0) with time zone not null default now(),
+
+    FOREIGN KEY ("product_id") REFERENCES "products" ("id") ON DELETE CASCADE,
+    FOREIGN KEY ("user_id") REFERENCES "users" ("id") ON DELETE CASCADE,
+    CONSTRAINT reviews_product_user_key UNIQUE (product_id, user_id)
+);
+
+CREATE INDEX IF NOT EXISTS reviews_product_status_idx ON reviews (product_id, status);
+
+ALTER TABLE products
+    ADD COLUMN rating_average numeric(3, 2) not null default 0,
+    ADD COLUMN rating_count integer not null default 0;
@@ -0,0 +1,25 @@
+CREATE TABLE IF NOT EXISTS wishlists (
+    id uuid primary key,
+    user_id uuid not null,
+    name varchar(100) not null,
+    is_public boolean not null default false,
+    share_token varchar(64) unique,
+    version integer not null default 0,
+    created_at timestamp(0) with time zone not null default now(),
+    updated_at timestamp(0) with time zone not null default now(),
+
+    FOREIGN KEY ("user_id") REFERENCES "users" ("id") ON DELETE CASCADE,
+    CONSTRAINT wishlists_user_name_key UNIQUE (user_id, name)
+);
+
+-- Deleting a product drops it from every list; archived products are
+-- flagged as unavailable when the list is read.
+CREATE TABLE IF NOT EXISTS wishlist_items (
+    wishlist_id uuid not null,
+    product_id uuid not null,
+    added_at timestamp(0) with time zone not null default now(),
+
+    PRIMARY KEY (wishlist_id, product_id),
+    FOREIGN KEY ("wishlist_id") REFERENCES "wishlists" ("id") ON DELETE CASCADE,
+    FOREIGN KEY ("product_id") REFERENCES "products" ("id") ON DELETE CASCADE
+);
@@ -0,0 +1,15 @@
+CREATE TABLE IF NOT EXISTS sellers (
+    id uuid primary key,
+    user_id uuid unique not null,
+    display_name varchar(100) not null,
+    slug varchar(60) unique not null,
+    description text not null default '',
+    logo varchar(255) not null default '',
+    shipping_policy text not null default '',
+    return_policy text not null default '',
+    version integer not null default 0,
+    created_at timestamp(0) with time zone not null default now(),
+    updated_at timestamp(0) with time zone not null default now(),
+
+    FOREIGN KEY ("user_id") REFERENCES "users" ("id") ON DELETE CASCADE
+);
@@ -0,0 +1,5 @@
+ALTER TABLE order_items DROP COLUMN IF EXISTS seller_order_id;
+
+DROP TABLE IF EXISTS seller_orders;
+
+ALTER TABLE sellers DROP COLUMN IF EXISTS commission_rate_bps;
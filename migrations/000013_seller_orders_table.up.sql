@@ -0,0 +1,23 @@
+ALTER TABLE sellers ADD COLUMN commission_rate_bps integer CHECK (commission_rate_bps BETWEEN 0 AND 10000);
+
+CREATE TABLE IF NOT EXISTS seller_orders (
+    id uuid primary key,
+    order_id uuid not null,
+    seller_user_id uuid not null,
+    status varchar(20) not null default 'pending',
+    subtotal integer not null,
+    discount_share integer not null default 0,
+    commission_rate_bps integer not null,
+    commission integer not null,
+    net_amount integer not null,
+    created_at timestamp(0) with time zone not null default now(),
+    updated_at timestamp(0) with time zone not null default now(),
+
+    FOREIGN KEY ("order_id") REFERENCES "orders" ("id") ON DELETE CASCADE,
+    FOREIGN KEY ("seller_user_id") REFERENCES "users" ("id"),
+    CONSTRAINT seller_orders_order_seller_key UNIQUE (order_id, seller_user_id)
+);
+
+CREATE INDEX IF NOT EXISTS seller_orders_seller_idx ON seller_orders (seller_user_id);
+
+ALTER TABLE order_items ADD COLUMN seller_order_id uuid REFERENCES seller_orders (id) ON DELETE CASCADE;
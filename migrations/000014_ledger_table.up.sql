@@ -0,0 +1,38 @@
+-- Every ledger transaction is a balanced set of entries: amounts across its
+-- entries always sum to zero.
+CREATE TABLE IF NOT EXISTS ledger_transactions (
+    id uuid primary key,
+    type varchar(20) not null CHECK (type IN ('sale', 'commission', 'refund', 'payout')),
+    seller_order_id uuid,
+    payout_id uuid,
+    created_at timestamp(0) with time zone not null default now(),
+
+    FOREIGN KEY ("seller_order_id") REFERENCES "seller_orders" ("id")
+);
+
+CREATE TABLE IF NOT EXISTS ledger_entries (
+    id uuid primary key,
+    transaction_id uuid not null,
+    account varchar(30) not null,
+    seller_user_id uuid,
+    amount integer not null,
+    created_at timestamp(0) with time zone not null default now(),
+
+    FOREIGN KEY ("transaction_id") REFERENCES "ledger_transactions" ("id"),
+    FOREIGN KEY ("seller_user_id") REFERENCES "users" ("id"),
+    CHECK ((account = 'seller_payable') = (seller_user_id IS NOT NULL))
+);
+
+CREATE INDEX IF NOT EXISTS ledger_entries_seller_idx ON ledger_entries (seller_user_id, account);
+
+CREATE TABLE IF NOT EXISTS payouts (
+    id uuid primary key,
+    seller_user_id uuid not null,
+    amount integer not null CHECK (amount > 0),
+    status varchar(20) not null default 'pending',
+    created_at timestamp(0) with time zone not null default now(),
+
+    FOREIGN KEY ("seller_user_id") REFERENCES "users" ("id")
+);
+
+ALTER TABLE ledger_transactions ADD FOREIGN KEY ("payout_id") REFERENCES "payouts" ("id");
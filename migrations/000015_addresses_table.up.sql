@@ -0,0 +1,29 @@
+CREATE TABLE IF NOT EXISTS addresses (
+    id uuid primary key,
+    user_id uuid not null,
+    full_name varchar(100) not null,
+    line1 varchar(255) not null,
+    line2 varchar(255) not null default '',
+    city varchar(100) not null,
+    region varchar(100) not null default '',
+    postal_code varchar(20) not null default '',
+    country char(2) not null,
+    phone_number varchar(30) not null default '',
+    is_default_shipping boolean not null default false,
+    is_default_billing boolean not null default false,
+    version integer not null default 0,
+    created_at timestamp(0) with time zone not null default now(),
+    updated_at timestamp(0) with time zone not null default now(),
+
+    FOREIGN KEY ("user_id") REFERENCES "users" ("id") ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS addresses_user_id_idx ON addresses (user_id);
+
+-- At most one default of each kind per user.
+CREATE UNIQUE INDEX IF NOT EXISTS addresses_default_shipping_key ON addresses (user_id) WHERE is_default_shipping;
+CREATE UNIQUE INDEX IF NOT EXISTS addresses_default_billing_key ON addresses (user_id) WHERE is_default_billing;
+
+-- Orders keep their own copy so editing or deleting an address never
+-- rewrites where a past order was shipped.
+ALTER TABLE orders ADD COLUMN shipping_address jsonb;
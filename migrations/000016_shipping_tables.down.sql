@@ -0,0 +1,10 @@
+ALTER TABLE orders DROP COLUMN IF EXISTS shipping_method;
+
+DROP TABLE IF EXISTS shipping_rates;
+DROP TABLE IF EXISTS shipping_zones;
+
+ALTER TABLE products
+    DROP COLUMN IF EXISTS weight_grams,
+    DROP COLUMN IF EXISTS length_mm,
+    DROP COLUMN IF EXISTS width_mm,
+    DROP COLUMN IF EXISTS height_mm;
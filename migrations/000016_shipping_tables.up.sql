@@ -0,0 +1,37 @@
+ALTER TABLE products
+    ADD COLUMN weight_grams integer not null default 0 CHECK (weight_grams >= 0),
+    ADD COLUMN length_mm integer not null default 0 CHECK (length_mm >= 0),
+    ADD COLUMN width_mm integer not null default 0 CHECK (width_mm >= 0),
+    ADD COLUMN height_mm integer not null default 0 CHECK (height_mm >= 0);
+
+-- A zone covers a set of countries, optionally narrowed to regions within
+-- them. A zone with no countries is the catch-all "rest of world".
+CREATE TABLE IF NOT EXISTS shipping_zones (
+    id uuid primary key,
+    name varchar(100) not null unique,
+    countries text[] not null default '{}',
+    regions text[] not null default '{}',
+    created_at timestamp(0) with time zone not null default now()
+);
+
+-- Rates apply when the parcel's billable weight and the order subtotal fall
+-- inside their brackets; null bounds are open.
+CREATE TABLE IF NOT EXISTS shipping_rates (
+    id uuid primary key,
+    zone_id uuid not null,
+    name varchar(100) not null,
+    min_weight_grams integer not null default 0,
+    max_weight_grams integer,
+    min_subtotal integer not null default 0,
+    max_subtotal integer,
+    price integer not null CHECK (price >= 0),
+    free_over integer,
+    min_days integer,
+    max_days integer,
+
+    FOREIGN KEY ("zone_id") REFERENCES "shipping_zones" ("id") ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS shipping_rates_zone_id_idx ON shipping_rates (zone_id);
+
+ALTER TABLE orders ADD COLUMN shipping_method varchar(100);
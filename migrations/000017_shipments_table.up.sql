@@ -0,0 +1,44 @@
+-- A shipment is one parcel sent by a single seller. An order item may be
+-- spread over several shipments until its full quantity has been sent.
+CREATE TABLE IF NOT EXISTS shipments (
+    id uuid primary key,
+    order_id uuid not null,
+    seller_order_id uuid not null,
+    carrier varchar(100) not null,
+    tracking_number varchar(100) not null,
+    status varchar(30) not null default 'label_created',
+    delivered_at timestamp(0) with time zone,
+    created_at timestamp(0) with time zone not null default now(),
+    updated_at timestamp(0) with time zone not null default now(),
+
+    FOREIGN KEY ("order_id") REFERENCES "orders" ("id") ON DELETE CASCADE,
+    FOREIGN KEY ("seller_order_id") REFERENCES "seller_orders" ("id") ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS shipments_order_id_idx ON shipments (order_id);
+
+CREATE TABLE IF NOT EXISTS shipment_items (
+    shipment_id uuid not null,
+    order_item_id uuid not null,
+    quantity integer not null CHECK (quantity > 0),
+
+    PRIMARY KEY (shipment_id, order_item_id),
+    FOREIGN KEY ("shipment_id") REFERENCES "shipments" ("id") ON DELETE CASCADE,
+    FOREIGN KEY ("order_item_id") REFERENCES "order_items" ("id") ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS shipment_items_order_item_id_idx ON shipment_items (order_item_id);
+
+CREATE TABLE IF NOT EXISTS shipment_events (
+    id uuid primary key,
+    shipment_id uuid not null,
+    status varchar(30) not null,
+    description varchar(255) not null default '',
+    location varchar(255) not null default '',
+    occurred_at timestamp(0) with time zone not null default now(),
+    created_at timestamp(0) with time zone not null default now(),
+
+    FOREIGN KEY ("shipment_id") REFERENCES "shipments" ("id") ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS shipment_events_shipment_id_idx ON shipment_events (shipment_id, occurred_at);
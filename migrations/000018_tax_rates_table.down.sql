@@ -0,0 +1,10 @@
+ALTER TABLE orders DROP COLUMN IF EXISTS tax_total;
+
+ALTER TABLE order_items
+    DROP COLUMN IF EXISTS tax_rate_bps,
+    DROP COLUMN IF EXISTS tax_amount,
+    DROP COLUMN IF EXISTS tax_inclusive;
+
+ALTER TABLE products DROP COLUMN IF EXISTS tax_class;
+
+DROP TABLE IF EXISTS tax_rates;
@@ -0,0 +1,23 @@
+-- A blank region applies to the whole country; a region-level rate takes
+-- precedence over it for the same tax class.
+CREATE TABLE IF NOT EXISTS tax_rates (
+    id uuid primary key,
+    country char(2) not null,
+    region varchar(100) not null default '',
+    tax_class varchar(50) not null default 'standard',
+    name varchar(100) not null,
+    rate_bps integer not null CHECK (rate_bps BETWEEN 0 AND 10000),
+    inclusive boolean not null default false,
+    created_at timestamp(0) with time zone not null default now(),
+
+    CONSTRAINT tax_rates_jurisdiction_key UNIQUE (country, region, tax_class)
+);
+
+ALTER TABLE products ADD COLUMN tax_class varchar(50) not null default 'standard';
+
+ALTER TABLE order_items
+    ADD COLUMN tax_rate_bps integer not null default 0,
+    ADD COLUMN tax_amount integer not null default 0,
+    ADD COLUMN tax_inclusive boolean not null default false;
+
+ALTER TABLE orders ADD COLUMN tax_total integer not null default 0;
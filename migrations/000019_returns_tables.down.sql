@@ -0,0 +1,6 @@
+DROP TABLE IF EXISTS refunds;
+DROP TABLE IF EXISTS return_items;
+DROP TABLE IF EXISTS returns;
+
+ALTER TABLE orders DROP COLUMN IF EXISTS refunded_total;
+ALTER TABLE products DROP COLUMN IF EXISTS stock;
@@ -0,0 +1,53 @@
+-- Stock is only tracked for products that set it; NULL means unlimited.
+ALTER TABLE products ADD COLUMN stock integer CHECK (stock >= 0);
+
+ALTER TABLE orders ADD COLUMN refunded_total integer not null default 0 CHECK (refunded_total >= 0);
+
+-- A return covers items from a single seller's part of an order.
+CREATE TABLE IF NOT EXISTS returns (
+    id uuid primary key,
+    order_id uuid not null,
+    seller_order_id uuid not null,
+    user_id uuid not null,
+    status varchar(20) not null default 'requested',
+    reason text not null,
+    resolution_note text not null default '',
+    refund_amount integer not null default 0,
+    restocked boolean not null default false,
+    version integer not null default 0,
+    created_at timestamp(0) with time zone not null default now(),
+    updated_at timestamp(0) with time zone not null default now(),
+
+    FOREIGN KEY ("order_id") REFERENCES "orders" ("id") ON DELETE CASCADE,
+    FOREIGN KEY ("seller_order_id") REFERENCES "seller_orders" ("id") ON DELETE CASCADE,
+    FOREIGN KEY ("user_id") REFERENCES "users" ("id") ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS returns_order_id_idx ON returns (order_id);
+
+CREATE TABLE IF NOT EXISTS return_items (
+    return_id uuid not null,
+    order_item_id uuid not null,
+    quantity integer not null CHECK (quantity > 0),
+
+    PRIMARY KEY (return_id, order_item_id),
+    FOREIGN KEY ("return_id") REFERENCES "returns" ("id") ON DELETE CASCADE,
+    FOREIGN KEY ("order_item_id") REFERENCES "order_items" ("id") ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS return_items_order_item_id_idx ON return_items (order_item_id);
+
+CREATE TABLE IF NOT EXISTS refunds (
+    id uuid primary key,
+    order_id uuid not null,
+    return_id uuid,
+    amount integer not null CHECK (amount > 0),
+    provider varchar(50) not null,
+    provider_reference varchar(255) not null,
+    created_at timestamp(0) with time zone not null default now(),
+
+    FOREIGN KEY ("order_id") REFERENCES "orders" ("id") ON DELETE CASCADE,
+    FOREIGN KEY ("return_id") REFERENCES "returns" ("id") ON DELETE SET NULL
+);
+
+CREATE INDEX IF NOT EXISTS refunds_order_id_idx ON refunds (order_id);
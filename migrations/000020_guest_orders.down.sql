@@ -0,0 +1,10 @@
+DELETE FROM orders WHERE user_id IS NULL;
+
+DROP INDEX IF EXISTS orders_guest_token_idx;
+
+ALTER TABLE orders DROP CONSTRAINT IF EXISTS orders_owner_check;
+ALTER TABLE orders DROP CONSTRAINT IF EXISTS orders_lookup_token_key;
+ALTER TABLE orders DROP COLUMN IF EXISTS lookup_token;
+ALTER TABLE orders DROP COLUMN IF EXISTS guest_token;
+ALTER TABLE orders DROP COLUMN IF EXISTS guest_email;
+ALTER TABLE orders ALTER COLUMN user_id SET NOT NULL;
@@ -0,0 +1,11 @@
+-- Guests check out without an account: user_id stays NULL until they
+-- register and the order is claimed.
+ALTER TABLE orders ALTER COLUMN user_id DROP NOT NULL;
+ALTER TABLE orders ADD COLUMN guest_email varchar(255);
+ALTER TABLE orders ADD COLUMN guest_token varchar(64);
+ALTER TABLE orders ADD COLUMN lookup_token varchar(64);
+
+ALTER TABLE orders ADD CONSTRAINT orders_lookup_token_key UNIQUE (lookup_token);
+ALTER TABLE orders ADD CONSTRAINT orders_owner_check CHECK (user_id IS NOT NULL OR guest_email IS NOT NULL);
+
+CREATE INDEX IF NOT EXISTS orders_guest_token_idx ON orders (guest_token) WHERE user_id IS NULL;
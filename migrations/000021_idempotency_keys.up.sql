@@ -0,0 +1,18 @@
+-- Scope is a hash of the caller's credentials so one client can't replay
+-- another's response by guessing its key.
+CREATE TABLE IF NOT EXISTS idempotency_keys (
+    scope char(64) not null,
+    key varchar(255) not null,
+    method varchar(10) not null,
+    path text not null,
+    fingerprint char(64) not null,
+    status_code integer,
+    content_type varchar(255) not null default '',
+    response_body bytea,
+    created_at timestamp(0) with time zone not null default now(),
+    completed_at timestamp(0) with time zone,
+
+    PRIMARY KEY (scope, key)
+);
+
+CREATE INDEX IF NOT EXISTS idempotency_keys_created_at_idx ON idempotency_keys (created_at);
@@ -0,0 +1,17 @@
+-- Domain events are written here in the same transaction as the change they
+-- describe, then delivered to subscribers by the dispatcher.
+CREATE TABLE IF NOT EXISTS outbox (
+    id bigserial primary key,
+    event_type varchar(100) not null,
+    aggregate_id varchar(100) not null,
+    payload jsonb not null,
+    attempts integer not null default 0,
+    last_error text not null default '',
+    next_attempt_at timestamp(0) with time zone not null default now(),
+    dispatched_at timestamp(0) with time zone,
+    failed_at timestamp(0) with time zone,
+    created_at timestamp(0) with time zone not null default now()
+);
+
+CREATE INDEX IF NOT EXISTS outbox_pending_idx ON outbox (next_attempt_at, id)
+    WHERE dispatched_at IS NULL AND failed_at IS NULL;
@@ -0,0 +1,41 @@
+CREATE TABLE IF NOT EXISTS webhook_subscriptions (
+    id uuid primary key,
+    user_id uuid not null,
+    url text not null,
+    secret varchar(100) not null,
+    event_types text[] not null,
+    active boolean not null default true,
+    version integer not null default 0,
+    created_at timestamp(0) with time zone not null default now(),
+    updated_at timestamp(0) with time zone not null default now(),
+
+    FOREIGN KEY ("user_id") REFERENCES "users" ("id") ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS webhook_subscriptions_user_id_idx ON webhook_subscriptions (user_id);
+
+-- One delivery per subscription and event; its latest attempt is recorded
+-- on the row. The body is fixed when the delivery is created so retries
+-- and redeliveries send exactly the same payload.
+CREATE TABLE IF NOT EXISTS webhook_deliveries (
+    id uuid primary key,
+    subscription_id uuid not null,
+    event_id bigint not null,
+    event_type varchar(100) not null,
+    body jsonb not null,
+    status varchar(20) not null default 'pending',
+    attempts integer not null default 0,
+    next_attempt_at timestamp(0) with time zone not null default now(),
+    response_code integer,
+    last_error text not null default '',
+    delivered_at timestamp(0) with time zone,
+    created_at timestamp(0) with time zone not null default now(),
+    updated_at timestamp(0) with time zone not null default now(),
+
+    FOREIGN KEY ("subscription_id") REFERENCES "webhook_subscriptions" ("id") ON DELETE CASCADE,
+    CONSTRAINT webhook_deliveries_subscription_event_key UNIQUE (subscription_id, event_id)
+);
+
+CREATE INDEX IF NOT EXISTS webhook_deliveries_due_idx ON webhook_deliveries (next_attempt_at)
+    WHERE status IN ('pending', 'sending');
+CREATE INDEX IF NOT EXISTS webhook_deliveries_subscription_idx ON webhook_deliveries (subscription_id, created_at DESC);
@@ -0,0 +1,19 @@
+CREATE TABLE IF NOT EXISTS jobs (
+    id bigserial primary key,
+    kind varchar(100) not null,
+    payload jsonb not null,
+    -- queued -> running -> succeeded, or back to queued to retry. Jobs that
+    -- run out of attempts are dead-lettered with status 'dead'.
+    status varchar(20) not null default 'queued',
+    attempts integer not null default 0,
+    max_attempts integer not null default 5 CHECK (max_attempts > 0),
+    run_at timestamp(0) with time zone not null default now(),
+    locked_until timestamp(0) with time zone,
+    last_error text not null default '',
+    created_at timestamp(0) with time zone not null default now(),
+    updated_at timestamp(0) with time zone not null default now(),
+    finished_at timestamp(0) with time zone
+);
+
+CREATE INDEX IF NOT EXISTS jobs_queued_idx ON jobs (kind, run_at) WHERE status = 'queued';
+CREATE INDEX IF NOT EXISTS jobs_running_idx ON jobs (kind, locked_until) WHERE status = 'running';
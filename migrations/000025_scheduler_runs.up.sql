@@ -0,0 +1,16 @@
+-- One row per task per scheduled slot. The unique key stops two replicas
+-- running the same slot even if their clocks tick at slightly different
+-- times.
+CREATE TABLE IF NOT EXISTS scheduler_runs (
+    id bigserial primary key,
+    task varchar(100) not null,
+    scheduled_for timestamp(0) with time zone not null,
+    status varchar(20) not null default 'running',
+    error text not null default '',
+    started_at timestamp(0) with time zone not null default now(),
+    finished_at timestamp(0) with time zone,
+
+    CONSTRAINT scheduler_runs_task_slot_key UNIQUE (task, scheduled_for)
+);
+
+CREATE INDEX IF NOT EXISTS scheduler_runs_task_idx ON scheduler_runs (task, started_at DESC);
@@ -0,0 +1,33 @@
+-- Users opt out per category. Account emails (password changes) are always
+-- sent, so they have no column here. Users without a row get the defaults.
+CREATE TABLE IF NOT EXISTS notification_preferences (
+    user_id uuid primary key,
+    order_updates boolean not null default true,
+    shipping_updates boolean not null default true,
+    version integer not null default 0,
+    updated_at timestamp(0) with time zone not null default now(),
+
+    FOREIGN KEY ("user_id") REFERENCES "users" ("id") ON DELETE CASCADE
+);
+
+-- Every email rendered for an event, kept as it was sent. The unique key
+-- makes redelivered events harmless. user_id is NULL for guest orders.
+CREATE TABLE IF NOT EXISTS notifications (
+    id uuid primary key,
+    user_id uuid,
+    event_id bigint not null,
+    kind varchar(50) not null,
+    recipient varchar(255) not null,
+    subject text not null,
+    text_body text not null,
+    html_body text not null,
+    status varchar(20) not null default 'queued',
+    last_error text not null default '',
+    sent_at timestamp(0) with time zone,
+    created_at timestamp(0) with time zone not null default now(),
+
+    FOREIGN KEY ("user_id") REFERENCES "users" ("id") ON DELETE SET NULL,
+    CONSTRAINT notifications_event_kind_recipient_key UNIQUE (event_id, kind, recipient)
+);
+
+CREATE INDEX IF NOT EXISTS notifications_user_id_idx ON notifications (user_id, created_at DESC);
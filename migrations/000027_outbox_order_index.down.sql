@@ -0,0 +1 @@
+DROP INDEX IF EXISTS outbox_order_id_idx;
@@ -0,0 +1,3 @@
+-- Order streams replay an order's events from the outbox, which finds them
+-- by the order_id carried in order and shipment payloads.
+CREATE INDEX IF NOT EXISTS outbox_order_id_idx ON outbox ((payload->>'order_id'), id);
@@ -0,0 +1,11 @@
+DROP TABLE IF EXISTS audit_log;
+
+UPDATE products SET status = 'draft' WHERE status = 'hidden';
+
+ALTER TABLE products
+    DROP CONSTRAINT IF EXISTS products_status_check,
+    ADD CONSTRAINT products_status_check CHECK (status IN ('draft', 'published', 'archived'));
+
+ALTER TABLE users
+    DROP COLUMN IF EXISTS password_reset_required,
+    DROP COLUMN IF EXISTS suspended_at;
@@ -0,0 +1,25 @@
+ALTER TABLE users
+    ADD COLUMN IF NOT EXISTS suspended_at timestamp(0) with time zone,
+    ADD COLUMN IF NOT EXISTS password_reset_required boolean not null default false;
+
+-- Hidden products were taken down by an admin. Sellers can't publish them
+-- again until an admin unhides them.
+ALTER TABLE products
+    DROP CONSTRAINT IF EXISTS products_status_check,
+    ADD CONSTRAINT products_status_check CHECK (status IN ('draft', 'published', 'archived', 'hidden'));
+
+-- Written by the admin back office. Rows are never updated or deleted.
+CREATE TABLE IF NOT EXISTS audit_log (
+    id bigserial primary key,
+    actor_id uuid,
+    action varchar(100) not null,
+    target_type varchar(50) not null,
+    target_id varchar(100) not null,
+    before jsonb,
+    after jsonb,
+    request_id varchar(100) not null default '',
+    ip varchar(100) not null default '',
+    created_at timestamp(0) with time zone not null default now()
+);
+
+CREATE INDEX IF NOT EXISTS audit_log_target_idx ON audit_log (target_type, target_id, id);
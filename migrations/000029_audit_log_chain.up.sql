@@ -0,0 +1,24 @@
+-- Each entry carries the hash of the one before it, so editing or removing
+-- a row breaks the chain from that point on. Entries written before
+-- chaining have no hash and are not checked.
+ALTER TABLE audit_log
+    ADD COLUMN IF NOT EXISTS prev_hash varchar(64) not null default '',
+    ADD COLUMN IF NOT EXISTS hash varchar(64);
+
+CREATE INDEX IF NOT EXISTS audit_log_actor_idx ON audit_log (actor_id, id);
+CREATE INDEX IF NOT EXISTS audit_log_action_idx ON audit_log (action, id);
+CREATE INDEX IF NOT EXISTS audit_log_created_at_idx ON audit_log (created_at);
+
+-- The application only ever inserts. Refuse anything else so a bug or a
+-- stray query can't rewrite history.
+CREATE OR REPLACE FUNCTION audit_log_append_only() RETURNS trigger AS $$
+BEGIN
+    RAISE EXCEPTION 'audit_log is append-only';
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER audit_log_no_update BEFORE UPDATE OR DELETE ON audit_log
+    FOR EACH ROW EXECUTE FUNCTION audit_log_append_only();
+
+CREATE TRIGGER audit_log_no_truncate BEFORE TRUNCATE ON audit_log
+    FOR EACH STATEMENT EXECUTE FUNCTION audit_log_append_only();
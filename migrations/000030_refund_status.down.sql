@@ -0,0 +1,7 @@
+DROP INDEX IF EXISTS refunds_return_id_key;
+
+ALTER TABLE refunds ALTER COLUMN provider_reference DROP DEFAULT;
+
+ALTER TABLE refunds
+    DROP COLUMN IF EXISTS issued_at,
+    DROP COLUMN IF EXISTS status;
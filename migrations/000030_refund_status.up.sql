@@ -0,0 +1,10 @@
+-- Refunds are recorded as pending with the return and paid out afterwards
+-- by a job, so a failed payout never leaves money sent but unrecorded.
+ALTER TABLE refunds
+    ADD COLUMN IF NOT EXISTS status varchar(20) not null default 'issued'
+        CHECK (status IN ('pending', 'issued')),
+    ADD COLUMN IF NOT EXISTS issued_at timestamp(0) with time zone;
+
+ALTER TABLE refunds ALTER COLUMN provider_reference SET DEFAULT '';
+
+CREATE UNIQUE INDEX IF NOT EXISTS refunds_return_id_key ON refunds (return_id) WHERE return_id IS NOT NULL;
@@ -0,0 +1,9 @@
+-- Each line's share of the order discount, as allocated at checkout. Refunds
+-- read it back instead of re-deriving it, since the rounding remainder
+-- depends on item order. Older lines stay NULL unless there was no discount.
+ALTER TABLE order_items ADD COLUMN IF NOT EXISTS discount_amount integer;
+
+UPDATE order_items oi
+SET discount_amount = 0
+FROM orders o
+WHERE o.id = oi.order_id AND o.discount_total = 0;
@@ -0,0 +1,3 @@
+-- Dispatchers lease the events they claim instead of holding row locks
+-- while subscribers run. An expired lease lets another dispatcher take over.
+ALTER TABLE outbox ADD COLUMN IF NOT EXISTS locked_until timestamp with time zone;
@@ -1,6 +1,8 @@
 package utils
 
 import (
+	"regexp"
+	"strings"
 	"time"
 
 	"github.com/go-playground/validator/v10"
@@ -9,9 +11,17 @@ import (
 
 const QueryTimeout = 5 * time.Second
 
+var MaxUploadBytes = int64(GetInt("UPLOAD_MAX_BYTES", 5<<20))
+
+// DefaultCommissionRateBps is the platform's cut of a sale, in basis points,
+// for sellers without a negotiated rate.
+var DefaultCommissionRateBps = GetInt("COMMISSION_RATE_BPS", 1000)
+
 var Logger *zap.SugaredLogger
 var Validator *validator.Validate
 
+var slugPattern = regexp.MustCompile(`^[a-z0-9]+(?:-[a-z0-9]+)*$`)
+
 var (
 	tokenExp    = time.Hour * 24
 	tokenIssuer = "ecommerce"
@@ -23,10 +33,31 @@ func init() {
 	defer Logger.Sync()
 
 	Validator = validator.New(validator.WithRequiredStructEnabled())
+	Validator.RegisterValidation("slug", func(fl validator.FieldLevel) bool {
+		return slugPattern.MatchString(fl.Field().String())
+	})
 }
 
-func AssignIfNotNil(dest *string, src *string) {
+func AssignIfNotNil[T any](dest *T, src *T) {
 	if src != nil {
 		*dest = *src
 	}
 }
+
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+// EscapeLike escapes s so LIKE and ILIKE patterns match it literally.
+func EscapeLike(s string) string {
+	return likeEscaper.Replace(s)
+}
+
+// Backoff is the wait before retrying after the given number of failed
+// attempts. It doubles each time, starting at a second and capped at an
+// hour.
+func Backoff(attempts int) time.Duration {
+	if attempts > 13 {
+		return time.Hour
+	}
+
+	return min(time.Second<<max(attempts-1, 0), time.Hour)
+}
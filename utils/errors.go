@@ -6,10 +6,33 @@ import (
 )
 
 var (
-	ErrorNotFound             = errors.New("resource not found")
-	ErrorInvalidID            = errors.New("invalid post id")
-	ErrorDuplicateEmail       = errors.New("a user with that email already exists")
-	ErrorDuplicatePhoneNumber = errors.New("duplicate phone number")
+	ErrorNotFound              = errors.New("resource not found")
+	ErrorInvalidID             = errors.New("invalid post id")
+	ErrorDuplicateEmail        = errors.New("a user with that email already exists")
+	ErrorDuplicatePhoneNumber  = errors.New("duplicate phone number")
+	ErrorDuplicateCouponCode   = errors.New("a coupon with that code already exists")
+	ErrorInvalidCoupon         = errors.New("coupon is invalid or has expired")
+	ErrorCouponUsageExceeded   = errors.New("coupon usage limit has been reached")
+	ErrorCouponNotApplicable   = errors.New("coupon does not apply to this order")
+	ErrorInvalidImageOrder     = errors.New("image ids must list every product image exactly once")
+	ErrorTooManyImages         = errors.New("product has reached the maximum number of images")
+	ErrorDuplicateReview       = errors.New("you have already reviewed this product")
+	ErrorDuplicateWishlist     = errors.New("a wishlist with that name already exists")
+	ErrorDuplicateSlug         = errors.New("a store with that slug already exists")
+	ErrorDuplicateSeller       = errors.New("you already have a store")
+	ErrorDuplicateZone         = errors.New("a shipping zone with that name already exists")
+	ErrorNoShippingOptions     = errors.New("no shipping options are available for this address")
+	ErrorInvalidShippingOption = errors.New("shipping option is not available for this order")
+	ErrorOverShipment          = errors.New("shipment quantity exceeds what is left to ship")
+	ErrorDuplicateTaxRate      = errors.New("a tax rate for that jurisdiction and class already exists")
+	ErrorOutOfStock            = errors.New("not enough stock to fulfil the order")
+	ErrorOverReturn            = errors.New("return quantity exceeds what is left to return")
+	ErrorRefundExceedsTotal    = errors.New("refund would exceed the amount paid for the order")
+	ErrorEditConflict          = errors.New("the resource was modified by another request")
+	ErrorPreconditionFailed    = errors.New("the resource has changed since it was fetched")
+	ErrorPreconditionRequired  = errors.New("an If-Match header is required")
+	ErrorAccountSuspended      = errors.New("this account has been suspended")
+	ErrorPasswordResetRequired = errors.New("you must change your password before continuing")
 )
 
 func InternalServerError(w http.ResponseWriter, r *http.Request, err error) {
@@ -29,6 +52,18 @@ func ForbiddenServerError(w http.ResponseWriter, r *http.Request) {
 
 	WriteJSONError(w, http.StatusForbidden, []string{}, "Forbidden")
 }
+
+// ForbiddenResponse is ForbiddenServerError with a reason the client can
+// act on.
+func ForbiddenResponse(w http.ResponseWriter, r *http.Request, err error) {
+	Logger.Warnw("forbidden",
+		"method", r.Method,
+		"path", r.URL.Path,
+		"error", err.Error())
+
+	WriteJSONError(w, http.StatusForbidden, []string{err.Error()}, "forbidden")
+}
+
 func BadRequestError(w http.ResponseWriter, r *http.Request, err error) {
 	Logger.Errorw("bad request",
 		"method", r.Method,
@@ -60,6 +95,38 @@ func UnAuthorizedRequestError(w http.ResponseWriter, r *http.Request, err error)
 	WriteJSONError(w, http.StatusUnauthorized, errors, err.Error())
 }
 
+func ConflictResponse(w http.ResponseWriter, r *http.Request, err error) {
+	Logger.Errorw("conflict",
+		"method", r.Method,
+		"path", r.URL.Path,
+		"error", err.Error())
+
+	WriteJSONError(w, http.StatusConflict, []string{err.Error()}, "conflict")
+}
+
+func PreconditionFailedResponse(w http.ResponseWriter, r *http.Request, err error) {
+	Logger.Errorw("precondition failed",
+		"method", r.Method,
+		"path", r.URL.Path,
+		"error", err.Error())
+
+	status := http.StatusPreconditionFailed
+	if err == ErrorPreconditionRequired {
+		status = http.StatusPreconditionRequired
+	}
+
+	WriteJSONError(w, status, []string{err.Error()}, "precondition failed")
+}
+
+func UnprocessableEntityResponse(w http.ResponseWriter, r *http.Request, err error) {
+	Logger.Errorw("unprocessable entity",
+		"method", r.Method,
+		"path", r.URL.Path,
+		"error", err.Error())
+
+	WriteJSONError(w, http.StatusUnprocessableEntity, []string{err.Error()}, "unprocessable entity")
+}
+
 func RateLimitExceededResponse(w http.ResponseWriter, r *http.Request, retryAfter string) {
 	Logger.Warnw("rate limit exceeded", "method", r.Method, "path", r.URL.Path)
 
@@ -0,0 +1,65 @@
+package utils
+
+import (
+	"net/http"
+	"strings"
+)
+
+// RequireIfMatch makes If-Match mandatory on conditional updates. It is off
+// by default so older clients keep working.
+var RequireIfMatch = GetString("REQUIRE_IF_MATCH", "false") == "true"
+
+// ETag builds an entity tag from a resource's version and anything else
+// that changes its representation without bumping the version.
+func ETag(parts ...string) string {
+	return `"` + strings.Join(parts, "-") + `"`
+}
+
+// NotModified sets the ETag header and, when the client's If-None-Match
+// already names it, answers 304. Callers stop handling the request when it
+// returns true.
+func NotModified(w http.ResponseWriter, r *http.Request, etag string) bool {
+	w.Header().Set("ETag", etag)
+
+	if matchETag(r.Header.Get("If-None-Match"), etag) {
+		w.WriteHeader(http.StatusNotModified)
+		return true
+	}
+
+	return false
+}
+
+// CheckIfMatch compares the If-Match header with the resource's current
+// tag before an update.
+func CheckIfMatch(r *http.Request, etag string) error {
+	header := r.Header.Get("If-Match")
+	if header == "" {
+		if RequireIfMatch {
+			return ErrorPreconditionRequired
+		}
+		return nil
+	}
+
+	if !matchETag(header, etag) {
+		return ErrorPreconditionFailed
+	}
+
+	return nil
+}
+
+// matchETag reports whether a comma-separated If-Match or If-None-Match
+// header lists etag. Weak tags are compared by their value.
+func matchETag(header, etag string) bool {
+	if header == "" {
+		return false
+	}
+
+	for _, tag := range strings.Split(header, ",") {
+		tag = strings.TrimPrefix(strings.TrimSpace(tag), "W/")
+		if tag == "*" || tag == etag {
+			return true
+		}
+	}
+
+	return false
+}
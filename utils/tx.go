@@ -0,0 +1,22 @@
+package utils
+
+import (
+	"context"
+	"database/sql"
+)
+
+// WithTx runs fn inside a transaction, committing on success and rolling
+// back if fn returns an error.
+func WithTx(ctx context.Context, db *sql.DB, fn func(*sql.Tx) error) error {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	if err := fn(tx); err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+
+	return tx.Commit()
+}